@@ -0,0 +1,400 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+// aliasMarker tags the lines auto-git adds to shell startup files
+const aliasMarker = "# added by auto-git alias install"
+
+var (
+	aliasShell string
+	aliasPrint bool
+)
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Set up git and shell aliases for auto-git",
+}
+
+var aliasInstallCmd = &cobra.Command{
+	Use:   "install [name] [-- auto-git arguments...]",
+	Short: "Add a git alias (git <name>, default \"ac\") and optionally a shell alias",
+	Long: `Add a git alias so "git ac" runs auto-git. Arguments after "--" are
+passed to every invocation and quoted for each shell.
+
+With --shell an alias of the same name is also added to the startup file of
+bash (~/.bashrc), zsh (~/.zshrc), fish (config.fish), or PowerShell ($PROFILE).
+--shell without a value detects the current shell. Running install again
+replaces the previous alias.`,
+	Example: `  auto-git alias install
+  auto-git alias install ac -- --review --model "qwen2.5 coder"
+  auto-git alias install gac --shell`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args = args[:dash]
+		}
+		return cobra.MaximumNArgs(1)(cmd, args)
+	},
+	Run: runAliasInstall,
+}
+
+var aliasUninstallCmd = &cobra.Command{
+	Use:   "uninstall [name]",
+	Short: "Remove the git alias and any shell alias added by install",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runAliasUninstall,
+}
+
+func init() {
+	aliasInstallCmd.Flags().StringVar(&aliasShell, "shell", "", "also add a shell alias: bash, zsh, fish, or powershell (detected when no value is given)")
+	aliasInstallCmd.Flags().Lookup("shell").NoOptDefVal = "auto"
+	aliasInstallCmd.Flags().BoolVar(&aliasPrint, "print", false, "print what would be added without changing anything")
+
+	aliasUninstallCmd.Flags().StringVar(&aliasShell, "shell", "", "also remove the shell alias from this shell's startup file (detected when no value is given)")
+	aliasUninstallCmd.Flags().Lookup("shell").NoOptDefVal = "auto"
+
+	aliasCmd.AddCommand(aliasInstallCmd)
+	aliasCmd.AddCommand(aliasUninstallCmd)
+}
+
+func aliasName(args []string) string {
+	name := "ac"
+	if len(args) > 0 {
+		name = strings.TrimSpace(args[0])
+	}
+	if name == "" || strings.ContainsAny(name, " \t'\"=/\\") {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error: invalid alias name %q", name))
+		exit(ExitError)
+	}
+	return name
+}
+
+func runAliasInstall(cmd *cobra.Command, args []string) {
+	var extra []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		args, extra = args[:dash], args[dash:]
+	}
+	name := aliasName(args)
+	exe := autoGitExecutable()
+
+	gitValue := "!" + strings.Join(append([]string{git.ShellQuote(filepath.ToSlash(exe))}, shQuoteAll(extra)...), " ")
+	var shell, rcFile, shellLine string
+	if aliasShell != "" {
+		shell = detectShell(aliasShell)
+		var err error
+		rcFile, err = shellRCFile(shell)
+		if err != nil {
+			printError(err)
+			exit(ExitError)
+		}
+		shellLine = shellAlias(shell, name, exe, extra)
+	}
+
+	if aliasPrint {
+		fmt.Printf("git config --global alias.%s %s\n", name, git.ShellQuote(gitValue))
+		if shellLine != "" {
+			fmt.Printf("%s: %s\n", rcFile, shellLine)
+		}
+		return
+	}
+
+	if existing, err := git.GetGlobalConfig("alias." + name); err == nil && existing != "" && existing != gitValue {
+		fmt.Println(i18n.Sprintf("Replacing existing git alias %s: %s", name, existing))
+	}
+	if err := git.SetGlobalConfig("alias."+name, gitValue); err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	fmt.Println(i18n.Sprintf("Added git alias: git %s", name))
+
+	if shellLine != "" {
+		if err := writeShellAlias(rcFile, name, shellLine); err != nil {
+			printError(err)
+			exit(ExitError)
+		}
+		fmt.Println(i18n.Sprintf("Added %s alias %s to %s (open a new shell to use it)", shell, name, rcFile))
+	}
+}
+
+func runAliasUninstall(cmd *cobra.Command, args []string) {
+	name := aliasName(args)
+
+	existing, err := git.GetGlobalConfig("alias." + name)
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	switch {
+	case existing == "":
+		fmt.Println(i18n.Sprintf("No git alias %s is set", name))
+	case !runsAutoGit(existing):
+		fmt.Println(i18n.Sprintf("Keeping git alias %s, which was not added by auto-git: %s", name, existing))
+	default:
+		if err := git.UnsetGlobalConfig("alias." + name); err != nil {
+			printError(err)
+			exit(ExitError)
+		}
+		fmt.Println(i18n.Sprintf("Removed git alias %s", name))
+	}
+
+	if aliasShell != "" {
+		shell := detectShell(aliasShell)
+		rcFile, err := shellRCFile(shell)
+		if err != nil {
+			printError(err)
+			exit(ExitError)
+		}
+		removed, err := removeShellAlias(rcFile, name)
+		if err != nil {
+			printError(err)
+			exit(ExitError)
+		}
+		if removed {
+			fmt.Println(i18n.Sprintf("Removed %s alias %s from %s", shell, name, rcFile))
+		}
+	}
+}
+
+// runsAutoGit reports whether the git alias value runs auto-git, as the ones
+// added by install do: this executable, or one named auto-git wherever it was
+// at the time
+func runsAutoGit(value string) bool {
+	command, ok := strings.CutPrefix(value, "!")
+	if !ok {
+		return false
+	}
+	exe := firstShellWord(strings.TrimSpace(command))
+	if exe == filepath.ToSlash(autoGitExecutable()) {
+		return true
+	}
+	return strings.TrimSuffix(path.Base(exe), ".exe") == "auto-git"
+}
+
+// firstShellWord returns the first word of a POSIX shell command line,
+// without its quotes
+func firstShellWord(line string) string {
+	var word strings.Builder
+	quote := rune(0)
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' {
+				escaped = true
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '\\':
+			escaped = true
+		case r == ' ' || r == '\t' || r == '\n':
+			return word.String()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	return word.String()
+}
+
+// autoGitExecutable returns "auto-git" when that resolves to this binary on
+// PATH, and the absolute path of the running executable otherwise
+func autoGitExecutable() string {
+	self, err := os.Executable()
+	if err != nil {
+		return "auto-git"
+	}
+	self, _ = filepath.EvalSymlinks(self)
+	if onPath, err := exec.LookPath("auto-git"); err == nil {
+		if resolved, err := filepath.EvalSymlinks(onPath); err == nil && resolved == self {
+			return "auto-git"
+		}
+	}
+	return self
+}
+
+// detectShell resolves "auto" to the current shell
+func detectShell(shell string) string {
+	shell = strings.ToLower(strings.TrimSpace(shell))
+	if shell != "auto" {
+		return shell
+	}
+	if runtime.GOOS == "windows" {
+		return "powershell"
+	}
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case "zsh":
+		return "zsh"
+	case "fish":
+		return "fish"
+	case "pwsh":
+		return "powershell"
+	default:
+		return "bash"
+	}
+}
+
+// shellRCFile returns the startup file where shell aliases are defined
+func shellRCFile(shell string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	switch shell {
+	case "bash":
+		return filepath.Join(home, ".bashrc"), nil
+	case "zsh":
+		if dir := os.Getenv("ZDOTDIR"); dir != "" {
+			return filepath.Join(dir, ".zshrc"), nil
+		}
+		return filepath.Join(home, ".zshrc"), nil
+	case "fish":
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			configHome = filepath.Join(home, ".config")
+		}
+		return filepath.Join(configHome, "fish", "config.fish"), nil
+	case "powershell":
+		if runtime.GOOS == "windows" {
+			return filepath.Join(home, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1"), nil
+		}
+		return filepath.Join(home, ".config", "powershell", "Microsoft.PowerShell_profile.ps1"), nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q (supported: bash, zsh, fish, powershell)", shell)
+	}
+}
+
+// shellAlias renders the alias definition for shell, quoted for that shell
+func shellAlias(shell, name, exe string, extra []string) string {
+	switch shell {
+	case "fish":
+		words := append([]string{fishQuote(exe)}, fishQuoteAll(extra)...)
+		return fmt.Sprintf("alias %s %s %s", name, fishQuote(strings.Join(words, " ")), aliasMarker)
+	case "powershell":
+		// PowerShell aliases cannot carry arguments, so define a function
+		words := append([]string{psQuote(exe)}, psQuoteAll(extra)...)
+		return fmt.Sprintf("function %s { & %s @args } %s", name, strings.Join(words, " "), aliasMarker)
+	default:
+		words := append([]string{git.ShellQuote(exe)}, shQuoteAll(extra)...)
+		return fmt.Sprintf("alias %s=%s %s", name, git.ShellQuote(strings.Join(words, " ")), aliasMarker)
+	}
+}
+
+// writeShellAlias adds line to rcFile, replacing an earlier alias of the same name
+func writeShellAlias(rcFile, name, line string) error {
+	lines, err := readLines(rcFile)
+	if err != nil {
+		return err
+	}
+	lines = withoutAlias(lines, name)
+	lines = append(lines, line)
+
+	if err := os.MkdirAll(filepath.Dir(rcFile), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(rcFile), err)
+	}
+	return os.WriteFile(rcFile, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// removeShellAlias deletes the alias added by install from rcFile
+func removeShellAlias(rcFile, name string) (bool, error) {
+	lines, err := readLines(rcFile)
+	if err != nil || len(lines) == 0 {
+		return false, err
+	}
+	kept := withoutAlias(lines, name)
+	if len(kept) == len(lines) {
+		return false, nil
+	}
+	content := ""
+	if len(kept) > 0 {
+		content = strings.Join(kept, "\n") + "\n"
+	}
+	return true, os.WriteFile(rcFile, []byte(content), 0644)
+}
+
+func readLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n"), nil
+}
+
+// withoutAlias drops the lines auto-git added for name
+func withoutAlias(lines []string, name string) []string {
+	var kept []string
+	for _, line := range lines {
+		if strings.HasSuffix(line, aliasMarker) && definesAlias(line, name) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
+
+func definesAlias(line, name string) bool {
+	for _, prefix := range []string{"alias " + name + "=", "alias " + name + " ", "function " + name + " "} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func shQuoteAll(words []string) []string {
+	out := make([]string, len(words))
+	for i, w := range words {
+		out[i] = git.ShellQuote(w)
+	}
+	return out
+}
+
+// fishQuote quotes s for fish, where only \ and ' are special inside single quotes
+func fishQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$*?{}()<>|&;#~") {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func fishQuoteAll(words []string) []string {
+	out := make([]string, len(words))
+	for i, w := range words {
+		out[i] = fishQuote(w)
+	}
+	return out
+}
+
+// psQuote quotes s for PowerShell, where a single quote is escaped by doubling it
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func psQuoteAll(words []string) []string {
+	out := make([]string, len(words))
+	for i, w := range words {
+		out[i] = psQuote(w)
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"auto-git/internal/logging"
+)
+
+// batchOut receives the JSON log of a --batch run; nil otherwise
+var batchOut io.Writer
+
+// exitReasons name the exit codes in the JSON log of --batch runs
+var exitReasons = map[int]string{
+	ExitError:               "error",
+	ExitNoChanges:           "no_changes",
+	ExitProviderUnreachable: "provider_unreachable",
+	ExitGenerationFailed:    "generation_failed",
+	ExitCommitFailed:        "commit_failed",
+	ExitPushFailed:          "push_failed",
+	ExitCancelled:           "cancelled",
+	ExitVerifyFailed:        "verify_failed",
+	ExitInterrupted:         "interrupted",
+}
+
+// enterBatchMode keeps stdout for the JSON log and sends everything meant
+// for people to stderr, so that scheduled jobs can parse stdout line by line
+func enterBatchMode() {
+	batchOut = os.Stdout
+	os.Stdout = os.Stderr
+	statusOut = os.Stderr
+}
+
+// logExit records why a --batch run is exiting
+func logExit(code int) {
+	if batchOut == nil {
+		return
+	}
+	if code == ExitOK {
+		logging.Info("exit", "code", code)
+		return
+	}
+	logging.Error("exit", "code", code, "reason", exitReasons[code])
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"auto-git/internal/config"
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/ui"
+	"auto-git/pkg/autogit"
+
+	"github.com/spf13/cobra"
+)
+
+// sampleDiff is the fixed change used by benchmark so results are comparable
+// across runs and machines
+const sampleDiff = `diff --git a/internal/cache/cache.go b/internal/cache/cache.go
+index 3b18e51..a9d4c2f 100644
+--- a/internal/cache/cache.go
++++ b/internal/cache/cache.go
+@@ -1,19 +1,32 @@
+ package cache
+ 
+-import "sync"
++import (
++	"sync"
++	"time"
++)
+ 
+ type Cache struct {
+ 	mu    sync.Mutex
+-	items map[string]string
++	items map[string]entry
++	ttl   time.Duration
+ }
+ 
+-func New() *Cache {
+-	return &Cache{items: make(map[string]string)}
++type entry struct {
++	value   string
++	expires time.Time
++}
++
++func New(ttl time.Duration) *Cache {
++	return &Cache{items: make(map[string]entry), ttl: ttl}
+ }
+ 
+ func (c *Cache) Get(key string) (string, bool) {
+ 	c.mu.Lock()
+ 	defer c.mu.Unlock()
+-	v, ok := c.items[key]
+-	return v, ok
++	e, ok := c.items[key]
++	if !ok || time.Now().After(e.expires) {
++		delete(c.items, key)
++		return "", false
++	}
++	return e.value, true
+ }
+`
+
+var benchmarkCurrent bool
+
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark [model...]",
+	Short: "Compare models on the same diff",
+	Long: `Generate a commit message with each given model (or the configured model)
+and report latency, token usage, and the messages side by side.
+
+A fixed sample diff is used by default so results are comparable; pass
+--current to benchmark against the changes in the current repository.`,
+	ValidArgsFunction: completeModels,
+	Run:               runBenchmark,
+}
+
+func init() {
+	benchmarkCmd.Flags().BoolVar(&benchmarkCurrent, "current", false, "use the current repository changes instead of the sample diff")
+}
+
+type benchmarkResult struct {
+	model            string
+	latency          time.Duration
+	promptTokens     int
+	completionTokens int
+	message          string
+	err              error
+}
+
+func runBenchmark(cmd *cobra.Command, args []string) {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+
+	models := modelArgs(args, cfg.Model)
+
+	var changes *git.Changes
+	var diffContent string
+	if benchmarkCurrent {
+		changes, diffContent, err = readRepoChanges()
+	} else {
+		diffContent = sampleDiff
+		changes, err = git.ParsePatch(sampleDiff)
+	}
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+
+	prov := connectProvider(cfg)
+	redactor := promptRedactor(cfg)
+
+	results := make([]benchmarkResult, 0, len(models))
+	for _, model := range models {
+		engine, err := autogit.New(autogit.WithProvider(prov), autogit.WithModel(model), autogit.WithRedactor(redactor))
+		if err != nil {
+			results = append(results, benchmarkResult{model: model, err: err})
+			continue
+		}
+		systemPrompt, userPrompt := engine.BuildPrompt(changes, diffContent)
+
+		// Run sequentially so models do not compete for the same server
+		spinner := ui.NewSpinner(i18n.Sprintf("Generating with %s...", model))
+		start := time.Now()
+		completion, err := prov.Generate(model, systemPrompt, userPrompt)
+		result := benchmarkResult{model: model, latency: time.Since(start), err: err}
+		spinner.Stop()
+
+		if err == nil {
+			result.message, result.err = autogit.Validate(completion.Content)
+			result.promptTokens = completion.Usage.PromptTokens
+			result.completionTokens = completion.Usage.CompletionTokens
+		}
+		results = append(results, result)
+	}
+
+	printBenchmarkResults(results, cfg.Provider)
+}
+
+// modelArgs reads the models given as arguments, each of which may be a
+// comma-separated list, falling back to the configured model
+func modelArgs(args []string, configured string) []string {
+	var models []string
+	for _, arg := range args {
+		for _, name := range strings.Split(arg, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				models = append(models, name)
+			}
+		}
+	}
+	if len(models) == 0 {
+		models = []string{configured}
+	}
+	return models
+}
+
+func printBenchmarkResults(results []benchmarkResult, providerName string) {
+	// Picks made with --compare show how the models fare on real changes
+	stats, _ := config.LoadModelStats()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tLATENCY\tPROMPT TOKENS\tCOMPLETION TOKENS\tPICKED\tMESSAGE")
+	for _, r := range results {
+		picked := formatPicks(stats[config.ModelStatsKey(providerName, r.model)])
+		if r.err != nil {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t%s\terror: %v\n", r.model, r.latency.Round(time.Millisecond), picked, r.err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.model, r.latency.Round(time.Millisecond),
+			formatTokens(r.promptTokens), formatTokens(r.completionTokens), picked, r.message)
+	}
+	w.Flush()
+}
+
+// formatPicks shows how often a model's message was picked with --compare,
+// e.g. "3/5", or "-" when it was never compared
+func formatPicks(entry config.ModelStatsEntry) string {
+	if entry.Compared == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d", entry.Wins, entry.Compared)
+}
+
+// formatTokens shows "-" when the provider did not report usage
+func formatTokens(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", n)
+}
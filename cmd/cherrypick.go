@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var cherryPickCmd = &cobra.Command{
+	Use:   "cherry-pick <commit>",
+	Short: "Cherry-pick a commit with a message written for the current branch",
+	Long: `Cherry-pick a commit (git cherry-pick --no-commit), generate a message from
+the changes as they apply to the current branch, with the original message as
+context, and commit and push like a normal run.
+
+The original author and date are kept, and the message ends with
+"(cherry picked from commit <hash>)" as git cherry-pick -x writes it.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runCherryPick,
+}
+
+func runCherryPick(cmd *cobra.Command, args []string) {
+	lockRepository()
+
+	commit, err := git.ResolveCommit(args[0])
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	originalMessage, err := git.CommitMessageOf(commit)
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	originalSubject, _, _ := strings.Cut(originalMessage, "\n")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+
+	if err := git.CherryPickNoCommit(commit); err != nil {
+		printError(err)
+		exit(ExitCommitFailed)
+	}
+	// Until the cherry-pick is committed, leaving means undoing it
+	committed := false
+	atExit(func() {
+		if committed {
+			return
+		}
+		if err := git.AbortCherryPick(); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: %v", err))
+		}
+	})
+
+	// Describe the change as it lands here, which may differ from the original
+	changes, diffContent, err := git.CollectStaged(".", git.PathFilter{})
+	if err != nil {
+		if err == git.ErrNoChanges {
+			err = fmt.Errorf("%.12s is already applied to this branch", commit)
+		}
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+
+	fmt.Fprintln(statusOut, i18n.Sprintf("Cherry-picking %.7s %s", commit, originalSubject))
+	fmt.Fprintln(statusOut, changes.Summary)
+	fmt.Fprintln(statusOut)
+
+	target := git.CurrentBranch()
+	if target == "" {
+		target = "a detached HEAD"
+	}
+	changes.Context = fmt.Sprintf("These changes are cherry-picked onto %s from commit %.12s, whose message was:\n%s", target, commit, originalMessage)
+
+	message := generateMessage(cfg, changes, diffContent)
+	if strings.TrimSpace(message) == "" {
+		message = originalSubject
+	}
+	if cfg.Review {
+		message = reviewMessage(cfg, message, diffContent, changes, cfg.UseEditor)
+	}
+	message += fmt.Sprintf("\n\n(cherry picked from commit %s)", commit)
+	if !cfg.Review {
+		fmt.Printf("\n%s\n%s\n\n", i18n.T("Generated commit message:"), message)
+		for _, trailer := range commitTrailers(cfg) {
+			fmt.Println(trailer)
+		}
+		fmt.Println(i18n.T("Proceeding with commit and push..."))
+	}
+
+	opts := commitOptions(cfg)
+	opts.Paths = git.PathFilter{}
+	if author, date, err := git.AuthorOf(commit); err == nil {
+		if opts.Author == "" {
+			opts.Author = author
+		}
+		if opts.Date == "" {
+			opts.Date = date
+		}
+	}
+	if err := git.CommitWith(message, opts); err != nil {
+		printError(err)
+		exit(ExitCommitFailed)
+	}
+	committed = true
+
+	publishCommit(cfg)
+}
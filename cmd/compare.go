@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"auto-git/internal/config"
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+	"auto-git/internal/provider"
+	"auto-git/internal/ui"
+	"auto-git/pkg/autogit"
+)
+
+// compareFlag lists the models --compare generates a message with
+var compareFlag string
+
+type compareResult struct {
+	model   string
+	latency time.Duration
+	message string
+	err     error
+}
+
+// compareModels returns the models given to --compare
+func compareModels() []string {
+	var models []string
+	for _, name := range strings.Split(compareFlag, ",") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(models, name) {
+			models = append(models, name)
+		}
+	}
+	return models
+}
+
+// compareMessages generates a message with each model at once, shows them
+// side by side and returns the one the user picks, counting the pick among the
+// models that produced a message in the model stats. Without a user to ask,
+// the first message is used and nothing is counted.
+func compareMessages(prov provider.Provider, cfg *config.Config, models []string, changes *git.Changes, diffContent string) string {
+	if skip, reason := canSkipValidation(cfg); skip {
+		logging.Debug("skipping connection check", "reason", reason)
+		if err := breakerError(cfg); err != nil {
+			return fallbackMessage(cfg, changes, err)
+		}
+	} else if err := pingProvider(prov, cfg); err != nil {
+		return fallbackMessage(cfg, changes, err)
+	}
+
+	engines := make([]*autogit.Engine, len(models))
+	for i, model := range models {
+		engine, err := newEngine(prov, cfg, model)
+		if err != nil {
+			printError(err)
+			exit(ExitError)
+		}
+		warnContextOverflow(model, engine.PromptTokens(changes, diffContent), engine.ContextWindow())
+		engines[i] = engine
+	}
+
+	fmt.Fprintln(statusOut, i18n.Sprintf("Using provider: %s, models: %s", cfg.Provider, strings.Join(models, ", ")))
+	results := make([]compareResult, len(models))
+	spinner := ui.NewSpinner(i18n.Sprintf("Generating commit messages with %d models...", len(models)))
+	var wg sync.WaitGroup
+	for i, engine := range engines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			message, err := engine.Generate(changes, diffContent)
+			results[i] = compareResult{model: models[i], latency: time.Since(start), message: message, err: err}
+		}()
+	}
+	wg.Wait()
+	spinner.Stop()
+
+	var candidates []ui.Candidate
+	var picks []compareResult
+	for _, r := range results {
+		logging.Debug("generation finished", "provider", cfg.Provider, "model", r.model, "duration", r.latency, "error", r.err)
+		if r.err == nil && strings.TrimSpace(r.message) == "" {
+			r.err = autogit.ErrEmptyMessage
+		}
+		if r.err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: %s failed: %v", r.model, r.err))
+			continue
+		}
+		candidates = append(candidates, ui.Candidate{
+			Label:   fmt.Sprintf("%s, %s", r.model, r.latency.Round(time.Millisecond)),
+			Message: r.message,
+		})
+		picks = append(picks, r)
+	}
+
+	if len(candidates) == 0 {
+		for _, r := range results {
+			if !errors.Is(r.err, autogit.ErrEmptyMessage) && !errors.Is(r.err, autogit.ErrNotConventional) {
+				fmt.Fprintln(os.Stderr, i18n.Sprintf("Error generating commit message: %v", r.err))
+				exit(ExitGenerationFailed)
+			}
+		}
+		// Let the user write the message, as for a single model
+		return ""
+	}
+	if !ui.IsInteractive() {
+		return candidates[0].Message
+	}
+
+	choice, err := ui.SelectMessage(candidates)
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+	compared := make([]string, len(picks))
+	for i, r := range picks {
+		compared[i] = r.model
+	}
+	winner := picks[choice].model
+	logging.Info("compared models", "provider", cfg.Provider, "models", compared, "winner", winner)
+	if err := config.RecordComparison(cfg.Provider, compared, winner); err != nil {
+		logging.Debug("failed to record comparison", "error", err)
+	}
+	rememberMessage(cfg.Provider, winner, candidates[choice].Message)
+	return candidates[choice].Message
+}
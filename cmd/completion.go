@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"auto-git/internal/config"
+	"auto-git/pkg/autogit"
+
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate a shell completion script",
+	Long: `Generate a shell completion script for auto-git.
+
+Bash:
+  source <(auto-git completion bash)
+  # or install permanently:
+  auto-git completion bash > /etc/bash_completion.d/auto-git
+
+Zsh:
+  auto-git completion zsh > "${fpath[1]}/_auto-git"
+
+Fish:
+  auto-git completion fish > ~/.config/fish/completions/auto-git.fish
+
+PowerShell:
+  auto-git completion powershell | Out-String | Invoke-Expression`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch args[0] {
+		case "bash":
+			err = rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			err = rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			err = rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			err = rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		if err != nil {
+			cmd.PrintErrf("Error generating completion: %v\n", err)
+			exit(ExitError)
+		}
+	},
+}
+
+// completeProviders completes the names of the supported providers
+func completeProviders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return filterPrefix(autogit.AvailableProviders(), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeModels completes model names from the cache of the selected provider
+func completeModels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	providerName := providerFlag
+	if providerName == "" {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		providerName = cfg.Provider
+	}
+	return filterPrefix(config.CachedModels(providerName), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func filterPrefix(candidates []string, prefix string) []string {
+	var out []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
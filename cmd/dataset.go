@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"auto-git/internal/dataset"
+	"auto-git/internal/eval"
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/prompt"
+	"auto-git/internal/tokenizer"
+	"auto-git/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	datasetFormat       string
+	datasetCommits      int
+	datasetMaxDiff      int
+	datasetOutput       string
+	datasetConventional bool
+)
+
+var exportDatasetCmd = &cobra.Command{
+	Use:   "export-dataset",
+	Short: "Export past commits as fine-tuning examples",
+	Long: `Walk the history of the current repository and write one JSON line per
+commit: the prompt auto-git would build for its diff, and the subject the
+commit was written with. The file can be used to fine-tune a small local
+model on the repository's own style.
+
+Formats:
+  openai   {"messages": [system, user, assistant]} for OpenAI fine-tuning
+  axolotl  {"instruction", "input", "output"}, axolotl's alpaca type
+
+Diffs longer than --max-diff-tokens are shortened the same way as for a
+model's context window, and the redact settings are applied. Merges, root
+commits and empty commits are skipped.`,
+	Args: cobra.NoArgs,
+	Run:  runExportDataset,
+}
+
+func init() {
+	exportDatasetCmd.Flags().StringVar(&datasetFormat, "format", string(dataset.OpenAI), "output format: openai or axolotl")
+	exportDatasetCmd.Flags().IntVar(&datasetCommits, "commits", 1000, "number of recent commits to export")
+	exportDatasetCmd.Flags().IntVar(&datasetMaxDiff, "max-diff-tokens", 2000, "shorten diffs to about this many tokens")
+	exportDatasetCmd.Flags().StringVarP(&datasetOutput, "output", "o", "", "write to this file instead of stdout")
+	exportDatasetCmd.Flags().BoolVar(&datasetConventional, "conventional", false, "only export commits whose subject uses one of the prompt's types")
+}
+
+func runExportDataset(cmd *cobra.Command, args []string) {
+	format, err := dataset.ParseFormat(datasetFormat)
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	if datasetCommits <= 0 || datasetMaxDiff <= 0 {
+		printError(fmt.Errorf("--commits and --max-diff-tokens must be positive"))
+		exit(ExitError)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+	redactor := promptRedactor(cfg)
+
+	commits, err := git.RecentCommits(datasetCommits)
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+	if len(commits) == 0 {
+		printError(fmt.Errorf("no commits to export"))
+		exit(ExitError)
+	}
+
+	var out io.Writer = os.Stdout
+	if datasetOutput != "" {
+		f, err := os.Create(datasetOutput)
+		if err != nil {
+			printError(err)
+			exit(ExitError)
+		}
+		defer f.Close()
+		out = f
+	}
+	w := dataset.NewWriter(out, format)
+
+	count := tokenizer.Heuristic.Count
+	written, skipped := 0, 0
+	spinner := ui.NewSpinner(i18n.T("Exporting commits..."))
+	for i, commit := range commits {
+		spinner.SetDetail(fmt.Sprintf("%d/%d", i+1, len(commits)))
+		example, ok, err := datasetExample(commit, redactor, count)
+		if err != nil {
+			spinner.Stop()
+			printError(err)
+			exit(exitCodeFor(err, ExitError))
+		}
+		if !ok {
+			skipped++
+			continue
+		}
+		if err := w.Write(example); err != nil {
+			spinner.Stop()
+			printError(err)
+			exit(ExitError)
+		}
+		written++
+	}
+	spinner.Stop()
+
+	target := "stdout"
+	if datasetOutput != "" {
+		target = datasetOutput
+	}
+	fmt.Fprintln(os.Stderr, i18n.Sprintf("Wrote %d example(s) to %s, skipped %d commit(s)", written, target, skipped))
+}
+
+// datasetExample builds the example for commit. It returns false for
+// commits that are left out: empty ones, and with --conventional those
+// whose subject has no allowed type.
+func datasetExample(commit string, redactor func(string) string, count func(string) int) (dataset.Example, bool, error) {
+	message, err := git.CommitMessageOf(commit)
+	if err != nil {
+		return dataset.Example{}, false, err
+	}
+	subject, _, _ := strings.Cut(message, "\n")
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return dataset.Example{}, false, nil
+	}
+	if typ, _ := eval.Split(subject); datasetConventional && !prompt.IsCommitType(typ) {
+		return dataset.Example{}, false, nil
+	}
+
+	changes, diffContent, err := git.DiffBetween(commit+"^", commit)
+	if errors.Is(err, git.ErrNoChanges) {
+		return dataset.Example{}, false, nil
+	}
+	if err != nil {
+		return dataset.Example{}, false, err
+	}
+	diffContent = prompt.FitDiff(redactor(diffContent), datasetMaxDiff, count)
+	systemPrompt, userPrompt := prompt.BuildFullPrompt(changes, diffContent)
+	return dataset.Example{SystemPrompt: systemPrompt, UserPrompt: userPrompt, Message: subject}, true, nil
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"auto-git/internal/eval"
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/ui"
+	"auto-git/pkg/autogit"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	evalCommits int
+	evalPrompts []string
+	evalDetails bool
+)
+
+var evalCmd = &cobra.Command{
+	Use:   "eval [model...]",
+	Short: "Score models and prompts against the repository history",
+	Long: `Replay recent commits of the current repository through each given model
+(or the configured model) and each prompt, and score the generated messages
+against the ones the commits were written with:
+
+  TYPE       share of conventional commits whose type was matched
+             ("-" when none of the commits is conventional)
+  SIMILAR    mean share of words the subjects have in common
+  LENGTH OK  share of subjects within 72 characters
+
+The built-in system prompt is always evaluated as "default". Add prompt
+versions to compare with --prompt name=path, where the file holds a system
+prompt. Merges, root commits and empty commits are skipped.`,
+	ValidArgsFunction: completeModels,
+	Run:               runEval,
+}
+
+func init() {
+	evalCmd.Flags().IntVar(&evalCommits, "commits", 20, "number of recent commits to replay")
+	evalCmd.Flags().StringArrayVar(&evalPrompts, "prompt", nil, "system prompt to compare, as name=path (repeatable)")
+	evalCmd.Flags().BoolVar(&evalDetails, "details", false, "print every generated message next to the real one")
+}
+
+// evalPrompt is a system prompt under evaluation; the default one is empty
+type evalPrompt struct {
+	name string
+	text string
+}
+
+// evalCase is a commit replayed by eval
+type evalCase struct {
+	commit      string
+	message     string
+	changes     *git.Changes
+	diffContent string
+}
+
+// evalVariant is one model with one prompt, and its results in case order
+type evalVariant struct {
+	model   string
+	prompt  string
+	results []eval.Result
+}
+
+func runEval(cmd *cobra.Command, args []string) {
+	if evalCommits <= 0 {
+		printError(fmt.Errorf("--commits must be positive"))
+		exit(ExitError)
+	}
+	prompts, err := readEvalPrompts(evalPrompts)
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+	models := modelArgs(args, cfg.Model)
+
+	cases, err := readEvalCases(evalCommits)
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+	if len(cases) == 0 {
+		printError(fmt.Errorf("no commits to evaluate"))
+		exit(ExitError)
+	}
+
+	prov := connectProvider(cfg)
+	redactor := promptRedactor(cfg)
+
+	var variants []*evalVariant
+	for _, model := range models {
+		for _, p := range prompts {
+			v := &evalVariant{model: model, prompt: p.name}
+			variants = append(variants, v)
+
+			engine, err := autogit.New(autogit.WithProvider(prov), autogit.WithModel(model),
+				autogit.WithRedactor(redactor), autogit.WithSystemPrompt(p.text))
+			if err != nil {
+				for _, c := range cases {
+					v.results = append(v.results, eval.Result{Commit: c.commit, Err: err})
+				}
+				continue
+			}
+
+			// Run sequentially so variants do not compete for the same server
+			spinner := ui.NewSpinner(i18n.Sprintf("Evaluating %s with the %s prompt...", model, p.name))
+			for i, c := range cases {
+				spinner.SetDetail(fmt.Sprintf("%d/%d", i+1, len(cases)))
+				start := time.Now()
+				message, err := engine.Generate(c.changes, c.diffContent)
+				result := eval.Result{Commit: c.commit, Message: message, Latency: time.Since(start), Err: err}
+				if err == nil {
+					result.Score = eval.Rate(message, c.message, ui.SubjectLimit)
+				}
+				v.results = append(v.results, result)
+			}
+			spinner.Stop()
+		}
+	}
+
+	if evalDetails {
+		printEvalDetails(cases, variants)
+	}
+	printEvalSummary(len(cases), variants)
+}
+
+// readEvalPrompts returns the default prompt followed by the prompts given
+// as name=path
+func readEvalPrompts(specs []string) ([]evalPrompt, error) {
+	prompts := []evalPrompt{{name: "default"}}
+	for _, spec := range specs {
+		name, path, ok := strings.Cut(spec, "=")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("--prompt %q: expected name=path", spec)
+		}
+		for _, p := range prompts {
+			if p.name == name {
+				return nil, fmt.Errorf("--prompt %q: the name %s is already used", spec, name)
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("--prompt %s: %w", name, err)
+		}
+		text := strings.TrimSpace(string(data))
+		if text == "" {
+			return nil, fmt.Errorf("--prompt %s: %s is empty", name, path)
+		}
+		prompts = append(prompts, evalPrompt{name: name, text: text})
+	}
+	return prompts, nil
+}
+
+// readEvalCases collects the diff and message of up to limit recent commits
+func readEvalCases(limit int) ([]evalCase, error) {
+	commits, err := git.RecentCommits(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	cases := make([]evalCase, 0, len(commits))
+	for _, commit := range commits {
+		changes, diffContent, err := git.DiffBetween(commit+"^", commit)
+		if errors.Is(err, git.ErrNoChanges) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		message, err := git.CommitMessageOf(commit)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, evalCase{commit: commit, message: message, changes: changes, diffContent: diffContent})
+	}
+	return cases, nil
+}
+
+func printEvalSummary(cases int, variants []*evalVariant) {
+	fmt.Printf("%s\n\n", i18n.Sprintf("%d commit(s) evaluated", cases))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tPROMPT\tTYPE\tSIMILAR\tLENGTH OK\tERRORS\tLATENCY")
+	for _, v := range variants {
+		s := eval.Summarize(v.results)
+		if s.Errors == s.Cases {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t-\t%d\t-\n", v.model, v.prompt, s.Errors)
+			continue
+		}
+		typeAccuracy := "-"
+		if s.Typed > 0 {
+			typeAccuracy = formatPercent(s.TypeAccuracy)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", v.model, v.prompt, typeAccuracy,
+			formatPercent(s.Similarity), formatPercent(s.LengthCompliance), s.Errors, s.Latency.Round(time.Millisecond))
+	}
+	w.Flush()
+}
+
+// printEvalDetails lists, per commit, the real subject and what each
+// variant generated
+func printEvalDetails(cases []evalCase, variants []*evalVariant) {
+	for i, c := range cases {
+		subject, _, _ := strings.Cut(c.message, "\n")
+		fmt.Printf("%.7s %s\n", c.commit, subject)
+		for _, v := range variants {
+			r := v.results[i]
+			if r.Err != nil {
+				fmt.Println(i18n.Sprintf("  %s/%s: error: %v", v.model, v.prompt, r.Err))
+				continue
+			}
+			mark := " "
+			if r.Score.TypeMatch {
+				mark = "✓"
+			}
+			fmt.Printf("  %s %s/%s (%s): %s\n", mark, v.model, v.prompt, formatPercent(r.Score.Similarity), r.Message)
+		}
+		fmt.Println()
+	}
+}
+
+// formatPercent shows a share from 0 to 1 as a whole percentage
+func formatPercent(share float64) string {
+	return fmt.Sprintf("%.0f%%", share*100)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+	"auto-git/internal/ui"
+	"auto-git/internal/verify"
+)
+
+// Exit codes returned by auto-git so that wrappers and hooks can branch on
+// the kind of failure
+const (
+	ExitOK                  = 0
+	ExitError               = 1   // unclassified error (bad config, invalid arguments, ...)
+	ExitNoChanges           = 2   // nothing to commit
+	ExitProviderUnreachable = 3   // the LLM provider could not be reached
+	ExitGenerationFailed    = 4   // the provider failed to produce a commit message
+	ExitCommitFailed        = 5   // staging or committing failed
+	ExitPushFailed          = 6   // the commit was created but pushing it failed
+	ExitCancelled           = 7   // the user cancelled an interactive prompt
+	ExitVerifyFailed        = 8   // the commit was created but verify_command failed, so it was not pushed
+	ExitInterrupted         = 130 // interrupted by SIGINT or SIGTERM, as shells report Ctrl-C
+)
+
+// exitCodeFor maps well-known errors to their exit code, falling back to fallback
+func exitCodeFor(err error, fallback int) int {
+	switch {
+	case errors.Is(err, git.ErrNoChanges):
+		return ExitNoChanges
+	case errors.Is(err, git.ErrPushFailed):
+		return ExitPushFailed
+	case errors.Is(err, ui.ErrCancelled):
+		return ExitCancelled
+	case errors.Is(err, verify.ErrFailed):
+		return ExitVerifyFailed
+	default:
+		return fallback
+	}
+}
+
+// cleanups undo temporary changes to the repository, such as stashed files,
+// before auto-git exits
+var (
+	cleanups   []func()
+	cleanupsMu sync.Mutex
+)
+
+// atExit registers f to run before auto-git exits, in reverse order
+func atExit(f func()) {
+	cleanupsMu.Lock()
+	defer cleanupsMu.Unlock()
+	cleanups = append(cleanups, f)
+}
+
+// runCleanups runs the registered cleanups once; a second caller, such as
+// the signal handler, waits until they are done
+func runCleanups() {
+	cleanupsMu.Lock()
+	defer cleanupsMu.Unlock()
+	for len(cleanups) > 0 {
+		f := cleanups[len(cleanups)-1]
+		cleanups = cleanups[:len(cleanups)-1]
+		f()
+	}
+}
+
+// exitOnSignal runs the cleanups and exits when auto-git is interrupted, so
+// that Ctrl-C doesn't leave the stash unpopped or the repository locked.
+// The handler stays installed for the rest of the process, so a second
+// Ctrl-C doesn't cut the cleanups short.
+func exitOnSignal() {
+	ctx, _ := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		fmt.Fprintln(os.Stderr)
+		logging.Info("interrupted")
+		exit(ExitInterrupted)
+	}()
+}
+
+// exit runs the cleanups, flushes the log file and terminates the process with code
+func exit(code int) {
+	runCleanups()
+	logRequestStats()
+	logExit(code)
+	closeLog()
+	os.Exit(code)
+}
+
+// printError reports err on stderr with a localized "Error:" prefix, and in
+// the JSON log of --batch runs
+func printError(err error) {
+	fmt.Fprintln(os.Stderr, i18n.Sprintf("Error: %v", err))
+	if batchOut != nil {
+		logging.Error("error", "error", err)
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var fixupCmd = &cobra.Command{
+	Use:   "fixup [commit]",
+	Short: "Commit the current changes as a fixup for an earlier commit",
+	Long: `Stage the current changes and commit them as "fixup! <subject>" for an
+earlier commit, ready for git rebase -i --autosquash.
+
+Without a commit the target is picked from the diff: the commit that last
+touched most of the modified lines, or else the last one to touch the changed
+files. When the branch has an upstream, only unpushed commits are considered.
+The fixup commit is not pushed.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runFixup,
+}
+
+func runFixup(cmd *cobra.Command, args []string) {
+	if stagedFlag && !pathFilter().Empty() {
+		fmt.Fprintln(os.Stderr, i18n.T("Error: --staged cannot be combined with --include or --exclude"))
+		exit(ExitError)
+	}
+	lockRepository()
+
+	changes, _, err := readPendingChanges()
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+	fmt.Fprintln(statusOut, i18n.T("Changes detected:"))
+	fmt.Fprintln(statusOut, changes.Summary)
+	fmt.Fprintln(statusOut)
+
+	var target string
+	if len(args) == 1 {
+		target, err = git.ResolveCommit(args[0])
+	} else {
+		target, err = git.FixupTarget(pathFilter(), stagedFlag)
+		if errors.Is(err, git.ErrNoFixupTarget) {
+			err = fmt.Errorf("%w; pass it explicitly: auto-git fixup <commit>", err)
+		}
+	}
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+
+	description, err := git.DescribeCommit(target)
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	fmt.Println(i18n.Sprintf("Fixup target: %s", description))
+	if len(args) == 0 {
+		ok, err := ui.Confirm(i18n.T("Create fixup commit?"), true)
+		if err != nil && !errors.Is(err, ui.ErrNonInteractive) {
+			printError(err)
+			exit(exitCodeFor(err, ExitError))
+		}
+		if err == nil && !ok {
+			exit(ExitCancelled)
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+
+	if !stagedFlag {
+		if err := git.StagePaths(pathFilter()); err != nil {
+			printError(err)
+			exit(ExitCommitFailed)
+		}
+	}
+	if err := git.CommitFixup(target, commitOptions(cfg)); err != nil {
+		printError(err)
+		exit(ExitCommitFailed)
+	}
+
+	fmt.Println(i18n.Sprintf("Created fixup commit; apply it with: git rebase -i --autosquash %s~", target[:12]))
+}
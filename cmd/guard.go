@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"auto-git/internal/config"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+	"auto-git/internal/prompt"
+	"auto-git/internal/provider"
+	"auto-git/internal/tokenizer"
+	"auto-git/internal/ui"
+	"auto-git/pkg/autogit"
+)
+
+// guardDiffSize asks before a diff over the size_guard limits is sent to a
+// remote provider, exiting with ExitCancelled if the user declines. The diff
+// is measured as it will be sent, after shortening it to the model's context
+// window. Without a user to ask it only warns. Local providers cost nothing
+// and are not guarded.
+func guardDiffSize(cfg *config.Config, prov provider.Provider, diffContent string) {
+	if cfg.SizeGuard.Disabled || autogit.CheckLocal(prov) == nil {
+		return
+	}
+
+	count := tokenizer.ForModel(cfg.Model).Count
+	if window := contextWindow(cfg, cfg.Model); window > tokenizer.ReplyReserve {
+		diffContent = prompt.FitDiff(diffContent, window-tokenizer.ReplyReserve, count)
+	}
+	lines := strings.Count(diffContent, "\n")
+	bytes := len(diffContent)
+	tokens := count(diffContent)
+
+	maxLines, maxBytes, maxTokens := cfg.SizeGuard.Limits()
+	var exceeded []string
+	if maxLines > 0 && lines > maxLines {
+		exceeded = append(exceeded, i18n.Sprintf("%d lines > %d", lines, maxLines))
+	}
+	if maxBytes > 0 && bytes > maxBytes {
+		exceeded = append(exceeded, i18n.Sprintf("%d bytes > %d", bytes, maxBytes))
+	}
+	if maxTokens > 0 && tokens > maxTokens {
+		exceeded = append(exceeded, i18n.Sprintf("~%d tokens > %d", tokens, maxTokens))
+	}
+	if len(exceeded) == 0 {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, i18n.Sprintf("The diff is large: %d lines, %d bytes, ~%d tokens (%s).", lines, bytes, tokens, strings.Join(exceeded, ", ")))
+	if cost := cfg.SizeGuard.CostPer1KTokens; cost > 0 {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Estimated input cost: $%.4f", float64(tokens)/1000*cost))
+	}
+
+	ok, err := ui.Confirm(i18n.Sprintf("Send it to %s?", cfg.Provider), false)
+	if errors.Is(err, ui.ErrNonInteractive) {
+		// Unattended runs have no one to ask; failing them would break CI
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: sending the large diff to %s without confirmation", cfg.Provider))
+		logging.Info("size guard exceeded", "provider", cfg.Provider, "tokens", tokens, "lines", lines, "bytes", bytes)
+		return
+	}
+	if err != nil || !ok {
+		fmt.Fprintln(os.Stderr, i18n.T("Cancelled."))
+		exit(ExitCancelled)
+	}
+}
+
+// contextWindow returns the context window of model in tokens: context_window
+// from the config, or the built-in size for known models, or 0 when unknown
+func contextWindow(cfg *config.Config, model string) int {
+	if cfg.ContextWindow != 0 {
+		return max(cfg.ContextWindow, 0)
+	}
+	return tokenizer.ContextWindow(model)
+}
+
+// warnContextOverflow warns that a prompt of tokens doesn't fit into the
+// context window of model, leaving room for the reply
+func warnContextOverflow(model string, tokens, window int) bool {
+	logging.Debug("prompt size", "model", model, "tokenizer", tokenizer.ForModel(model).Name(), "tokens", tokens, "context_window", window)
+	if window == 0 || tokens <= window-tokenizer.ReplyReserve {
+		return false
+	}
+	fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: the prompt (~%d tokens) exceeds the context window of %s (%d tokens); the diff will be shortened to fit", tokens, model, window))
+	return true
+}
+
+// fitSummaryDiff shortens diffContent so that the prompts of generateSummary
+// fit into the context window of model
+func fitSummaryDiff(cfg *config.Config, model, systemPrompt, diffContent string, userPrompt func(diff string) string) string {
+	window := contextWindow(cfg, model)
+	count := tokenizer.ForModel(model).Count
+	overhead := count(systemPrompt) + count(userPrompt(""))
+	if !warnContextOverflow(model, overhead+count(diffContent), window) {
+		return diffContent
+	}
+	return prompt.FitDiff(diffContent, window-tokenizer.ReplyReserve-overhead, count)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"auto-git/internal/config"
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+)
+
+// applyHookFixes reports the files commit hooks changed after the message
+// was generated and, depending on mode, mentions them in the message or
+// describes the final commit anew. It returns the subject of the commit.
+func applyHookFixes(cfg *config.Config, mode, message string, fixes *git.HookFixes) string {
+	fmt.Fprintln(statusOut, i18n.Sprintf("Commit hooks changed %s; their changes are part of the commit.", strings.Join(fixes.Paths, ", ")))
+	logging.Info("commit hooks changed files", "paths", fixes.Paths)
+
+	amended := ""
+	switch mode {
+	case config.HookFixesAnnotate:
+		amended = annotateHookFixes(message, fixes.Paths)
+	case config.HookFixesRegenerate:
+		changes, diffContent, err := git.DiffBetween("HEAD^", "HEAD")
+		if err != nil {
+			// The first commit of a repository has no parent to compare with
+			logging.Debug("failed to read the final commit", "error", err)
+			amended = annotateHookFixes(message, fixes.Paths)
+			break
+		}
+		fmt.Fprintln(statusOut, i18n.T("Describing the commit with the changes of the hooks..."))
+		amended = generateMessage(cfg, changes, diffContent)
+		if strings.TrimSpace(amended) == "" {
+			amended = annotateHookFixes(message, fixes.Paths)
+		}
+	}
+
+	subject, _, _ := strings.Cut(message, "\n")
+	if amended == "" {
+		return subject
+	}
+	if err := git.AmendMessage(amended, commitOptions(cfg).Signoff); err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: %v", err))
+		return subject
+	}
+	fmt.Printf("\n%s\n%s\n\n", i18n.T("Updated commit message:"), amended)
+	subject, _, _ = strings.Cut(amended, "\n")
+	return subject
+}
+
+// annotateHookFixes adds a paragraph naming the files changed by commit
+// hooks to the end of message
+func annotateHookFixes(message string, paths []string) string {
+	return strings.TrimRight(message, "\n") + "\n\nCommit hooks also changed: " + strings.Join(paths, ", ")
+}
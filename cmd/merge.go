@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"auto-git/internal/config"
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+	"auto-git/internal/prompt"
+	"auto-git/internal/ui"
+)
+
+// currentMerge returns the in-progress merge, if any, exiting when it cannot
+// be committed yet
+func currentMerge() *git.MergeState {
+	state, err := git.CurrentMerge()
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	if state == nil {
+		return nil
+	}
+	if len(state.Unresolved) > 0 {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error: a merge is in progress with unresolved conflicts in:\n  %s\nResolve them and stage the files, then run auto-git --continue.", strings.Join(state.Unresolved, "\n  ")))
+		exit(ExitCommitFailed)
+	}
+	if !pathFilter().Empty() {
+		fmt.Fprintln(os.Stderr, i18n.T("Error: --include and --exclude cannot be used while a merge is in progress"))
+		exit(ExitError)
+	}
+	return state
+}
+
+// generateMergeMessage describes an in-progress merge: git's merge subject,
+// a generated summary of the merged work and the resolved conflicts. When the
+// provider is unreachable the merged commits are listed instead.
+func generateMergeMessage(cfg *config.Config, state *git.MergeState, changes *git.Changes, diffContent string) string {
+	summary := generateSummary(cfg, "merge", prompt.BuildMergeSystemPrompt(), diffContent, func(diff string) string {
+		return prompt.BuildMergeUserPrompt(state, changes, diff)
+	})
+	if summary == "" {
+		summary = prompt.FallbackMergeSummary(state)
+	}
+	return prompt.MergeMessage(state, summary)
+}
+
+// generateSummary asks the provider for free-form text, such as the body of a
+// merge or revert commit, rather than a conventional subject. userPrompt
+// receives the redacted diff. It returns "" when the provider is unreachable
+// and fallbacks are allowed, or when the reply is empty.
+func generateSummary(cfg *config.Config, what, systemPrompt, diffContent string, userPrompt func(diff string) string) string {
+	prov := createProvider(cfg)
+	guardDiffSize(cfg, prov, diffContent)
+
+	model := cfg.Model
+	if skip, _ := canSkipValidation(cfg); !skip {
+		if err := pingProvider(prov, cfg); err != nil {
+			if cfg.NoFallback {
+				fmt.Fprintln(os.Stderr, i18n.Sprintf("Error connecting to %s: %v", cfg.Provider, err))
+				exit(ExitProviderUnreachable)
+			}
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: could not reach %s: %v", cfg.Provider, err))
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: writing the %s message without a generated summary", what))
+			return ""
+		}
+		model = resolveModel(prov, cfg)
+	}
+
+	diffContent = fitSummaryDiff(cfg, model, systemPrompt, promptRedactor(cfg)(diffContent), userPrompt)
+	fmt.Fprintln(statusOut, i18n.Sprintf("Using provider: %s, model: %s", cfg.Provider, model))
+	spinner := ui.NewSpinner(i18n.Sprintf("Summarizing %s with %s...", what, model))
+	start := time.Now()
+	completion, err := prov.Generate(model, systemPrompt, userPrompt(diffContent))
+	spinner.Stop()
+	logging.Debug("summary finished", "kind", what, "provider", cfg.Provider, "model", model, "duration", time.Since(start), "error", err)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error generating commit message: %v", err))
+		exit(ExitGenerationFailed)
+	}
+	return prompt.CleanSummary(completion.Content)
+}
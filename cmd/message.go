@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+
+	"github.com/spf13/cobra"
+)
+
+var messageCmd = &cobra.Command{
+	Use:   "message",
+	Short: "Print a generated commit message without touching git",
+	Long: `Generate a commit message for the current changes and print it to stdout.
+
+No files are staged, committed, or pushed, so the output can be used directly:
+
+  git commit -m "$(auto-git message)"
+
+With --stdin a unified diff is read from standard input instead of the
+repository, e.g. git diff main | auto-git message --stdin
+
+With --output-file the message is written to a file instead, e.g. from a
+prepare-commit-msg hook: auto-git message --output-file "$1"
+
+With --last the message last generated in this repository is printed again
+without asking the model.`,
+	Args: cobra.NoArgs,
+	Run:  runMessage,
+}
+
+var (
+	messageFromStdin bool
+	outputFileFlag   string
+	lastMessageFlag  bool
+)
+
+func init() {
+	messageCmd.Flags().BoolVar(&messageFromStdin, "stdin", false, "read a unified diff from stdin instead of the git repository")
+	messageCmd.Flags().StringVar(&outputFileFlag, "output-file", "", "write the message to this file instead of stdout (\"-\" for stdout); comment lines already in it are kept")
+	messageCmd.Flags().BoolVar(&lastMessageFlag, "last", false, "print the message last generated in this repository instead of generating one")
+}
+
+func runMessage(cmd *cobra.Command, args []string) {
+	// Keep stdout reserved for the message itself
+	statusOut = os.Stderr
+
+	if lastMessageFlag {
+		printLastMessage()
+		return
+	}
+
+	var changes *git.Changes
+	var diffContent string
+	var err error
+	if messageFromStdin {
+		changes, diffContent, err = readPatch(os.Stdin)
+	} else {
+		changes, diffContent, err = readRepoChanges()
+	}
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+
+	commitMessage := generateMessage(cfg, changes, diffContent)
+	if strings.TrimSpace(commitMessage) == "" {
+		fmt.Fprintln(os.Stderr, i18n.T("Error: generated commit message is empty"))
+		exit(ExitGenerationFailed)
+	}
+
+	if outputFileFlag == "" {
+		printMessage(commitMessage)
+		return
+	}
+	if err := writeMessageFile(outputFileFlag, commitMessage); err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+}
+
+// printLastMessage prints, or writes to --output-file, the message last
+// generated in this repository
+func printLastMessage() {
+	s := repoState()
+	if s == nil || strings.TrimSpace(s.LastMessage) == "" {
+		fmt.Fprintln(os.Stderr, i18n.T("Error: no message has been generated in this repository yet"))
+		exit(ExitError)
+	}
+	if outputFileFlag == "" {
+		printMessage(s.LastMessage)
+		return
+	}
+	if err := writeMessageFile(outputFileFlag, s.LastMessage); err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+}
+
+// writeMessageFile writes message to path, or to stdout for "-" as with git
+// commit -F. Comment lines already in the file, such as the ones git puts in
+// COMMIT_EDITMSG before running prepare-commit-msg, are kept below the message.
+func writeMessageFile(path, message string) error {
+	if path == "-" {
+		printMessage(message)
+		return nil
+	}
+
+	content := strings.TrimRight(message, "\n") + "\n"
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var comments []string
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.HasPrefix(line, "#") {
+			comments = append(comments, line)
+		}
+	}
+	if len(comments) > 0 {
+		content += "\n" + strings.Join(comments, "\n") + "\n"
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write commit message: %w", err)
+	}
+	fmt.Fprintln(statusOut, i18n.Sprintf("Wrote commit message to %s", path))
+	return nil
+}
+
+// printMessage prints message to stdout. Under --batch, where stdout only
+// carries the JSON log, it is logged as a message event instead.
+func printMessage(message string) {
+	if batchOut != nil {
+		logging.Info("message", "message", message)
+		return
+	}
+	fmt.Println(message)
+}
+
+// readRepoChanges collects the change summary and diff to describe: the
+// pending changes, or with --against everything the branch changes
+func readRepoChanges() (*git.Changes, string, error) {
+	if againstFlag != "" {
+		return git.CollectAgainst(".", againstFlag, pathFilter(), stagedFlag)
+	}
+	return readPendingChanges()
+}
+
+// readPendingChanges collects the uncommitted changes in the current
+// repository, limited to the --include/--exclude paths and to the index with --staged
+func readPendingChanges() (*git.Changes, string, error) {
+	if stagedFlag {
+		return git.CollectStaged(".", pathFilter())
+	}
+	return git.CollectFiltered(".", pathFilter())
+}
+
+// readPatch reads a unified diff from r and builds the change summary from it
+func readPatch(r io.Reader) (*git.Changes, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read diff from stdin: %w", err)
+	}
+
+	diffContent := string(data)
+	if strings.TrimSpace(diffContent) == "" {
+		return nil, "", fmt.Errorf("no diff provided on stdin")
+	}
+
+	changes, err := git.ParsePatch(diffContent)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return changes, diffContent, nil
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"auto-git/internal/config"
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+	"auto-git/internal/workspace"
+)
+
+// applyModelRules returns a copy of cfg using the provider and model of the
+// first model_rules entry the changes meet, or cfg itself when none does.
+// --provider and --model take precedence over the rules.
+func applyModelRules(cfg *config.Config, changes *git.Changes) *config.Config {
+	if len(cfg.ModelRules) == 0 || providerFlag != "" || modelFlag != "" {
+		return cfg
+	}
+
+	files := append(append([]git.FileChange{}, changes.Staged...), changes.Unstaged...)
+	lines := 0
+	for _, f := range files {
+		lines += f.Additions + f.Deletions
+	}
+
+	for i, rule := range cfg.ModelRules {
+		if strings.TrimSpace(rule.Model) == "" {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: model_rules entry %d has no model; ignoring it", i+1))
+			continue
+		}
+		if !ruleMatches(rule, files, lines) {
+			continue
+		}
+
+		selected := *cfg
+		if name := strings.ToLower(strings.TrimSpace(rule.Provider)); name != "" && name != cfg.Provider {
+			selected.Provider = name
+			selected.Endpoint = ""
+		}
+		if rule.Endpoint != "" {
+			selected.Endpoint = rule.Endpoint
+		}
+		selected.Model = rule.Model
+		logging.Debug("model rule matched", "rule", i+1, "lines", lines, "files", len(files), "provider", selected.Provider, "model", selected.Model)
+		return &selected
+	}
+	return cfg
+}
+
+// ruleMatches reports whether changes to files with lines added and deleted
+// lines meet all conditions of rule
+func ruleMatches(rule config.ModelRule, files []git.FileChange, lines int) bool {
+	if rule.MaxLines > 0 && lines > rule.MaxLines {
+		return false
+	}
+	if rule.MinLines > 0 && lines < rule.MinLines {
+		return false
+	}
+	if rule.MaxFiles > 0 && len(files) > rule.MaxFiles {
+		return false
+	}
+	if len(rule.Paths) == 0 {
+		return true
+	}
+	for _, f := range files {
+		if workspace.MatchAny(rule.Paths, f.Path) {
+			return true
+		}
+	}
+	return false
+}
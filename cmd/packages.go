@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"auto-git/internal/config"
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+	"auto-git/internal/prompt"
+	"auto-git/internal/ui"
+	"auto-git/internal/workspace"
+)
+
+// commitPerPackage offers to split the changes into one commit per monorepo
+// package and creates them, pushing once at the end. It returns false when
+// the changes touch a single package or the user declines, leaving the
+// single commit to the caller.
+func commitPerPackage(cfg *config.Config, changes *git.Changes) bool {
+	if stagedFlag || againstFlag != "" {
+		// Committing a path takes its worktree content, which --staged must not
+		logging.Debug("per-package commits need pending worktree changes; making one commit")
+		return false
+	}
+	root, err := git.Root()
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+
+	groups := workspace.NewDetector(root, cfg.PackageRoots).Group(changedPaths(changes))
+	if len(groups) < 2 {
+		logging.Debug("changes touch a single package", "packages", len(groups))
+		return false
+	}
+
+	fmt.Fprintln(statusOut, i18n.Sprintf("The changes touch %d packages:", len(groups)))
+	for _, g := range groups {
+		fmt.Fprintf(statusOut, "  %s: %s\n", describePackage(g.Package), i18n.Sprintf("%d file(s)", len(g.Paths)))
+	}
+
+	split, err := ui.Confirm(i18n.Sprintf("Create %d commits, one per package?", len(groups)), true)
+	if errors.Is(err, ui.ErrNonInteractive) {
+		// Asking for per-package mode is the answer
+		split, err = true, nil
+	}
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+	if !split {
+		return false
+	}
+
+	for i, g := range groups {
+		fmt.Fprintln(statusOut)
+		fmt.Fprintln(statusOut, fmt.Sprintf("[%d/%d] %s", i+1, len(groups), describePackage(g.Package)))
+		commitPackage(cfg, g)
+	}
+	publishCommit(cfg)
+	return true
+}
+
+// commitPackage generates a message scoped to the package for its changes
+// and commits them
+func commitPackage(cfg *config.Config, g workspace.Group) {
+	filter := git.ExactPaths(g.Paths)
+	changes, diffContent, err := git.CollectFiltered(".", filter)
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+	if g.Name != "" {
+		// Tell the model which package this is; the scope is enforced below
+		changes.Context = fmt.Sprintf("Package: %s (%s, %s/)", g.Name, g.Kind, g.Dir)
+	}
+
+	message := prompt.WithScope(generateMessage(cfg, changes, diffContent), g.Name)
+	message = confirmMessage(cfg, message, changes, diffContent)
+	recordCommit(cfg, message, filter, false)
+	reportTodos(diffContent)
+}
+
+// changedPaths lists the staged and unstaged paths once each
+func changedPaths(changes *git.Changes) []string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, files := range [][]git.FileChange{changes.Staged, changes.Unstaged} {
+		for _, f := range files {
+			if !seen[f.Path] {
+				seen[f.Path] = true
+				paths = append(paths, f.Path)
+			}
+		}
+	}
+	return paths
+}
+
+// describePackage names a package for status output, e.g. "api (go module, services/api/)"
+func describePackage(pkg workspace.Package) string {
+	if pkg.Dir == "" {
+		return i18n.T("repository root")
+	}
+	return fmt.Sprintf("%s (%s, %s/)", pkg.Name, i18n.T(string(pkg.Kind)), pkg.Dir)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+	"auto-git/pkg/autogit"
+)
+
+// prFileName is the file in the repository state directory that holds the
+// last generated pull request title and description
+const prFileName = "PULL_REQUEST.md"
+
+// pullRequest is the pull request text generated along with the commit
+// message when --pr is set
+var pullRequest *autogit.PullRequest
+
+// generatePR generates the commit message and the pull request text in one
+// request, keeping the latter for reportPullRequest. When the reply holds only
+// a commit message, it is returned with autogit.ErrNoPullRequest.
+func generatePR(engine *autogit.Engine, changes *autogit.Changes, diffContent string) (string, error) {
+	pr, err := engine.GeneratePR(changes, diffContent)
+	if err != nil && !errors.Is(err, autogit.ErrNoPullRequest) {
+		return "", err
+	}
+	if err == nil {
+		pullRequest = pr
+	}
+	return pr.CommitMessage(), err
+}
+
+// reportPullRequest prints the generated pull request title and description
+// and saves them for gh pr create --body-file
+func reportPullRequest() {
+	if pullRequest == nil {
+		return
+	}
+
+	fmt.Printf("\n%s\n%s\n\n%s\n", i18n.T("Pull request:"), pullRequest.Title, pullRequest.Description)
+
+	dir, err := git.StateDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0o755)
+	}
+	if err != nil {
+		logging.Debug("failed to save pull request text", "error", err)
+		return
+	}
+	path := filepath.Join(dir, prFileName)
+	if err := os.WriteFile(path, []byte(pullRequest.Description+"\n"), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: failed to save the pull request description: %v", err))
+		return
+	}
+	fmt.Println()
+	fmt.Println(i18n.Sprintf("Saved the description to %s. To open the pull request:", path))
+	fmt.Printf("  gh pr create --title %s --body-file %s\n", git.ShellQuote(pullRequest.Title), git.ShellQuote(path))
+}
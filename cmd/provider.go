@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"auto-git/internal/config"
+	"auto-git/internal/i18n"
+	"auto-git/internal/ollama"
+	"auto-git/internal/openai"
+	"auto-git/internal/provider"
+	"auto-git/internal/ui"
+	"auto-git/pkg/autogit"
+
+	"github.com/spf13/cobra"
+)
+
+var providerCmd = &cobra.Command{
+	Use:   "provider",
+	Short: "Inspect the LLM providers",
+}
+
+var providerStatusTimeout time.Duration
+
+var providerStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check connectivity, authentication and models of every provider",
+	Long: `Check every usable provider at once and print a status table: the
+configured provider, Ollama, SiliconFlow and OpenAI when their API key is set,
+and installed plugins.
+
+Each provider is checked for connectivity (with latency), a valid API key and,
+for the configured provider, whether the configured model is available. The
+exit code is 3 when the configured provider fails its check.`,
+	Args: cobra.NoArgs,
+	Run:  runProviderStatus,
+}
+
+func init() {
+	providerStatusCmd.Flags().DurationVar(&providerStatusTimeout, "timeout", 10*time.Second, "give up on a provider after this long")
+	providerCmd.AddCommand(providerStatusCmd)
+}
+
+// providerStatus is the health of one provider
+type providerStatus struct {
+	name       string
+	endpoint   string
+	configured bool
+	err        error
+	latency    time.Duration
+	// auth is "ok", "invalid", "missing" or "-" when no key is needed
+	auth string
+	// models is the number of models offered, -1 when they could not be listed
+	models int
+	// hasModel reports whether the configured model is offered
+	hasModel bool
+}
+
+// authFailure matches the errors of HTTP providers rejecting the API key
+var authFailure = regexp.MustCompile(`status code:? (401|403)\b`)
+
+func runProviderStatus(cmd *cobra.Command, args []string) {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+
+	names := statusProviders(cfg)
+	results := make([]providerStatus, len(names))
+	spinner := ui.NewSpinner(i18n.Sprintf("Checking %d providers...", len(names)))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = checkProvider(cfg, name)
+		}()
+	}
+	wg.Wait()
+	spinner.Stop()
+
+	printProviderStatus(results, cfg.Model)
+	for _, r := range results {
+		if r.configured && r.err != nil {
+			exit(ExitProviderUnreachable)
+		}
+	}
+}
+
+// statusProviders lists the configured provider first, then the others that
+// can be used without further setup
+func statusProviders(cfg *config.Config) []string {
+	names := []string{cfg.Provider}
+	for _, name := range autogit.AvailableProviders() {
+		if slices.Contains(names, name) || name == autogit.ProviderMock {
+			continue
+		}
+		if name != autogit.ProviderOllama && slices.Contains(autogit.SupportedProviders, name) && autogit.APIKeyFromEnv(name) == "" {
+			// Hosted providers are unusable without a key
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// checkProvider connects to a provider and lists its models
+func checkProvider(cfg *config.Config, name string) providerStatus {
+	status := providerStatus{name: name, configured: name == cfg.Provider, models: -1, auth: "-"}
+	endpoint := ""
+	if status.configured {
+		endpoint = cfg.Endpoint
+	}
+	apiKey := autogit.APIKeyFromEnv(name)
+	prov, err := autogit.NewProvider(name, endpoint, apiKey)
+	if err != nil {
+		status.err = err
+		return status
+	}
+	autogit.SetOpenAIAccount(prov, cfg.OpenAIOrganization, cfg.OpenAIProject)
+	autogit.SetHeaders(prov, cfg.Headers[name])
+	setProviderTimeout(prov, providerStatusTimeout)
+	if status.configured {
+		if prov, err = autogit.FilterModels(prov, cfg.ModelFilter); err != nil {
+			status.err = err
+			return status
+		}
+	}
+	status.endpoint = autogit.Endpoint(prov)
+
+	start := time.Now()
+	err = prov.CheckConnection()
+	status.latency = time.Since(start)
+	if err == nil {
+		var models []provider.Model
+		models, err = prov.ListModels()
+		status.models = len(models)
+		for _, m := range models {
+			if m.Name == cfg.Model {
+				status.hasModel = true
+			}
+		}
+	}
+
+	switch {
+	case autogit.APIKeyEnvVar(name) == "" || name == autogit.ProviderOllama && apiKey == "":
+		// Ollama only needs a key behind an authenticating proxy
+	case apiKey == "":
+		status.auth = "missing"
+	case err != nil && authFailure.MatchString(err.Error()):
+		status.auth = "invalid"
+	case err == nil:
+		status.auth = "ok"
+	}
+	status.err = err
+	return status
+}
+
+// setProviderTimeout shortens the request timeout of the built-in HTTP providers
+func setProviderTimeout(prov provider.Provider, timeout time.Duration) {
+	switch c := provider.Unwrap(prov).(type) {
+	case *ollama.Client:
+		c.Client.Timeout = timeout
+	case *openai.Client:
+		c.Client.Timeout = timeout
+	}
+}
+
+func printProviderStatus(results []providerStatus, model string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tENDPOINT\tLATENCY\tAUTH\tMODELS\tSTATUS")
+	for _, r := range results {
+		name := r.name
+		if r.configured {
+			name += " *"
+		}
+		endpoint := r.endpoint
+		if endpoint == "" {
+			endpoint = "-"
+		}
+		if r.err != nil {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t-\terror: %v\n", name, endpoint, r.latency.Round(time.Millisecond), r.auth, r.err)
+			continue
+		}
+		models := fmt.Sprintf("%d", r.models)
+		if r.configured {
+			if r.hasModel {
+				models += fmt.Sprintf(" (%s available)", model)
+			} else {
+				models += fmt.Sprintf(" (%s missing)", model)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\tok\n", name, endpoint, r.latency.Round(time.Millisecond), r.auth, models)
+	}
+	w.Flush()
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+	"auto-git/internal/ui"
+)
+
+// pushCommit pushes to origin, if there is one, and reports whether it did.
+// A branch without an upstream is pushed to origin and tracks it there, and
+// when the remote branch moved on, interactive runs offer to rebase onto it.
+func pushCommit() (bool, error) {
+	pushed, err := runPush(git.PushIfRemoteExists)
+	switch {
+	case errors.Is(err, git.ErrNoUpstream) && git.CurrentBranch() != "":
+		fmt.Fprintln(statusOut, i18n.Sprintf("%s has no upstream branch; pushing it to origin/%s.", git.CurrentBranch(), git.CurrentBranch()))
+		logging.Info("setting upstream branch", "branch", git.CurrentBranch())
+		_, err = runPush(func() (bool, error) { return true, git.PushSetUpstream() })
+		return err == nil, err
+	case errors.Is(err, git.ErrPushRejected) && ui.IsInteractive():
+		rebase, confirmErr := ui.Confirm(i18n.T("The remote branch has new commits. Rebase onto them and push again?"), true)
+		if confirmErr != nil || !rebase {
+			return false, err
+		}
+		spinner := ui.NewSpinner(i18n.T("Rebasing onto the remote branch..."))
+		pullErr := git.PullRebase()
+		spinner.Stop()
+		if pullErr != nil {
+			return false, fmt.Errorf("%w (%v)", err, pullErr)
+		}
+		logging.Info("rebased onto the upstream branch")
+		return runPush(git.PushIfRemoteExists)
+	}
+	return pushed, err
+}
+
+// runPush runs push under a "Pushing..." status line
+func runPush(push func() (bool, error)) (bool, error) {
+	if ui.IsInteractive() {
+		// A spinner would draw over git's credential prompts
+		fmt.Fprintln(statusOut, i18n.T("Pushing..."))
+		return push()
+	}
+	spinner := ui.NewSpinner(i18n.T("Pushing..."))
+	defer spinner.Stop()
+	// Large pushes, such as the first one or LFS uploads, take a while
+	git.SetPushProgress(spinner.SetDetail)
+	defer git.SetPushProgress(nil)
+	return push()
+}
+
+// pushHint suggests what to do about a failed push, or returns ""
+func pushHint(err error) string {
+	branch := git.CurrentBranch()
+	if branch == "" {
+		branch = "<branch>"
+	}
+	switch {
+	case errors.Is(err, git.ErrPushRejected):
+		return i18n.T("Hint: the remote branch has commits you don't have. Run git pull --rebase, then git push.")
+	case errors.Is(err, git.ErrNoUpstream):
+		return i18n.T("Hint: check out a branch, then run git push --set-upstream origin HEAD.")
+	case errors.Is(err, git.ErrPushAuth):
+		return i18n.T("Hint: check your credentials. HTTPS remotes need a token or a credential helper, and SSH remotes a key loaded in ssh-agent (ssh-add -l).")
+	case errors.Is(err, git.ErrProtectedBranch):
+		return i18n.Sprintf("Hint: push to another branch and open a pull request, e.g. git push origin HEAD:%s-changes", branch)
+	}
+	return ""
+}
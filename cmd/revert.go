@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/prompt"
+	"auto-git/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var revertCmd = &cobra.Command{
+	Use:   "revert <commit>",
+	Short: "Revert a commit with a generated explanation",
+	Long: `Revert a commit (git revert --no-commit), explain from the original commit's
+message and diff what the revert undoes, then commit and push like a normal run.
+
+The message keeps git's format: Revert "<subject>", the explanation, and
+"This reverts commit <hash>.". Pass --reason to say why it is being reverted.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRevert,
+}
+
+var revertReason string
+
+func init() {
+	revertCmd.Flags().StringVar(&revertReason, "reason", "", "why the commit is reverted, e.g. \"breaks login on Safari\"")
+}
+
+func runRevert(cmd *cobra.Command, args []string) {
+	lockRepository()
+
+	commit, err := git.ResolveCommit(args[0])
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	originalMessage, err := git.CommitMessageOf(commit)
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	originalSubject, _, _ := strings.Cut(originalMessage, "\n")
+	changes, diffContent, err := git.DiffBetween(commit+"^", commit)
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+
+	if err := git.RevertNoCommit(commit); err != nil {
+		printError(err)
+		exit(ExitCommitFailed)
+	}
+	// Until the revert is committed, leaving means undoing it
+	committed := false
+	atExit(func() {
+		if committed {
+			return
+		}
+		if err := git.AbortRevert(); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: %v", err))
+		}
+	})
+
+	fmt.Fprintln(statusOut, i18n.Sprintf("Reverting %.7s %s", commit, originalSubject))
+	fmt.Fprintln(statusOut, changes.Summary)
+	fmt.Fprintln(statusOut)
+
+	explanation := generateSummary(cfg, "revert", prompt.BuildRevertSystemPrompt(), diffContent, func(diff string) string {
+		return prompt.BuildRevertUserPrompt(originalMessage, revertReason, changes, diff)
+	})
+	message := prompt.RevertMessage(originalSubject, commit, explanation, revertReason)
+
+	if cfg.Review {
+		// Review the revert itself rather than the commit being undone
+		revertChanges, revertDiff, err := git.CollectStaged(".", git.PathFilter{})
+		if err != nil {
+			printError(err)
+			exit(ExitError)
+		}
+		message = reviewMessage(cfg, message, revertDiff, revertChanges, cfg.UseEditor)
+	} else {
+		fmt.Printf("\n%s\n%s\n\n", i18n.T("Generated commit message:"), message)
+		for _, trailer := range commitTrailers(cfg) {
+			fmt.Println(trailer)
+		}
+		fmt.Println(i18n.T("Proceeding with commit and push..."))
+	}
+
+	spinner := ui.NewSpinner(i18n.Sprintf("Recording git changes: %s", fmt.Sprintf("Revert %q", originalSubject)))
+	opts := commitOptions(cfg)
+	opts.Paths = git.PathFilter{}
+	err = git.CommitWith(message, opts)
+	spinner.Stop()
+	if err != nil {
+		printError(err)
+		exit(ExitCommitFailed)
+	}
+	committed = true
+
+	publishCommit(cfg)
+}
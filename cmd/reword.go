@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var rewordCmd = &cobra.Command{
+	Use:   "reword",
+	Short: "Regenerate the messages of a range of commits",
+	Long: `Generate a new message for every commit in a range from its own diff, show
+the old and new subjects side by side, and after confirmation rewrite the
+commits with a scripted interactive rebase. Trees, authors and dates are kept.
+
+The range defaults to the commits not yet on the upstream branch:
+
+  auto-git reword --range origin/main..HEAD
+
+Commits already on the upstream branch are refused unless --force is given.`,
+	Args: cobra.NoArgs,
+	Run:  runReword,
+}
+
+var (
+	rewordRange string
+	rewordForce bool
+	rewordYes   bool
+)
+
+func init() {
+	rewordCmd.Flags().StringVar(&rewordRange, "range", "@{upstream}..HEAD", "commits to reword, as a git revision range")
+	rewordCmd.Flags().BoolVar(&rewordForce, "force", false, "reword even if some of the commits have already been pushed")
+	rewordCmd.Flags().BoolVarP(&rewordYes, "yes", "y", false, "rewrite without asking for confirmation")
+}
+
+type rewordEntry struct {
+	commit     string
+	oldSubject string
+	newMessage string
+}
+
+func runReword(cmd *cobra.Command, args []string) {
+	lockRepository()
+
+	commits, base, err := git.RewordRange(rewordRange, rewordForce)
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+
+	// Check the provider once instead of for every commit; old messages are
+	// better than rule-based fallbacks, so there is no fallback here
+	prov := createProvider(cfg)
+	model := cfg.Model
+	if skip, _ := canSkipValidation(cfg); !skip {
+		if err := pingProvider(prov, cfg); err != nil {
+			printError(err)
+			exit(ExitProviderUnreachable)
+		}
+		model = resolveModel(prov, cfg)
+	}
+
+	entries := make([]rewordEntry, 0, len(commits))
+	for i, commit := range commits {
+		oldMessage, err := git.CommitMessageOf(commit)
+		if err != nil {
+			printError(err)
+			exit(ExitError)
+		}
+		oldSubject, _, _ := strings.Cut(oldMessage, "\n")
+		fmt.Fprintf(statusOut, "[%d/%d] %.7s %s\n", i+1, len(commits), commit, oldSubject)
+
+		changes, diffContent, err := git.DiffBetween(commit+"^", commit)
+		if errors.Is(err, git.ErrNoChanges) {
+			// Empty commits keep their message
+			entries = append(entries, rewordEntry{commit: commit, oldSubject: oldSubject})
+			continue
+		}
+		if err != nil {
+			printError(err)
+			exit(ExitError)
+		}
+
+		guardDiffSize(cfg, prov, diffContent)
+		message, err := generateWith(prov, cfg, model, changes, diffContent)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Error generating commit message: %v", err))
+			exit(ExitGenerationFailed)
+		}
+		if strings.TrimSpace(message) == oldMessage {
+			message = ""
+		}
+		entries = append(entries, rewordEntry{commit: commit, oldSubject: oldSubject, newMessage: message})
+	}
+
+	messages := map[string]string{}
+	for _, e := range entries {
+		if strings.TrimSpace(e.newMessage) != "" {
+			messages[e.commit] = e.newMessage
+		}
+	}
+
+	fmt.Println()
+	printRewordPreview(entries)
+	fmt.Println()
+	if len(messages) == 0 {
+		fmt.Println(i18n.T("No messages changed; nothing to reword."))
+		return
+	}
+
+	if !rewordYes {
+		ok, err := ui.Confirm(fmt.Sprintf("Rewrite %d commit message(s)?", len(messages)), false)
+		if errors.Is(err, ui.ErrNonInteractive) {
+			fmt.Fprintln(os.Stderr, i18n.T("Error: pass --yes to reword without confirmation"))
+			exit(ExitCancelled)
+		}
+		if err != nil {
+			printError(err)
+			exit(exitCodeFor(err, ExitError))
+		}
+		if !ok {
+			exit(ExitCancelled)
+		}
+	}
+
+	head, err := git.Head()
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+
+	spinner := ui.NewSpinner(i18n.T("Rewording commits..."))
+	err = git.Reword(base, messages)
+	spinner.Stop()
+	if err != nil {
+		printError(err)
+		exit(ExitCommitFailed)
+	}
+
+	fmt.Println(i18n.Sprintf("Reworded %d commit(s).", len(messages)))
+	fmt.Println(i18n.Sprintf("Undo with: git reset --hard %s", head))
+}
+
+func printRewordPreview(entries []rewordEntry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMMIT\tOLD SUBJECT\tNEW SUBJECT")
+	for _, e := range entries {
+		newSubject, _, _ := strings.Cut(e.newMessage, "\n")
+		if newSubject == "" {
+			newSubject = "(unchanged)"
+		}
+		fmt.Fprintf(w, "%.7s\t%s\t%s\n", e.commit, e.oldSubject, newSubject)
+	}
+	w.Flush()
+}
@@ -1,129 +1,325 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"auto-git/internal/anonymize"
+	"auto-git/internal/audit"
+	"auto-git/internal/ci"
 	"auto-git/internal/config"
+	"auto-git/internal/emoji"
 	"auto-git/internal/git"
-	"auto-git/internal/ollama"
-	"auto-git/internal/openai"
-	"auto-git/internal/provider"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+	"auto-git/internal/mock"
 	"auto-git/internal/prompt"
+	"auto-git/internal/provider"
+	"auto-git/internal/redact"
+	"auto-git/internal/state"
+	"auto-git/internal/style"
+	"auto-git/internal/transport"
 	"auto-git/internal/ui"
+	"auto-git/internal/verify"
+	"auto-git/pkg/autogit"
 
 	"github.com/spf13/cobra"
 )
 
-const (
-	ProviderOllama      = "ollama"
-	ProviderSiliconFlow = "siliconflow"
-	ProviderOpenAI      = "openai"
+// statusOut receives progress and status lines. Commands whose stdout is meant
+// to be consumed by other tools redirect it to stderr.
+var statusOut io.Writer = os.Stdout
+
+var (
+	debugFlag          bool
+	logFileFlag        string
+	providerFlag       string
+	modelFlag          string
+	nonInteractiveFlag bool
+	reviewFlag         bool
+	skipVerifyFlag     bool
+	signoffFlag        bool
+	authorFlag         string
+	allowEmptyFlag     bool
+	purposeFlag        string
+	dateFlag           string
+	includeFlag        []string
+	excludeFlag        []string
+	stagedFlag         bool
+	stashFlag          bool
+	againstFlag        string
+	funcContextFlag    bool
+	editorFlag         bool
+	fastFlag           bool
+	noColorFlag        bool
+	plainFlag          bool
+	perPackageFlag     bool
+	prFlag             bool
+	batchFlag          bool
+	continueFlag       bool
+	closeLog           = func() error { return nil }
+	// ciMode is set when running in a CI pipeline; see ci.Detect
+	ciMode bool
 )
 
-// newProvider creates a new provider instance based on the provider type
-func newProvider(providerType, endpoint, apiKey string) (provider.Provider, error) {
-	providerType = strings.ToLower(strings.TrimSpace(providerType))
-
-	switch providerType {
-	case ProviderOllama:
-		return ollama.NewClient(endpoint, apiKey), nil
-	case ProviderSiliconFlow:
-		return openai.NewClient(endpoint, apiKey, true), nil
-	case ProviderOpenAI:
-		return openai.NewClient(endpoint, apiKey, false), nil
-	default:
-		return nil, fmt.Errorf("unknown provider type: %s (supported: ollama, siliconflow, openai)", providerType)
+var rootCmd = &cobra.Command{
+	Use:               "auto-git",
+	Short:             "Auto-generate commit messages using LLM providers",
+	Long:              `Auto-git scans your git repository for uncommitted changes and uses LLM providers (Ollama, SiliconFlow, OpenAI) to generate commit messages.`,
+	PersistentPreRun:  setup,
+	PersistentPostRun: func(cmd *cobra.Command, args []string) { logRequestStats(); closeLog() },
+	Run:               run,
+}
+
+// setup applies the global flags before any command runs
+func setup(cmd *cobra.Command, args []string) {
+	// Errors surface later in the command itself; setup only needs best-effort settings
+	cfg, _ := readConfig()
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	if batchFlag {
+		enterBatchMode()
+	}
+	setupLogging(cmd, args, cfg)
+	i18n.SetLanguage(cfg.UILanguage)
+	git.SetFunctionContext(funcContextFlag || cfg.FunctionContext)
+	git.SetDeepenShallow(cfg.DeepenShallow)
+	ui.PrepareConsole()
+	applyTheme(cfg.Theme)
+	if err := ui.SetKeys(cfg.Keys); err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: %v", err))
 	}
+	// https://no-color.org: any non-empty NO_COLOR value disables color
+	if noColorFlag || os.Getenv("NO_COLOR") != "" {
+		ui.DisableColor()
+	}
+	if plainFlag || cfg.Plain {
+		ui.SetPlain()
+	}
+	ui.SetNonInteractive(nonInteractiveFlag)
+	if name, ok := ci.Detect(); ok {
+		// Pipelines and bots can't answer prompts, render colors or redraw spinners
+		logging.Debug("running in CI", "ci", name)
+		ciMode = true
+		ui.SetNonInteractive(true)
+		ui.DisableColor()
+		ui.DisableAnimation()
+	}
+	if batchFlag {
+		ui.SetPlain()
+		ui.SetNonInteractive(true)
+		ui.DisableColor()
+		ui.DisableAnimation()
+	}
+	if !ui.IsInteractive() {
+		logging.Debug("running non-interactively")
+	}
+	git.SetCredentialPrompts(ui.IsInteractive())
 }
 
-// getAPIKeyFromEnv retrieves the API key from environment variables based on provider type
-func getAPIKeyFromEnv(providerType string) string {
-	providerType = strings.ToLower(strings.TrimSpace(providerType))
+// setupLogging configures the logger from the --debug/--log-file flags and the
+// log_file config setting
+func setupLogging(cmd *cobra.Command, args []string, cfg *config.Config) {
+	logFile := logFileFlag
+	if logFile == "" {
+		logFile, _ = cfg.ResolveLogFile()
+	}
 
-	switch providerType {
-	case ProviderOllama:
-		return strings.TrimSpace(os.Getenv("OLLAMA_API_KEY"))
-	case ProviderSiliconFlow:
-		return strings.TrimSpace(os.Getenv("SILICON_KEY"))
-	case ProviderOpenAI:
-		return strings.TrimSpace(os.Getenv("OPENAI_API_KEY"))
-	default:
-		return ""
+	closer, err := logging.Init(logging.Options{Debug: debugFlag, File: logFile, JSON: batchOut})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: %v", err))
 	}
+	closeLog = closer
+	logging.Debug("starting", "command", cmd.CommandPath(), "args", args)
 }
 
-var rootCmd = &cobra.Command{
-	Use:   "auto-git",
-	Short: "Auto-generate commit messages using LLM providers",
-	Long:  `Auto-git scans your git repository for uncommitted changes and uses LLM providers (Ollama, SiliconFlow, OpenAI) to generate commit messages.`,
-	Run:   run,
+// logRequestStats records the provider requests of the run, per host
+func logRequestStats() {
+	for _, s := range transport.Snapshot() {
+		logging.Info("provider requests", "host", s.Host, "requests", s.Requests, "failures", s.Failures, "duration", s.Duration)
+	}
 }
 
-var configCmd = &cobra.Command{
-	Use:   "config",
-	Short: "Manage configuration",
+// applyTheme resolves the configured theme name and color overrides
+func applyTheme(tc config.ThemeConfig) {
+	name := tc.Name
+	if name == "" {
+		name = ui.DefaultThemeName
+	}
+
+	theme, ok := ui.Themes[name]
+	if !ok {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: unknown theme %q (available: %s), using %s", name, strings.Join(ui.ThemeNames(), ", "), ui.DefaultThemeName))
+		theme = ui.Themes[ui.DefaultThemeName]
+	}
+
+	if tc.Selected != "" {
+		theme.Selected = tc.Selected
+	}
+	if tc.Title != "" {
+		theme.Title = tc.Title
+	}
+	if tc.Spinner != "" {
+		theme.Spinner = tc.Spinner
+	}
+	ui.ApplyTheme(theme)
 }
 
-var setModelCmd = &cobra.Command{
-	Use:   "set-model [model-name]",
-	Short: "Set the default model",
-	Args:  cobra.MaximumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+var (
+	readConfigOnce sync.Once
+	savedConfig    *config.Config
+	savedConfigErr error
+	// unknownGitConfig are the keys of the autogit git config section that
+	// no setting matches, reported by the first loadConfig
+	unknownGitConfig []string
+)
+
+// readConfig loads the config file with the autogit section of git config
+// applied over it. Both are read once per run. On an invalid git config
+// value it returns the settings applied so far together with the error.
+func readConfig() (*config.Config, error) {
+	readConfigOnce.Do(func() {
 		cfg, err := config.LoadConfig()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-			os.Exit(1)
+			savedConfigErr = err
+			return
 		}
-
-		apiKey := getAPIKeyFromEnv(cfg.Provider)
-		prov, err := newProvider(cfg.Provider, cfg.Endpoint, apiKey)
+		savedConfig = cfg
+		values, err := git.ConfigSection(config.GitConfigSection)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating provider: %v\n", err)
-			os.Exit(1)
+			logging.Debug("failed to read git config", "error", err)
+			return
+		}
+		unknownGitConfig, savedConfigErr = cfg.ApplyGitConfig(values)
+	})
+	return savedConfig, savedConfigErr
+}
+
+// loadConfig returns the effective configuration of this run: the config
+// file and git config, with the --provider and --model overrides applied
+func loadConfig() (*config.Config, error) {
+	saved, err := readConfig()
+	for _, key := range unknownGitConfig {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: unknown git config key %s", key))
+	}
+	unknownGitConfig = nil
+	if err != nil {
+		return nil, err
+	}
+	// The overrides only apply to this copy
+	copied := *saved
+	cfg := &copied
+	if _, err := cfg.GetHookFixes(); err != nil {
+		return nil, err
+	}
+	if providerFlag != "" {
+		override := strings.ToLower(strings.TrimSpace(providerFlag))
+		if override != cfg.Provider {
+			// The saved endpoint belongs to the saved provider
+			cfg.Endpoint = ""
+		}
+		cfg.Provider = override
+	}
+	if ciMode || batchFlag {
+		// A rule-based message would hide a provider outage; fail the job instead
+		cfg.NoFallback = true
+	}
+	if modelFlag != "" {
+		cfg.Model = modelFlag
+	} else if cfg.Provider == autogit.ProviderMock {
+		// The mock provider has a single model; don't prompt for it
+		cfg.Model = mock.DefaultModel
+	}
+	return cfg, nil
+}
+
+// defaultModelChoice returns the model to preselect when the configured one
+// is not offered: the one last used in this repository, or else the first
+func defaultModelChoice(models []provider.Model) string {
+	if s := repoState(); s != nil && s.LastModel != "" {
+		for _, m := range models {
+			if m.Name == s.LastModel {
+				return m.Name
+			}
 		}
+	}
+	return models[0].Name
+}
 
-		logAuthStatus(cfg.Provider, apiKey)
+// cacheModelNames remembers the models offered by a provider for shell
+// completion, and for this repository, whose git config may select another
+// endpoint
+func cacheModelNames(providerName string, models []provider.Model) {
+	names := make([]string, 0, len(models))
+	for _, m := range models {
+		names = append(names, m.Name)
+	}
+	if err := config.CacheModels(providerName, names); err != nil {
+		logging.Debug("failed to cache model list", "error", err)
+	}
+	updateRepoState(func(s *state.State) { s.RecordModels(providerName, names) })
+}
 
-		spinner := ui.NewSpinner(fmt.Sprintf("Connecting to %s...", cfg.Provider))
-		if err := prov.CheckConnection(); err != nil {
-			spinner.Stop()
-			fmt.Fprintf(os.Stderr, "Error connecting to %s: %v\n", cfg.Provider, err)
-			os.Exit(1)
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage configuration",
+}
+
+var setModelCmd = &cobra.Command{
+	Use:               "set-model [model-name]",
+	Short:             "Set the default model",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeModels,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+			exit(ExitError)
 		}
-		spinner.Stop()
 
-		spinner = ui.NewSpinner("Fetching available models...")
+		prov := connectProvider(cfg)
+
+		spinner := ui.NewSpinner(i18n.T("Fetching available models..."))
 		models, err := prov.ListModels()
 		spinner.Stop()
 		if err != nil {
 			// If listing fails, allow manual entry
-			fmt.Fprintf(os.Stderr, "Warning: Could not list models: %v\n", err)
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: Could not list models: %v", err))
 			if len(args) == 0 {
-				fmt.Fprintf(os.Stderr, "Please provide a model name: auto-git config set-model <model-name>\n")
-				os.Exit(1)
+				fmt.Fprintln(os.Stderr, i18n.T("Please provide a model name: auto-git config set-model <model-name>"))
+				exit(ExitError)
 			}
 			selectedModel := args[0]
 			if err := config.SetModel(selectedModel); err != nil {
-				fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
-				os.Exit(1)
+				fmt.Fprintln(os.Stderr, i18n.Sprintf("Error saving config: %v", err))
+				exit(ExitError)
 			}
-			fmt.Printf("Model set to: %s\n", selectedModel)
+			fmt.Println(i18n.Sprintf("Model set to: %s", selectedModel))
 			return
 		}
 
+		cacheModelNames(cfg.Provider, models)
+
 		if len(models) == 0 {
-			fmt.Fprintf(os.Stderr, "No models available. Please provide a model name manually.\n")
+			fmt.Fprintln(os.Stderr, i18n.T("No models available. Please provide a model name manually."))
 			if len(args) == 0 {
-				os.Exit(1)
+				exit(ExitError)
 			}
 			selectedModel := args[0]
 			if err := config.SetModel(selectedModel); err != nil {
-				fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
-				os.Exit(1)
+				fmt.Fprintln(os.Stderr, i18n.Sprintf("Error saving config: %v", err))
+				exit(ExitError)
 			}
-			fmt.Printf("Model set to: %s\n", selectedModel)
+			fmt.Println(i18n.Sprintf("Model set to: %s", selectedModel))
 			return
 		}
 
@@ -138,63 +334,78 @@ var setModelCmd = &cobra.Command{
 				}
 			}
 			if !found {
-				fmt.Printf("Model '%s' not found. Please select a model:\n", selectedModel)
+				fmt.Println(i18n.Sprintf("Model '%s' not found. Please select a model:", selectedModel))
 				selectedModel, err = ui.SelectModel(models, cfg.Model)
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error selecting model: %v\n", err)
-					os.Exit(1)
+					fmt.Fprintln(os.Stderr, i18n.Sprintf("Error selecting model: %v", err))
+					exit(exitCodeFor(err, ExitError))
 				}
 			}
 		} else {
-			fmt.Println("Select a model:")
+			if !ui.IsInteractive() {
+				fmt.Fprintln(os.Stderr, i18n.T("Please provide a model name: auto-git config set-model <model-name>"))
+				exit(ExitError)
+			}
+			fmt.Println(i18n.T("Select a model:"))
 			selectedModel, err = ui.SelectModel(models, cfg.Model)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error selecting model: %v\n", err)
-				os.Exit(1)
+				fmt.Fprintln(os.Stderr, i18n.Sprintf("Error selecting model: %v", err))
+				exit(exitCodeFor(err, ExitError))
 			}
 		}
 
 		if err := config.SetModel(selectedModel); err != nil {
-			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Error saving config: %v", err))
+			exit(ExitError)
 		}
-		fmt.Printf("Model set to: %s\n", selectedModel)
+		fmt.Println(i18n.Sprintf("Model set to: %s", selectedModel))
 	},
 }
 
 var showConfigCmd = &cobra.Command{
 	Use:   "show",
-	Short: "Show current configuration",
+	Short: "Show the effective configuration: the config file, git config and flags combined",
 	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.LoadConfig()
+		cfg, err := loadConfig()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			printError(err)
+			exit(ExitError)
 		}
-		fmt.Printf("Provider: %s\n", cfg.Provider)
+		fmt.Println(i18n.Sprintf("Provider: %s", cfg.Provider))
 		if cfg.Endpoint != "" {
-			fmt.Printf("Endpoint: %s\n", cfg.Endpoint)
+			fmt.Println(i18n.Sprintf("Endpoint: %s", cfg.Endpoint))
+		}
+		fmt.Println(i18n.Sprintf("Model: %s", cfg.Model))
+		if cfg.Theme.Name != "" {
+			fmt.Println(i18n.Sprintf("Theme: %s", cfg.Theme.Name))
+		}
+		if cfg.Privacy != "" {
+			fmt.Println(i18n.Sprintf("Privacy: %s", cfg.Privacy))
+		}
+		if auditPath, _ := cfg.ResolveAuditLog(); auditPath != "" {
+			fmt.Println(i18n.Sprintf("Audit log: %s", auditPath))
 		}
-		fmt.Printf("Model: %s\n", cfg.Model)
 	},
 }
 
 var setProviderCmd = &cobra.Command{
-	Use:   "set-provider [provider]",
-	Short: "Set the LLM provider (ollama, siliconflow, openai)",
-	Args:  cobra.ExactArgs(1),
+	Use:               "set-provider [provider]",
+	Short:             "Set the LLM provider (ollama, siliconflow, openai, or a plugin)",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProviders,
 	Run: func(cmd *cobra.Command, args []string) {
 		providerType := strings.ToLower(strings.TrimSpace(args[0]))
-		if providerType != ProviderOllama && providerType != ProviderSiliconFlow && providerType != ProviderOpenAI {
-			fmt.Fprintf(os.Stderr, "Invalid provider: %s (supported: ollama, siliconflow, openai)\n", providerType)
-			os.Exit(1)
+		supported := autogit.AvailableProviders()
+		if !slices.Contains(supported, providerType) {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Invalid provider: %s (supported: %s)", providerType, strings.Join(supported, ", ")))
+			exit(ExitError)
 		}
 
 		if err := config.SetProvider(providerType); err != nil {
-			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Error saving config: %v", err))
+			exit(ExitError)
 		}
-		fmt.Printf("Provider set to: %s\n", providerType)
+		fmt.Println(i18n.Sprintf("Provider set to: %s", providerType))
 	},
 }
 
@@ -205,76 +416,641 @@ var setEndpointCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		endpoint := strings.TrimSpace(args[0])
 		if err := config.SetEndpoint(endpoint); err != nil {
-			fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Error saving config: %v", err))
+			exit(ExitError)
 		}
-		fmt.Printf("Endpoint set to: %s\n", endpoint)
+		fmt.Println(i18n.Sprintf("Endpoint set to: %s", endpoint))
 	},
 }
 
 func Execute() {
+	exitOnSignal()
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		printError(err)
+		exit(ExitError)
 	}
+	runCleanups()
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&debugFlag, "debug", false, "print debug logs (provider requests, git commands, timings) to stderr")
+	rootCmd.PersistentFlags().StringVar(&logFileFlag, "log-file", "", "also write debug logs to this file")
+	rootCmd.PersistentFlags().StringVar(&providerFlag, "provider", "", "override the configured provider for this run")
+	rootCmd.PersistentFlags().StringVar(&modelFlag, "model", "", "override the configured model for this run")
+	rootCmd.PersistentFlags().BoolVar(&fastFlag, "fast", false, "skip the connection check and model validation unless generation fails")
+	rootCmd.PersistentFlags().BoolVar(&noColorFlag, "no-color", false, "disable colored output (also honors NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractiveFlag, "non-interactive", false, "never prompt; use defaults or fail when input is required")
+	rootCmd.PersistentFlags().BoolVar(&batchFlag, "batch", false, "unattended mode for scheduled jobs: never prompt, fail fast and write JSON logs to stdout")
+	rootCmd.PersistentFlags().BoolVar(&plainFlag, "plain", false, "plain text output with line prompts and no spinners or full-screen UIs (screen readers, logging)")
+	rootCmd.PersistentFlags().StringArrayVar(&includeFlag, "include", nil, "only consider changed paths matching this glob (repeatable), e.g. 'internal/git/**'")
+	rootCmd.PersistentFlags().StringArrayVar(&excludeFlag, "exclude", nil, "ignore changed paths matching this glob (repeatable)")
+	rootCmd.PersistentFlags().BoolVarP(&funcContextFlag, "function-context", "W", false, "show the model the whole function around each change (git diff --function-context)")
+	rootCmd.PersistentFlags().StringVar(&againstFlag, "against", "", "describe everything the branch changes since <ref> (git diff <ref>...HEAD plus pending changes), e.g. main")
+	rootCmd.PersistentFlags().BoolVar(&stagedFlag, "staged", false, "only consider and commit changes already in the index")
+	rootCmd.Flags().StringVar(&authorFlag, "author", "", "commit as this author, \"Name <email>\"")
+	rootCmd.Flags().StringVar(&dateFlag, "date", "", "override the author date (any format git commit --date accepts)")
+	rootCmd.Flags().BoolVar(&stashFlag, "stash", false, "with --staged, stash unstaged changes while committing and restore them afterwards")
+	rootCmd.Flags().BoolVar(&allowEmptyFlag, "allow-empty", false, "create an empty commit when there are no changes, e.g. to trigger CI")
+	rootCmd.Flags().StringVar(&purposeFlag, "purpose", "", "what an empty commit is for, e.g. \"trigger deploy\" (asked when omitted)")
+	rootCmd.Flags().BoolVar(&signoffFlag, "signoff", false, "add a Signed-off-by trailer using your git identity")
+	rootCmd.Flags().BoolVar(&skipVerifyFlag, "skip-verify", false, "push without running verify_command")
+	rootCmd.Flags().BoolVar(&reviewFlag, "review", false, "show the diff and message for confirmation before committing")
+	rootCmd.Flags().BoolVar(&editorFlag, "editor", false, "edit messages in $GIT_EDITOR/$EDITOR instead of the built-in editor")
+	rootCmd.Flags().StringVar(&outputFileFlag, "output-file", "", "also write the final commit message to this file (\"-\" for stdout)")
+	rootCmd.Flags().BoolVar(&continueFlag, "continue", false, "conclude an in-progress merge whose conflicts are resolved and staged, like git merge --continue")
+	rootCmd.Flags().BoolVar(&prFlag, "pr", false, "also generate a pull request title and description, in the same request as the commit message")
+	rootCmd.Flags().StringVar(&compareFlag, "compare", "", "generate a message with each of these comma-separated models at once and pick one, e.g. llama3.2,qwen2.5")
+	rootCmd.Flags().BoolVar(&perPackageFlag, "per-package", false, "offer one scoped commit per monorepo package (Go module, npm workspace or package_roots entry) the changes touch")
+	rootCmd.RegisterFlagCompletionFunc("provider", completeProviders)
+	rootCmd.RegisterFlagCompletionFunc("model", completeModels)
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
 	configCmd.AddCommand(setModelCmd)
 	configCmd.AddCommand(setProviderCmd)
 	configCmd.AddCommand(setEndpointCmd)
 	configCmd.AddCommand(showConfigCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(messageCmd)
+	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(benchmarkCmd)
+	rootCmd.AddCommand(evalCmd)
+	rootCmd.AddCommand(exportDatasetCmd)
+	rootCmd.AddCommand(providerCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(tuiCmd)
+	rootCmd.AddCommand(aliasCmd)
+	rootCmd.AddCommand(squashCmd)
+	rootCmd.AddCommand(fixupCmd)
+	rootCmd.AddCommand(rewordCmd)
+	rootCmd.AddCommand(revertCmd)
+	rootCmd.AddCommand(cherryPickCmd)
 }
 
 func run(cmd *cobra.Command, args []string) {
-	fmt.Println("Scanning git repository for changes...")
+	if prFlag && perPackageFlag {
+		fmt.Fprintln(os.Stderr, i18n.T("Error: --pr cannot be combined with --per-package"))
+		exit(ExitError)
+	}
+	if prFlag && compareFlag != "" {
+		fmt.Fprintln(os.Stderr, i18n.T("Error: --pr cannot be combined with --compare"))
+		exit(ExitError)
+	}
+	if perPackageFlag && outputFileFlag != "" {
+		fmt.Fprintln(os.Stderr, i18n.T("Error: --output-file cannot be combined with --per-package"))
+		exit(ExitError)
+	}
+	if perPackageFlag && (stagedFlag || againstFlag != "") {
+		fmt.Fprintln(os.Stderr, i18n.T("Error: --per-package cannot be combined with --staged or --against"))
+		exit(ExitError)
+	}
+	if stashFlag && !stagedFlag {
+		fmt.Fprintln(os.Stderr, i18n.T("Error: --stash only works with --staged"))
+		exit(ExitError)
+	}
+	if stagedFlag && !pathFilter().Empty() {
+		// git commit <pathspec> would commit the worktree content of those paths
+		fmt.Fprintln(os.Stderr, i18n.T("Error: --staged cannot be combined with --include or --exclude"))
+		exit(ExitError)
+	}
+
+	lockRepository()
+
+	merge := currentMerge()
+	if continueFlag {
+		if merge == nil {
+			fmt.Fprintln(os.Stderr, i18n.T("Error: no merge in progress; there is nothing to continue"))
+			exit(ExitError)
+		}
+		if againstFlag != "" {
+			fmt.Fprintln(os.Stderr, i18n.T("Error: --continue cannot be combined with --against"))
+			exit(ExitError)
+		}
+		// Conclude the merge with the resolutions as staged
+		stagedFlag = true
+	}
+
+	fmt.Fprintln(statusOut, i18n.T("Scanning git repository for changes..."))
+
+	changes, diffContent, err := readRepoChanges()
+	if err == nil && againstFlag != "" {
+		// The message describes the branch, but only pending changes are committed
+		_, _, err = readPendingChanges()
+	}
+	emptyCommit := false
+	if errors.Is(err, git.ErrNoChanges) && allowEmptyFlag {
+		changes, diffContent, err = prompt.EmptyCommitChanges(emptyCommitPurpose()), "", nil
+		emptyCommit = true
+	}
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+
+	if emptyCommit {
+		fmt.Fprintln(statusOut, i18n.T("No changes; creating an empty commit."))
+	} else {
+		fmt.Fprintln(statusOut, i18n.T("Changes detected:"))
+		fmt.Fprintln(statusOut, changes.Summary)
+	}
+	logging.Info("changes detected", "staged", len(changes.Staged), "unstaged", len(changes.Unstaged), "empty", emptyCommit)
+	fmt.Fprintln(statusOut)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+
+	if stagedFlag && (stashFlag || cfg.AutoStash) {
+		stashUnstaged()
+	}
+
+	// --output-file describes a single commit
+	if merge == nil && !emptyCommit && !prFlag && outputFileFlag == "" && (perPackageFlag || cfg.PerPackage) && commitPerPackage(cfg, changes) {
+		return
+	}
+
+	var commitMessage string
+	if merge != nil {
+		fmt.Fprintln(statusOut, i18n.Sprintf("Merge in progress: %s", merge.Subject))
+		commitMessage = generateMergeMessage(cfg, merge, changes, diffContent)
+	} else {
+		commitMessage = generateMessage(cfg, changes, diffContent)
+	}
+
+	commitMessage = confirmMessage(cfg, commitMessage, changes, diffContent)
+	if outputFileFlag != "" {
+		// Written first so that a failed commit can be retried with git commit -F
+		if err := writeMessageFile(outputFileFlag, commitMessage); err != nil {
+			printError(err)
+			exit(ExitError)
+		}
+	}
+	recordCommit(cfg, commitMessage, pathFilter(), emptyCommit)
+	if againstFlag == "" {
+		// With --against the diff covers the whole branch, not just this commit
+		reportTodos(diffContent)
+	}
+	publishCommit(cfg)
+	reportPullRequest()
+	logging.Info("done")
+}
+
+// confirmMessage shows the generated message, or lets the user review or
+// write it, and returns the message to commit
+func confirmMessage(cfg *config.Config, commitMessage string, changes *git.Changes, diffContent string) string {
+	if strings.TrimSpace(commitMessage) != "" {
+		commitMessage = checkSpelling(cfg, commitMessage, diffContent)
+	}
+	if strings.TrimSpace(commitMessage) == "" {
+		fmt.Println(i18n.T("Generated commit message is empty. Please enter a commit message manually:"))
+		manualMessage, err := editMessage(cfg, "", changes, editorFlag || cfg.UseEditor)
+		if err != nil {
+			printError(err)
+			exit(exitCodeFor(err, ExitGenerationFailed))
+		}
+		commitMessage = manualMessage
+		if strings.TrimSpace(commitMessage) == "" {
+			fmt.Fprintln(os.Stderr, i18n.T("Commit message cannot be empty"))
+			exit(ExitCancelled)
+		}
+	} else if reviewFlag || cfg.Review {
+		commitMessage = reviewMessage(cfg, commitMessage, diffContent, changes, editorFlag || cfg.UseEditor)
+	} else {
+		// Server responded with non-empty value - automate, don't pause
+		fmt.Printf("\n%s\n%s\n\n", i18n.T("Generated commit message:"), commitMessage)
+		for _, trailer := range commitTrailers(cfg) {
+			fmt.Println(trailer)
+		}
+		if opts := commitOptions(cfg); opts.Author != "" || opts.Date != "" {
+			fmt.Println(describeOverrides(opts))
+		}
+		fmt.Println(i18n.T("Proceeding with commit and push..."))
+	}
+	return commitMessage
+}
+
+// recordCommit stages the changes matching filter, unless only the index is
+// committed, and commits them with message
+func recordCommit(cfg *config.Config, commitMessage string, filter git.PathFilter, emptyCommit bool) {
+	subject, _, _ := strings.Cut(commitMessage, "\n")
+	spinner := ui.NewSpinner(i18n.T("Staging changes..."))
+	if !emptyCommit && !stagedFlag {
+		if err := git.StagePaths(filter); err != nil {
+			spinner.Stop()
+			printError(err)
+			exit(ExitCommitFailed)
+		}
+	}
+	spinner.SetPhase(i18n.Sprintf("Recording git changes: %s", subject))
+	opts := commitOptions(cfg)
+	opts.Paths = filter
+	opts.AllowEmpty = emptyCommit
+	mode, _ := cfg.GetHookFixes()
+	if mode == config.HookFixesOff {
+		if err := git.CommitWith(commitMessage, opts); err != nil {
+			spinner.Stop()
+			printError(err)
+			exit(ExitCommitFailed)
+		}
+		spinner.Stop()
+	} else {
+		// A merge commit's message describes the merge, not its diff
+		merging := currentMerge() != nil
+		fixes, err := git.CommitChecked(commitMessage, opts)
+		spinner.Stop()
+		if err != nil {
+			printError(err)
+			exit(ExitCommitFailed)
+		}
+		if fixes != nil {
+			if mode == config.HookFixesRegenerate && (merging || againstFlag != "") {
+				mode = config.HookFixesAnnotate
+			}
+			subject = applyHookFixes(cfg, mode, commitMessage, fixes)
+		}
+	}
+	head, _ := git.Head()
+	logging.Info("committed", "commit", head, "subject", subject)
+}
+
+// publishCommit verifies and pushes a freshly created commit
+func publishCommit(cfg *config.Config) {
+	if cfg.NoPush {
+		runCleanups()
+		fmt.Println(i18n.T("Committed locally; pushing is disabled."))
+		logging.Info("push skipped", "reason", "pushing is disabled")
+		return
+	}
+
+	verifyBeforePush(cfg)
+	runCleanups()
+
+	pushed, err := pushCommit()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error: commit successful but %v", err))
+		if hint := pushHint(err); hint != "" {
+			fmt.Fprintln(os.Stderr, hint)
+		}
+		exit(exitCodeFor(err, ExitPushFailed))
+	}
+
+	if pushed {
+		fmt.Println(i18n.T("Successfully committed and pushed!"))
+		logging.Info("pushed")
+	} else {
+		fmt.Println(i18n.T("Committed locally; remote 'origin' not configured, skipping push."))
+		logging.Info("push skipped", "reason", "no origin remote")
+	}
+}
+
+// lockRepository takes the repository lock so that a concurrent run can't
+// stage or commit at the same time; it is released on exit
+func lockRepository() {
+	lock, err := git.AcquireLock()
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	atExit(func() {
+		if err := lock.Release(); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: %v", err))
+		}
+	})
+}
+
+// stashUnstaged sets aside changes that are not staged so that commit hooks and
+// verify_command only see what is being committed; they are restored on exit
+func stashUnstaged() {
+	stashed, err := git.StashUnstaged()
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	if !stashed {
+		return
+	}
+	fmt.Fprintln(statusOut, i18n.T("Stashed unstaged changes; they will be restored after committing."))
+	atExit(func() {
+		if err := git.PopStash(); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: %v", err))
+		}
+	})
+}
+
+// emptyCommitPurpose returns --purpose, asking for it when omitted
+func emptyCommitPurpose() string {
+	const defaultPurpose = "trigger CI"
+	if purpose := strings.TrimSpace(purposeFlag); purpose != "" {
+		return purpose
+	}
+	purpose, err := ui.Ask("Purpose of the empty commit", defaultPurpose)
+	if errors.Is(err, ui.ErrNonInteractive) {
+		return defaultPurpose
+	}
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+	return purpose
+}
+
+// commitOptions collects the flags and config that shape the commit itself
+func commitOptions(cfg *config.Config) git.CommitOptions {
+	opts := git.CommitOptions{
+		Paths:   pathFilter(),
+		Signoff: signoffFlag || cfg.Signoff,
+		Author:  cfg.Author,
+		Date:    cfg.Date,
+	}
+	if authorFlag != "" {
+		opts.Author = authorFlag
+	}
+	if dateFlag != "" {
+		opts.Date = dateFlag
+	}
+	return opts
+}
+
+// describeOverrides summarizes the author and date overrides for previews
+func describeOverrides(opts git.CommitOptions) string {
+	var parts []string
+	if opts.Author != "" {
+		parts = append(parts, "author: "+opts.Author)
+	}
+	if opts.Date != "" {
+		parts = append(parts, "date: "+opts.Date)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// commitTrailers returns the trailers git will append to the message, for previews
+func commitTrailers(cfg *config.Config) []string {
+	var trailers []string
+	if commitOptions(cfg).Signoff {
+		if trailer, err := git.SignoffTrailer(); err == nil {
+			trailers = append(trailers, trailer)
+		} else {
+			logging.Debug("failed to preview sign-off", "error", err)
+		}
+	}
+	return trailers
+}
+
+// pathFilter returns the paths selected with --include and --exclude
+func pathFilter() git.PathFilter {
+	return git.PathFilter{Include: includeFlag, Exclude: excludeFlag}
+}
+
+// verifyBeforePush runs verify_command once the commit exists. On failure it
+// prints the command's output and exits with ExitVerifyFailed, leaving the
+// commit unpushed. Without an origin there is nothing to protect.
+func verifyBeforePush(cfg *config.Config) {
+	command := strings.TrimSpace(cfg.VerifyCommand)
+	if command == "" || skipVerifyFlag {
+		return
+	}
+	if hasOrigin, err := git.HasOrigin(); err != nil || !hasOrigin {
+		return
+	}
+	root, err := git.Root()
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
 
-	changes, err := git.GetChanges()
+	spinner := ui.NewSpinner(i18n.Sprintf("Verifying: %s", command))
+	output, err := verify.Run(root, command)
+	spinner.Stop()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		if output != "" {
+			fmt.Fprintln(os.Stderr, output)
+		}
+		printError(err)
+		fmt.Fprintln(os.Stderr, i18n.T("The commit was kept locally and not pushed. Fix the problem, amend or add a commit, then push."))
+		exit(exitCodeFor(err, ExitVerifyFailed))
+	}
+}
+
+// reviewMessage shows the diff and message until the user accepts the
+// (possibly edited) message or cancels the commit. A positive timeout
+// accepts the message if the user does nothing on the first review.
+func reviewMessage(cfg *config.Config, message, diffContent string, changes *git.Changes, useEditor bool) string {
+	trailers, timeout := commitTrailers(cfg), cfg.GetConfirmTimeout()
+	for {
+		action, err := ui.ReviewCommit(message, diffContent, trailers, timeout)
+		if err != nil {
+			printError(err)
+			exit(exitCodeFor(err, ExitError))
+		}
+		// Only the first review auto-accepts; after an edit the user is here
+		timeout = 0
+
+		switch action {
+		case ui.ReviewAccept:
+			return message
+		case ui.ReviewAutoAccept:
+			fmt.Fprintln(statusOut, i18n.T("No input before the confirm timeout; accepting the message."))
+			return message
+		case ui.ReviewEdit, ui.ReviewEditExternal:
+			edited, err := editMessage(cfg, message, changes, useEditor || action == ui.ReviewEditExternal)
+			if err != nil && !errors.Is(err, ui.ErrCancelled) {
+				printError(err)
+				exit(exitCodeFor(err, ExitError))
+			}
+			if strings.TrimSpace(edited) != "" {
+				message = edited
+			}
+		default:
+			fmt.Fprintln(os.Stderr, i18n.T("Commit cancelled"))
+			exit(ExitCancelled)
+		}
 	}
+}
 
-	fmt.Println("Changes detected:")
-	fmt.Println(changes.Summary)
-	fmt.Println()
+// editMessage lets the user edit message either in the built-in editor or in
+// the editor git is configured to use. The scopes of past commits are offered
+// for completion unless no_style is set.
+func editMessage(cfg *config.Config, message string, changes *git.Changes, external bool) (string, error) {
+	var scopes []string
+	if !cfg.NoStyle {
+		scopes = learnStyle().KnownScopes()
+	}
+	if !external {
+		return ui.EditCommitMessage(message, scopes)
+	}
 
-	diffContent, err := git.GetDiffContent()
+	editor, err := git.Editor()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting diff: %v\n", err)
-		os.Exit(1)
+		return "", err
 	}
+	context := git.PlainSummary(changes)
+	if len(scopes) > 0 {
+		context = append(context, "", i18n.Sprintf("Scopes used in this repository: %s", strings.Join(scopes, ", ")))
+	}
+	return ui.EditInEditor(editor, git.EditMessagePath(), message, context)
+}
 
-	cfg, err := config.LoadConfig()
+// connectProvider creates the configured provider and verifies it is reachable
+func connectProvider(cfg *config.Config) provider.Provider {
+	prov := createProvider(cfg)
+	checkConnection(prov, cfg)
+	return prov
+}
+
+// createProvider builds the configured provider without contacting it
+func createProvider(cfg *config.Config) provider.Provider {
+	apiKey := autogit.APIKeyFromEnv(cfg.Provider)
+	prov, err := autogit.NewProvider(cfg.Provider, cfg.Endpoint, apiKey)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error creating provider: %v", err))
+		exit(ExitError)
 	}
 
-	apiKey := getAPIKeyFromEnv(cfg.Provider)
-	prov, err := newProvider(cfg.Provider, cfg.Endpoint, apiKey)
+	if err := autogit.SetKeyRotation(prov, cfg.KeyRotation); err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	if err := autogit.SetReasoning(prov, cfg.ReasoningEffort, cfg.ThinkingBudget); err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	if err := autogit.SetPromptCache(prov, cfg.PromptCache); err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	autogit.SetOpenAIAccount(prov, cfg.OpenAIOrganization, cfg.OpenAIProject)
+	autogit.SetHeaders(prov, cfg.Headers[cfg.Provider])
+	prov, err = autogit.FilterModels(prov, cfg.ModelFilter)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating provider: %v\n", err)
-		os.Exit(1)
+		printError(err)
+		exit(ExitError)
 	}
 
+	enforcePrivacy(cfg, prov)
 	logAuthStatus(cfg.Provider, apiKey)
+	// Local providers see the real names; remote ones only placeholders
+	anonymized := cfg.Anonymize && autogit.CheckLocal(prov) != nil
 
-	spinner := ui.NewSpinner(fmt.Sprintf("Connecting to %s...", cfg.Provider))
-	if err := prov.CheckConnection(); err != nil {
-		spinner.Stop()
-		fmt.Fprintf(os.Stderr, "Error connecting to %s: %v\n", cfg.Provider, err)
-		os.Exit(1)
+	if auditPath, err := cfg.ResolveAuditLog(); err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: audit log disabled: %v", err))
+	} else if auditPath != "" {
+		logger := audit.NewLogger(auditPath, cfg.AuditLogMaxSizeMB, autogit.SplitAPIKeys(apiKey)...)
+		prov = audit.Wrap(prov, cfg.Provider, autogit.Endpoint(prov), logger)
+	}
+	if anonymized {
+		// Around the audit log, so that it records what was actually sent
+		prov = anonymize.Wrap(prov)
+	}
+	return prov
+}
+
+// enforcePrivacy exits before anything is sent if the privacy mode forbids the provider
+func enforcePrivacy(cfg *config.Config, prov provider.Provider) {
+	switch cfg.Privacy {
+	case "":
+		return
+	case config.PrivacyLocalOnly:
+		if err := autogit.CheckLocal(prov); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Error: privacy is %s but provider %s is remote: %v", cfg.Privacy, cfg.Provider, err))
+			fmt.Fprintln(os.Stderr, i18n.T("Use a local provider such as Ollama on localhost, or remove the privacy setting."))
+			exit(ExitError)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error: unknown privacy mode %q (supported: %s)", cfg.Privacy, config.PrivacyLocalOnly))
+		exit(ExitError)
+	}
+}
+
+// checkConnection exits with ExitProviderUnreachable if the provider cannot be reached
+func checkConnection(prov provider.Provider, cfg *config.Config) {
+	if err := pingProvider(prov, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error connecting to %s: %v", cfg.Provider, err))
+		exit(ExitProviderUnreachable)
+	}
+}
+
+// retryCountdown shows on spinner how long a retry of a rate limited or
+// unavailable provider is waiting
+func retryCountdown(spinner *ui.Spinner) func(int, time.Duration) {
+	return func(status int, wait time.Duration) {
+		format := i18n.T("provider unavailable, retrying in %ds")
+		if status == http.StatusTooManyRequests {
+			format = i18n.T("rate limited, retrying in %ds")
+		}
+		spinner.Countdown(format, wait)
+	}
+}
+
+// pingProvider checks the connection behind a spinner, unless the provider
+// failed often enough recently for the breaker to skip it
+func pingProvider(prov provider.Provider, cfg *config.Config) error {
+	if err := breakerError(cfg); err != nil {
+		return err
 	}
+	spinner := ui.NewSpinner(i18n.Sprintf("Connecting to %s...", cfg.Provider))
+	err := prov.CheckConnection()
 	spinner.Stop()
+	recordConnection(cfg, err)
+	return err
+}
+
+// breakerError returns why the provider is skipped when it failed
+// breaker_threshold times in a row within breaker_cooldown, so that a down
+// endpoint doesn't delay every commit by a connection timeout
+func breakerError(cfg *config.Config) error {
+	cooldown := cfg.GetBreakerCooldown()
+	entry, open := config.BreakerOpen(cfg.Provider, cfg.GetBreakerThreshold(), cooldown)
+	if !open {
+		return nil
+	}
+	left := time.Until(entry.LastFailure.Add(cooldown)).Round(time.Second)
+	logging.Debug("provider skipped by circuit breaker", "provider", cfg.Provider, "failures", entry.Failures, "retry_in", left)
+	return fmt.Errorf("skipped after %d failed connections in a row, trying again in %s", entry.Failures, left)
+}
+
+// recordConnection counts a failed connection towards the breaker, or resets
+// it after a successful one
+func recordConnection(cfg *config.Config, err error) {
+	record := config.RecordProviderSuccess
+	if err != nil {
+		record = config.RecordProviderFailure
+	}
+	if err := record(cfg.Provider); err != nil {
+		logging.Debug("failed to update circuit breaker", "provider", cfg.Provider, "error", err)
+	}
+}
 
+// fallbackMessage is used instead of a generated message when the provider is
+// unreachable. With no_fallback set it exits with ExitProviderUnreachable instead.
+func fallbackMessage(cfg *config.Config, changes *git.Changes, err error) string {
+	if cfg.NoFallback {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error connecting to %s: %v", cfg.Provider, err))
+		exit(ExitProviderUnreachable)
+	}
+
+	logging.Warn("provider unreachable, using fallback message", "provider", cfg.Provider, "error", err)
+	fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: could not reach %s: %v", cfg.Provider, err))
+	fmt.Fprintln(os.Stderr, i18n.T("Warning: using a rule-based FALLBACK message instead of a generated one"))
+	message := prompt.FallbackMessage(changes)
+	if style, err := emoji.Parse(cfg.Emoji, cfg.EmojiPosition, cfg.EmojiMap); err == nil {
+		message = style.Apply(message)
+	}
+	return message
+}
+
+// resolveModel returns the configured model, asking the user to pick another one
+// when the provider reports that it is not available
+func resolveModel(prov provider.Provider, cfg *config.Config) string {
 	selectedModel := cfg.Model
 
 	// Try to list models and validate the selected model
-	spinner = ui.NewSpinner("Fetching available models...")
+	spinner := ui.NewSpinner(i18n.T("Fetching available models..."))
 	models, err := prov.ListModels()
 	spinner.Stop()
+	if err == nil {
+		cacheModelNames(cfg.Provider, models)
+	}
 	if err == nil && len(models) > 0 {
 		found := false
 		for _, m := range models {
@@ -285,95 +1061,244 @@ func run(cmd *cobra.Command, args []string) {
 		}
 
 		if !found {
-			fmt.Printf("Model '%s' not found. Please select a model:\n", selectedModel)
-			selected, err := ui.SelectModel(models, models[0].Name)
+			if ui.IsInteractive() {
+				fmt.Fprintln(statusOut, i18n.Sprintf("Model '%s' not found. Please select a model:", selectedModel))
+			}
+			selected, err := ui.SelectModel(models, defaultModelChoice(models))
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error selecting model: %v\n", err)
-				os.Exit(1)
+				fmt.Fprintln(os.Stderr, i18n.Sprintf("Error selecting model: %v", err))
+				exit(exitCodeFor(err, ExitError))
+			}
+			if !ui.IsInteractive() {
+				fmt.Fprintln(statusOut, i18n.Sprintf("Model '%s' not found. Using %s", selectedModel, selected))
 			}
 			selectedModel = selected
 			if err := config.SetModel(selectedModel); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to save model preference: %v\n", err)
+				fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: failed to save model preference: %v", err))
 			}
 		}
 	} else if err != nil {
 		// If listing fails, continue with configured model
-		fmt.Printf("Warning: Could not list models: %v. Using configured model: %s\n", err, selectedModel)
+		fmt.Fprintln(statusOut, i18n.Sprintf("Warning: Could not list models: %v. Using configured model: %s", err, selectedModel))
 	}
 
-	fmt.Printf("Using provider: %s, model: %s\n", cfg.Provider, selectedModel)
+	return selectedModel
+}
 
-	systemPrompt, userPrompt := prompt.BuildFullPrompt(changes, diffContent)
+// generateMessage asks the configured provider for a commit message describing the changes
+//
+// The connection check and model validation are skipped with --fast or when the
+// configured model is in a fresh model cache; they then only run if generation fails.
+// An unreachable provider yields a rule-based fallback message unless no_fallback is set.
+// With --compare, the message is picked among those of several models. The
+// provider and model may be chosen per run by model_rules.
+func generateMessage(cfg *config.Config, changes *git.Changes, diffContent string) string {
+	cfg = applyModelRules(cfg, changes)
+	prov := createProvider(cfg)
+	guardDiffSize(cfg, prov, diffContent)
+	if models := compareModels(); len(models) > 0 {
+		return compareMessages(prov, cfg, models, changes, diffContent)
+	}
+	selectedModel := cfg.Model
 
-	spinner = ui.NewSpinner("Generating commit message...")
-	commitMessage, err := prov.GenerateCommitMessage(selectedModel, systemPrompt, userPrompt)
-	spinner.Stop()
+	validated := false
+	if skip, reason := canSkipValidation(cfg); skip {
+		logging.Debug("skipping connection check and model validation", "reason", reason)
+		if err := breakerError(cfg); err != nil {
+			return fallbackMessage(cfg, changes, err)
+		}
+	} else {
+		if err := pingProvider(prov, cfg); err != nil {
+			return fallbackMessage(cfg, changes, err)
+		}
+		selectedModel = resolveModel(prov, cfg)
+		validated = true
+	}
+
+	commitMessage, err := generateWith(prov, cfg, selectedModel, changes, diffContent)
+	if err != nil && !validated {
+		// Find out whether the endpoint or the model is the problem, then retry once
+		logging.Debug("generation failed, validating provider and model", "error", err)
+		if pingErr := pingProvider(prov, cfg); pingErr != nil {
+			return fallbackMessage(cfg, changes, pingErr)
+		}
+		if model := resolveModel(prov, cfg); model != selectedModel {
+			selectedModel = model
+			commitMessage, err = generateWith(prov, cfg, selectedModel, changes, diffContent)
+		}
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error generating commit message: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error generating commit message: %v", err))
+		exit(ExitGenerationFailed)
+	}
+	if !validated {
+		recordConnection(cfg, nil)
 	}
 
-	commitMessage = prompt.ExtractCommitMessage(commitMessage)
+	return commitMessage
+}
 
-	if strings.TrimSpace(commitMessage) == "" {
-		fmt.Println("Generated commit message is empty. Please enter a commit message manually:")
-		manualMessage, err := ui.EditCommitMessage("")
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
-		commitMessage = manualMessage
-		if strings.TrimSpace(commitMessage) == "" {
-			fmt.Fprintf(os.Stderr, "Commit message cannot be empty\n")
-			os.Exit(1)
+// canSkipValidation reports whether the pre-generation checks can be skipped and why
+func canSkipValidation(cfg *config.Config) (bool, string) {
+	if fastFlag || cfg.Fast {
+		return true, "fast mode"
+	}
+	// The repository's own list wins, as its endpoint may differ
+	var models []string
+	fresh := false
+	if s := repoState(); s != nil {
+		models, fresh = s.FreshModels(cfg.Provider, cfg.GetModelCacheTTL())
+	}
+	if !fresh {
+		models, fresh = config.FreshCachedModels(cfg.Provider, cfg.GetModelCacheTTL())
+	}
+	if !fresh {
+		return false, ""
+	}
+	for _, m := range models {
+		if m == cfg.Model {
+			return true, "model found in cached model list"
 		}
-	} else {
-		// Server responded with non-empty value - automate, don't pause
-		fmt.Printf("\nGenerated commit message:\n%s\n\n", commitMessage)
-		fmt.Println("Proceeding with commit and push...")
 	}
+	return false, ""
+}
 
-	spinner = ui.NewSpinner(fmt.Sprintf("Recording git changes: %s", commitMessage))
-	pushed, err := git.StageAndCommitAndPush(commitMessage)
+// generateWith runs the generation pipeline with model. An empty reply, or in
+// strict mode one that isn't Conventional Commits, is not an error; callers
+// fall back to asking the user for a message.
+func generateWith(prov provider.Provider, cfg *config.Config, model string, changes *git.Changes, diffContent string) (string, error) {
+	engine, err := newEngine(prov, cfg, model)
 	if err != nil {
-		spinner.Stop()
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return "", err
 	}
-	spinner.Stop()
+	warnContextOverflow(model, engine.PromptTokens(changes, diffContent), engine.ContextWindow())
 
-	if pushed {
-		fmt.Println("Successfully committed and pushed!")
+	fmt.Fprintln(statusOut, i18n.Sprintf("Using provider: %s, model: %s", cfg.Provider, model))
+
+	spinner := ui.NewSpinner(i18n.Sprintf("Generating commit message with %s...", model))
+	transport.OnWait = retryCountdown(spinner)
+	start := time.Now()
+	var commitMessage string
+	if prFlag {
+		commitMessage, err = generatePR(engine, changes, diffContent)
 	} else {
-		fmt.Println("Committed locally; remote 'origin' not configured, skipping push.")
+		commitMessage, err = engine.Generate(changes, diffContent)
+	}
+	spinner.Stop()
+	transport.OnWait = nil
+	if used := engine.Model(); used != model {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Note: the prompt was too long for %s; used %s instead", model, used))
+		model = used
+	}
+	logging.Debug("generation finished", "provider", cfg.Provider, "model", model, "duration", time.Since(start), "error", err)
+	if err == nil {
+		logging.Info("message generated", "provider", cfg.Provider, "model", model)
+		rememberMessage(cfg.Provider, model, commitMessage)
+	}
+
+	if errors.Is(err, autogit.ErrEmptyMessage) {
+		return "", nil
+	}
+	if errors.Is(err, autogit.ErrNotConventional) {
+		// Let the user write the message rather than commit a rejected one
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Warning: %v", err))
+		return "", nil
 	}
+	if errors.Is(err, autogit.ErrNoPullRequest) {
+		logging.Debug("model replied without pull request text", "error", err)
+		fmt.Fprintln(os.Stderr, i18n.T("Warning: the model returned only a commit message; no pull request text was generated"))
+		return commitMessage, nil
+	}
+	return commitMessage, err
+}
+
+// newEngine sets up the generation pipeline for model with the configured
+// prompt and message settings
+func newEngine(prov provider.Provider, cfg *config.Config, model string) (*autogit.Engine, error) {
+	return autogit.New(
+		autogit.WithProvider(prov),
+		autogit.WithModel(model),
+		autogit.WithRedactor(promptRedactor(cfg)),
+		autogit.WithStyleGuide(styleGuide(cfg)),
+		autogit.WithContextWindow(cfg.ContextWindow),
+		autogit.WithStrictConventional(cfg.StrictConventional),
+		autogit.WithTemplates(cfg.Templates),
+		autogit.WithBranch(git.CurrentBranch()),
+		autogit.WithEmoji(cfg.Emoji, cfg.EmojiPosition),
+		autogit.WithEmojiMap(cfg.EmojiMap),
+		autogit.WithBannedWords(cfg.BannedWords, cfg.BannedWordsAction),
+		autogit.WithLargeContextModel(cfg.LargeContextModel),
+		autogit.WithDiffCompression(cfg.DiffCompression),
+	)
+}
+
+// styleGuide returns prompt instructions learned from the repository's commit history
+func styleGuide(cfg *config.Config) string {
+	if cfg.NoStyle {
+		return ""
+	}
+	return learnStyle().Guide()
+}
+
+// learnStyle returns the repository's style profile. The history is analyzed on
+// first use and again once the profile stored in .git/auto-git is stale; outside
+// a repository or on failure it returns nil.
+func learnStyle() *style.Profile {
+	dir, err := git.StateDir()
+	if err != nil {
+		logging.Debug("no repository for style profile", "error", err)
+		return nil
+	}
+
+	profile, err := style.Load(dir)
+	if err == nil && !profile.Stale() {
+		return profile
+	}
+
+	subjects, err := git.RecentSubjects(style.SampleSize)
+	if err != nil {
+		logging.Debug("failed to read history for style profile", "error", err)
+		return profile
+	}
+	profile = style.Analyze(subjects)
+	if err := profile.Save(dir); err != nil {
+		logging.Debug("failed to save style profile", "error", err)
+	}
+	logging.Debug("learned commit style", "commits", profile.Commits, "dir", dir)
+	return profile
+}
+
+// promptRedactor returns the configured diff redaction for prompts, exiting if
+// a custom pattern does not compile
+func promptRedactor(cfg *config.Config) func(string) string {
+	r, err := redact.New(redact.Rules{
+		Emails:    cfg.Redact.Emails,
+		IPs:       cfg.Redact.IPs,
+		EnvValues: cfg.Redact.EnvValues,
+		Patterns:  cfg.Redact.Patterns,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error in redact config: %v", err))
+		exit(ExitError)
+	}
+	return r.Apply
 }
 
 func logAuthStatus(providerType, apiKey string) {
+	envVar := autogit.APIKeyEnvVar(providerType)
+	if envVar == "" {
+		return
+	}
 	if apiKey == "" {
-		var envVar string
-		switch providerType {
-		case ProviderOllama:
-			envVar = "OLLAMA_API_KEY"
-		case ProviderSiliconFlow:
-			envVar = "SILICON_KEY"
-		case ProviderOpenAI:
-			envVar = "OPENAI_API_KEY"
-		}
-		fmt.Printf("Connecting to %s without %s (requests may be unauthenticated).\n", providerType, envVar)
+		fmt.Fprintln(statusOut, i18n.Sprintf("Connecting to %s without %s (requests may be unauthenticated).", providerType, envVar))
 		return
 	}
 
-	var envVar string
-	switch providerType {
-	case ProviderOllama:
-		envVar = "OLLAMA_API_KEY"
-	case ProviderSiliconFlow:
-		envVar = "SILICON_KEY"
-	case ProviderOpenAI:
-		envVar = "OPENAI_API_KEY"
+	if keys := autogit.SplitAPIKeys(apiKey); len(keys) > 1 {
+		fmt.Fprintln(statusOut, i18n.Sprintf("Using %s for authentication (%d keys)", envVar, len(keys)))
+		return
 	}
-	fmt.Printf("Using %s for authentication (%s)\n", envVar, maskAPIKey(apiKey))
+	fmt.Fprintln(statusOut, i18n.Sprintf("Using %s for authentication (%s)", envVar, maskAPIKey(apiKey)))
 }
 
 func maskAPIKey(key string) string {
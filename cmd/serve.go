@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"auto-git/internal/config"
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/logging"
+	"auto-git/internal/server"
+	"auto-git/internal/ui"
+	"auto-git/pkg/autogit"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr string
+	serveWeb  bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a local HTTP API for editors and GUI clients",
+	Long: `Start an HTTP server on localhost exposing:
+
+  GET  /health    liveness check
+  GET  /models    models offered by the configured provider
+  GET  /changes   changed files and the diff of the current repository
+  POST /generate  {"diff": "...", "model": "..."} -> {"message": "...", ...}
+                  (without a diff the current repository changes are used)
+  POST /commit    {"message": "...", "push": true} -> stages and commits
+                  (verify_command must pass before pushing)
+                  (without a message one is generated)
+
+With --web, a review page at / shows the changed files, the diff and the
+generated message, which can be edited, regenerated and committed. It is
+opened in the browser when auto-git runs in a terminal.
+
+Requests must use Content-Type: application/json. Only loopback addresses
+are accepted, requests must name localhost or a loopback address in their
+Host header, and cross-origin browser requests are rejected.`,
+	Args: cobra.NoArgs,
+	Run:  runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7878", "loopback address to listen on")
+	serveCmd.Flags().BoolVar(&serveWeb, "web", false, "serve a review page for the changes at /")
+}
+
+func runServe(cmd *cobra.Command, args []string) {
+	if err := server.CheckLoopback(serveAddr); err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+
+	// Prompts would wait on the terminal while the request hangs
+	git.SetCredentialPrompts(false)
+
+	prov := connectProvider(cfg)
+	srv := server.New(server.Options{
+		Provider:     prov,
+		ProviderName: cfg.Provider,
+		Model:        cfg.Model,
+		NewEngine: func(model string) (*autogit.Engine, error) {
+			return newEngine(prov, cfg, model)
+		},
+		Paths:         pathFilter(),
+		Commit:        serveCommit(cfg),
+		VerifyCommand: strings.TrimSpace(cfg.VerifyCommand),
+		Web:           serveWeb,
+	})
+
+	fmt.Println(i18n.Sprintf("Serving auto-git API on http://%s (provider: %s, model: %s)", serveAddr, cfg.Provider, cfg.Model))
+	if serveWeb {
+		pageURL := "http://" + serveAddr + "/"
+		fmt.Println(i18n.Sprintf("Review page: %s", pageURL))
+		if ui.IsInteractive() {
+			// The page loads once the server below is listening
+			go openBrowser(pageURL)
+		}
+	}
+	if err := srv.ListenAndServe(serveAddr); err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+}
+
+// serveCommit stages and commits for the API as recordCommit does for a run:
+// with the --include/--exclude paths, the author, date and sign-off settings,
+// and hook_fixes
+func serveCommit(cfg *config.Config) func(message string) error {
+	filter := pathFilter()
+	return func(message string) error {
+		if err := git.StagePaths(filter); err != nil {
+			return err
+		}
+		opts := commitOptions(cfg)
+		mode, _ := cfg.GetHookFixes()
+		if mode == config.HookFixesOff {
+			return git.CommitWith(message, opts)
+		}
+		fixes, err := git.CommitChecked(message, opts)
+		if err != nil {
+			return err
+		}
+		if fixes != nil {
+			applyHookFixes(cfg, mode, message, fixes)
+		}
+		return nil
+	}
+}
+
+// openBrowser opens url in the default browser. Failures are only logged:
+// the address is printed for opening it by hand.
+func openBrowser(url string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		logging.Debug("could not open the browser", "error", err)
+		return
+	}
+	go cmd.Wait()
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"auto-git/internal/config"
+	"auto-git/internal/i18n"
+	"auto-git/internal/spell"
+	"auto-git/internal/ui"
+)
+
+// checkSpelling looks for typos in the subject of a generated message, such
+// as identifiers from the diff the model misspelled, and offers to correct
+// them. Non-interactive runs only warn.
+func checkSpelling(cfg *config.Config, message, diffContent string) string {
+	if cfg.NoSpellCheck {
+		return message
+	}
+	subject, _, _ := strings.Cut(message, "\n")
+	corrections := spell.Check(subject, spell.Identifiers(diffContent))
+	if len(corrections) == 0 {
+		return message
+	}
+
+	fmt.Fprintln(os.Stderr, i18n.T("Warning: possible typos in the generated message:"))
+	for _, c := range corrections {
+		fmt.Fprintf(os.Stderr, "  %s → %s\n", c.Word, c.Suggestion)
+	}
+	fix, err := ui.Confirm(i18n.T("Apply these corrections?"), true)
+	if errors.Is(err, ui.ErrNonInteractive) {
+		return message
+	}
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+	if !fix {
+		return message
+	}
+	return spell.Apply(message, corrections)
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var squashCmd = &cobra.Command{
+	Use:   "squash N",
+	Short: "Squash the last N commits into one with a regenerated message",
+	Long: `Squash the last N commits into a single commit whose message is generated
+from their combined diff, e.g. to collapse a string of WIP commits before
+pushing. The original subjects are given to the model as context.
+
+The new commit keeps the author and date of the oldest squashed commit and
+is not pushed. Commits that are already on the upstream branch are refused
+unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSquash,
+}
+
+var squashForce bool
+
+func init() {
+	squashCmd.Flags().BoolVar(&squashForce, "force", false, "squash even if some of the commits have already been pushed")
+}
+
+func runSquash(cmd *cobra.Command, args []string) {
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error: invalid number of commits %q", args[0]))
+		exit(ExitError)
+	}
+
+	lockRepository()
+
+	base, err := git.SquashBase(n, squashForce)
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	head, err := git.Head()
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+
+	changes, diffContent, err := git.DiffSince(base)
+	if err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+	subjects, err := git.SubjectsSince(base)
+	if err != nil {
+		printError(err)
+		exit(ExitError)
+	}
+	changes.Context = "Commits being squashed, oldest first:\n  " + strings.Join(subjects, "\n  ")
+
+	fmt.Fprintln(statusOut, i18n.Sprintf("Squashing %d commits:", n))
+	fmt.Fprintln(statusOut, changes.Summary)
+	fmt.Fprintln(statusOut)
+	fmt.Fprintln(statusOut, changes.Context)
+	fmt.Fprintln(statusOut)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+
+	message := generateMessage(cfg, changes, diffContent)
+	if strings.TrimSpace(message) == "" {
+		fmt.Println(i18n.T("Generated commit message is empty. Please enter a commit message manually:"))
+		message, err = editMessage(cfg, "", changes, cfg.UseEditor)
+		if err != nil {
+			printError(err)
+			exit(exitCodeFor(err, ExitGenerationFailed))
+		}
+		if strings.TrimSpace(message) == "" {
+			fmt.Fprintln(os.Stderr, i18n.T("Commit message cannot be empty"))
+			exit(ExitCancelled)
+		}
+	} else {
+		// Rewriting history deserves a look before it happens
+		message = reviewMessage(cfg, message, diffContent, changes, cfg.UseEditor)
+	}
+
+	opts := commitOptions(cfg)
+	opts.Paths = git.PathFilter{}
+	if author, date, err := git.FirstAuthorSince(base); err == nil {
+		if opts.Author == "" {
+			opts.Author = author
+		}
+		if opts.Date == "" {
+			opts.Date = date
+		}
+	}
+
+	if err := git.SoftReset(base); err != nil {
+		printError(err)
+		exit(ExitCommitFailed)
+	}
+	if err := git.CommitWith(message, opts); err != nil {
+		printError(err)
+		if err := git.SoftReset(head); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Error: %v; run 'git reset --soft %s' to restore the original commits", err, head))
+		} else {
+			fmt.Fprintln(os.Stderr, i18n.T("The original commits have been restored."))
+		}
+		exit(ExitCommitFailed)
+	}
+
+	subject, _, _ := strings.Cut(message, "\n")
+	fmt.Println(i18n.Sprintf("Squashed %d commits into: %s", n, subject))
+	fmt.Println(i18n.Sprintf("Undo with: git reset --soft %s", head))
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"auto-git/internal/git"
+	"auto-git/internal/logging"
+	"auto-git/internal/state"
+)
+
+// repoState returns the state kept in .git/auto-git, or nil outside a
+// repository or when it can't be read
+func repoState() *state.State {
+	dir, err := git.StateDir()
+	if err != nil {
+		return nil
+	}
+	s, err := state.Load(dir)
+	if err != nil {
+		logging.Debug("failed to read repository state", "error", err)
+		return nil
+	}
+	return s
+}
+
+// updateRepoState applies change to the state kept in .git/auto-git. Outside
+// a repository there is nothing to update.
+func updateRepoState(change func(*state.State)) {
+	dir, err := git.StateDir()
+	if err != nil {
+		return
+	}
+	if err := state.Update(dir, change); err != nil {
+		logging.Debug("failed to save repository state", "error", err)
+	}
+}
+
+// rememberMessage records a generated message and its model for this
+// repository, e.g. for auto-git message --last
+func rememberMessage(providerName, model, message string) {
+	updateRepoState(func(s *state.State) { s.RecordMessage(providerName, model, message) })
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+
+	"auto-git/internal/i18n"
+	"auto-git/internal/todo"
+)
+
+// reportTodos lists the TODO/FIXME comments a commit added and resolved, as
+// a gentle reminder of the technical debt it leaves behind or pays off
+func reportTodos(diffContent string) {
+	delta := todo.Scan(diffContent)
+	if delta.Empty() {
+		return
+	}
+
+	fmt.Fprintln(statusOut, i18n.Sprintf("TODO/FIXME: %d added, %d resolved", len(delta.Added), len(delta.Removed)))
+	for _, item := range delta.Added {
+		fmt.Fprintf(statusOut, "  + %s\n", item)
+	}
+	for _, item := range delta.Removed {
+		fmt.Fprintf(statusOut, "  - %s\n", item)
+	}
+}
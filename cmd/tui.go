@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"auto-git/internal/config"
+	"auto-git/internal/git"
+	"auto-git/internal/i18n"
+	"auto-git/internal/provider"
+	"auto-git/internal/ui"
+	"auto-git/internal/verify"
+
+	"github.com/spf13/cobra"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Stage, describe, commit and push from one full-screen interface",
+	Long: `Open a full-screen interface that stays up between commits. The changed
+files are listed next to the diff of the selected one, with the generated
+message below, which can be edited in place:
+
+  space   stage or unstage the selected file
+  a       stage all changes
+  r       regenerate the message
+  i       edit the message (esc or tab to leave)
+  c       commit (everything, when nothing is staged)
+  p       push, after verify_command passes
+  q       quit
+
+The message describes the staged changes, or all changes while nothing is
+staged. Untracked files are listed once staged, e.g. with a. --include and
+--exclude limit the files shown. Other keys can be set with the keys
+setting; ctrl+c always quits.
+
+The repository stays locked while the interface is open, so other auto-git
+runs in it fail until you quit.`,
+	Args: cobra.NoArgs,
+	Run:  runTUI,
+}
+
+func runTUI(cmd *cobra.Command, args []string) {
+	if !ui.IsInteractive() {
+		printError(fmt.Errorf("auto-git tui needs a terminal: %w", ui.ErrNonInteractive))
+		exit(ExitError)
+	}
+	lockRepository()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error loading config: %v", err))
+		exit(ExitError)
+	}
+	// git would prompt for credentials underneath the interface
+	git.SetCredentialPrompts(false)
+
+	prov := connectProvider(cfg)
+	if err := ui.RunApp(tuiActions(prov, cfg)); err != nil {
+		printError(err)
+		exit(exitCodeFor(err, ExitError))
+	}
+}
+
+// tuiActions connects the interface to the repository and the provider
+func tuiActions(prov provider.Provider, cfg *config.Config) ui.AppActions {
+	filter := pathFilter()
+	return ui.AppActions{
+		Load: func() ([]ui.AppFile, error) {
+			return tuiFiles(filter)
+		},
+		Stage: func(path string, staged bool) error {
+			if staged {
+				return git.StagePaths(git.ExactPaths([]string{path}))
+			}
+			return git.UnstagePaths(git.ExactPaths([]string{path}))
+		},
+		StageAll: func() error {
+			return git.StagePaths(filter)
+		},
+		Generate: func() (string, error) {
+			changes, diffContent, err := git.CollectStaged(".", filter)
+			if errors.Is(err, git.ErrNoChanges) {
+				changes, diffContent, err = git.CollectFiltered(".", filter)
+			}
+			if err != nil {
+				return "", err
+			}
+			engine, err := newEngine(prov, cfg, cfg.Model)
+			if err != nil {
+				return "", err
+			}
+			message, err := engine.Generate(changes, diffContent)
+			if err != nil {
+				return "", err
+			}
+			rememberMessage(cfg.Provider, cfg.Model, message)
+			return message, nil
+		},
+		Commit: func(message string) error {
+			if _, _, err := git.CollectStaged(".", filter); errors.Is(err, git.ErrNoChanges) {
+				if err := git.StagePaths(filter); err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			}
+			// Only the index is committed, as staged in the interface
+			opts := commitOptions(cfg)
+			opts.Paths = git.PathFilter{}
+			return git.CommitWith(message, opts)
+		},
+		Push: func() (string, error) {
+			return tuiPush(cfg)
+		},
+	}
+}
+
+// tuiFiles lists the changed files matching filter with their patches
+func tuiFiles(filter git.PathFilter) ([]ui.AppFile, error) {
+	changes, diffContent, err := git.CollectFiltered(".", filter)
+	if errors.Is(err, git.ErrNoChanges) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	type patchKey struct {
+		path   string
+		staged bool
+	}
+	patches := map[patchKey]string{}
+	for _, p := range git.SplitPatch(diffContent) {
+		patches[patchKey{p.Path, p.Staged}] = p.Patch
+	}
+	var files []ui.AppFile
+	for _, group := range []struct {
+		files  []git.FileChange
+		staged bool
+	}{{changes.Staged, true}, {changes.Unstaged, false}} {
+		for _, f := range group.files {
+			files = append(files, ui.AppFile{
+				Path:      f.Path,
+				Staged:    group.staged,
+				Additions: f.Additions,
+				Deletions: f.Deletions,
+				Patch:     patches[patchKey{f.Path, group.staged}],
+			})
+		}
+	}
+	return files, nil
+}
+
+// tuiPush runs verify_command and pushes, setting the upstream branch if
+// there is none yet
+func tuiPush(cfg *config.Config) (string, error) {
+	if cfg.NoPush {
+		return "", fmt.Errorf("pushing is disabled")
+	}
+	hasOrigin, err := git.HasOrigin()
+	if err != nil {
+		return "", err
+	}
+	if !hasOrigin {
+		return i18n.T("Remote 'origin' not configured; nothing to push"), nil
+	}
+
+	if command := strings.TrimSpace(cfg.VerifyCommand); command != "" {
+		root, err := git.Root()
+		if err != nil {
+			return "", err
+		}
+		if _, err := verify.Run(root, command); err != nil {
+			return "", fmt.Errorf("%w; not pushed", err)
+		}
+	}
+
+	_, err = git.PushIfRemoteExists()
+	if errors.Is(err, git.ErrNoUpstream) && git.CurrentBranch() != "" {
+		err = git.PushSetUpstream()
+	}
+	if err != nil {
+		if hint := pushHint(err); hint != "" {
+			return "", fmt.Errorf("%w. %s", err, hint)
+		}
+		return "", err
+	}
+	return i18n.T("Pushed"), nil
+}
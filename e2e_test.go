@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// binary is the auto-git executable built by TestMain
+var binary string
+
+// TestMain builds auto-git once; the tests run it against temporary
+// repositories with the offline mock provider
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "auto-git-e2e")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	binary = filepath.Join(dir, "auto-git")
+	if out, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build auto-git: %v\n%s", err, out)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// repo is a temporary repository with one commit
+type repo struct {
+	t    *testing.T
+	dir  string
+	home string
+}
+
+func newRepo(t *testing.T) *repo {
+	t.Helper()
+	r := &repo{t: t, dir: t.TempDir(), home: t.TempDir()}
+	r.git("init", "-q", "-b", "main")
+	r.git("config", "user.name", "Test")
+	r.git("config", "user.email", "test@example.com")
+	r.git("config", "commit.gpgsign", "false")
+	r.write("README.md", "hello\n")
+	r.git("add", "-A")
+	r.git("commit", "-q", "-m", "initial commit")
+	return r
+}
+
+// env isolates runs from the user's configuration and from CI detection
+func (r *repo) env(extra ...string) []string {
+	env := append(os.Environ(),
+		"HOME="+r.home,
+		"XDG_CONFIG_HOME="+filepath.Join(r.home, ".config"),
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_CONFIG_GLOBAL="+os.DevNull,
+		"AUTO_GIT_CI=0",
+		"NO_COLOR=1",
+	)
+	return append(env, extra...)
+}
+
+func (r *repo) git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = r.env()
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (r *repo) write(name, content string) {
+	r.t.Helper()
+	path := filepath.Join(r.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// run runs auto-git with the mock provider and returns its stdout, stderr
+// and exit code
+func (r *repo) run(env []string, args ...string) (string, string, int) {
+	r.t.Helper()
+	cmd := exec.Command(binary, append([]string{"--provider", "mock", "--non-interactive"}, args...)...)
+	cmd.Dir = r.dir
+	cmd.Env = r.env(env...)
+	var stdout, stderr strings.Builder
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		r.t.Fatalf("failed to run auto-git: %v", err)
+	}
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+func (r *repo) subject() string {
+	r.t.Helper()
+	return r.git("log", "-1", "--format=%s")
+}
+
+func TestCommitsStagedNewFile(t *testing.T) {
+	r := newRepo(t)
+	r.write("docs/guide.md", "# Guide\n")
+	r.git("add", "docs")
+
+	if _, stderr, code := r.run(nil); code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	if got, want := r.subject(), "docs(docs): add guide.md"; got != want {
+		t.Errorf("subject = %q, want %q", got, want)
+	}
+	if status := r.git("status", "--porcelain"); status != "" {
+		t.Errorf("worktree not clean after commit:\n%s", status)
+	}
+}
+
+func TestCommitsModifiedFiles(t *testing.T) {
+	r := newRepo(t)
+	r.write("README.md", "hello\nworld\n")
+	r.write("main.go", "package main\n")
+	r.git("add", "main.go")
+
+	if _, stderr, code := r.run(nil); code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	if got, want := r.subject(), "chore: update 2 files"; got != want {
+		t.Errorf("subject = %q, want %q", got, want)
+	}
+}
+
+func TestStagedLeavesWorktreeChanges(t *testing.T) {
+	r := newRepo(t)
+	r.write("main.go", "package main\n")
+	r.git("add", "main.go")
+	r.write("README.md", "hello\nunstaged\n")
+
+	if _, stderr, code := r.run(nil, "--staged"); code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	if got, want := r.subject(), "feat: add main.go"; got != want {
+		t.Errorf("subject = %q, want %q", got, want)
+	}
+	if files := r.git("show", "--name-only", "--format=", "HEAD"); files != "main.go" {
+		t.Errorf("committed files = %q, want main.go", files)
+	}
+	if status := r.git("status", "--porcelain"); status != "M README.md" {
+		t.Errorf("status = %q, want the unstaged README.md", status)
+	}
+}
+
+func TestNoChangesExitCode(t *testing.T) {
+	r := newRepo(t)
+	if _, _, code := r.run(nil); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+	if got := r.subject(); got != "initial commit" {
+		t.Errorf("subject = %q, want no new commit", got)
+	}
+}
+
+func TestMessageDoesNotCommit(t *testing.T) {
+	r := newRepo(t)
+	r.write("docs/guide.md", "# Guide\n")
+	r.git("add", "docs")
+
+	stdout, stderr, code := r.run(nil, "message")
+	if code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	if got, want := strings.TrimSpace(stdout), "docs(docs): add guide.md"; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+	if got := r.subject(); got != "initial commit" {
+		t.Errorf("subject = %q, want no new commit", got)
+	}
+}
+
+func TestMockTemplate(t *testing.T) {
+	r := newRepo(t)
+	r.write("README.md", "hello\nworld\n")
+
+	stdout, stderr, code := r.run([]string{"AUTO_GIT_MOCK_TEMPLATE=docs: {{.Action}} {{len .Files}} file via {{.Model}}"}, "message")
+	if code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	if got, want := strings.TrimSpace(stdout), "docs: update 1 file via mock"; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+}
+
+func TestBatchLog(t *testing.T) {
+	r := newRepo(t)
+	r.write("main.go", "package main\n")
+	r.git("add", "main.go")
+
+	stdout, stderr, code := r.run(nil, "--batch")
+	if code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	var events []string
+	var subject string
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		var event struct {
+			Msg     string `json:"msg"`
+			Subject string `json:"subject"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("stdout line is not JSON: %q", scanner.Text())
+		}
+		events = append(events, event.Msg)
+		if event.Msg == "committed" {
+			subject = event.Subject
+		}
+	}
+	want := []string{"changes detected", "message generated", "committed", "push skipped", "done"}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %q, want %q", events, want)
+	}
+	if subject != "feat: add main.go" || r.subject() != subject {
+		t.Errorf("logged subject %q, committed %q", subject, r.subject())
+	}
+}
+
+func TestConfigShowIncludesGitConfig(t *testing.T) {
+	r := newRepo(t)
+	r.git("config", "autogit.model", "from-git-config")
+	r.git("config", "autogit.nosuchkey", "1")
+
+	cmd := exec.Command(binary, "config", "show")
+	cmd.Dir = r.dir
+	cmd.Env = r.env()
+	var stdout, stderr strings.Builder
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("config show: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Model: from-git-config") {
+		t.Errorf("output does not show the git config model:\n%s", stdout.String())
+	}
+	if n := strings.Count(stderr.String(), "autogit.nosuchkey"); n != 1 {
+		t.Errorf("unknown key reported %d times, want once:\n%s", n, stderr.String())
+	}
+}
+
+func TestBatchMessageIsLogged(t *testing.T) {
+	r := newRepo(t)
+	r.write("main.go", "package main\n")
+	r.git("add", "main.go")
+
+	for _, args := range [][]string{{"message", "--batch"}, {"message", "--batch", "--output-file", "-"}} {
+		stdout, stderr, code := r.run(nil, args...)
+		if code != 0 {
+			t.Fatalf("%v: exit code %d\n%s", args, code, stderr)
+		}
+		var message string
+		scanner := bufio.NewScanner(strings.NewReader(stdout))
+		for scanner.Scan() {
+			var event struct {
+				Msg     string `json:"msg"`
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				t.Fatalf("%v: stdout line is not JSON: %q", args, scanner.Text())
+			}
+			if event.Msg == "message" {
+				message = event.Message
+			}
+		}
+		if message != "feat: add main.go" {
+			t.Errorf("%v: logged message %q, want %q", args, message, "feat: add main.go")
+		}
+	}
+}
+
+func TestAliasUninstallKeepsOtherAliases(t *testing.T) {
+	r := newRepo(t)
+	gitconfig := filepath.Join(r.home, ".gitconfig")
+	env := []string{"GIT_CONFIG_GLOBAL=" + gitconfig}
+	r.git("config", "--file", gitconfig, "alias.ac", "commit -a")
+	r.git("config", "--file", gitconfig, "alias.gac", "!'/opt/my tools/auto-git' --review")
+
+	if _, stderr, code := r.run(env, "alias", "uninstall", "ac"); code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	if got := r.git("config", "--file", gitconfig, "alias.ac"); got != "commit -a" {
+		t.Errorf("alias.ac = %q, want it kept", got)
+	}
+	if _, stderr, code := r.run(env, "alias", "uninstall", "gac"); code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	if got := r.git("config", "--file", gitconfig, "--default", "", "alias.gac"); got != "" {
+		t.Errorf("alias.gac = %q, want it removed", got)
+	}
+}
+
+func TestServeCommitsLikeTheCommandLine(t *testing.T) {
+	r := newRepo(t)
+	r.write("main.go", "package main\n")
+	r.write("notes.md", "# Notes\n")
+	r.git("add", "-A")
+	r.git("config", "autogit.emoji", "always")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	cmd := exec.Command(binary, "--provider", "mock", "--non-interactive", "serve", "--addr", addr, "--include", "*.go")
+	cmd.Dir = r.dir
+	cmd.Env = r.env()
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	for start := time.Now(); ; time.Sleep(50 * time.Millisecond) {
+		if resp, err := http.Get("http://" + addr + "/health"); err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("server did not start")
+		}
+	}
+
+	resp, err := http.Post("http://"+addr+"/commit", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	if got, want := r.subject(), "✨ feat: add main.go"; got != want {
+		t.Errorf("subject = %q, want %q", got, want)
+	}
+	if files := r.git("show", "--name-only", "--format=", "HEAD"); files != "main.go" {
+		t.Errorf("committed files = %q, want only main.go", files)
+	}
+}
+
+func TestAuditLogRecordsMockGenerations(t *testing.T) {
+	r := newRepo(t)
+	r.write("main.go", "package main\n")
+	r.git("add", "main.go")
+	auditLog := filepath.Join(r.home, "audit.jsonl")
+	r.git("config", "autogit.auditlog", auditLog)
+
+	if _, stderr, code := r.run(nil, "message"); code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	data, err := os.ReadFile(auditLog)
+	if err != nil {
+		t.Fatalf("no audit log: %v", err)
+	}
+	var rec struct {
+		Provider string `json:"provider"`
+		Response string `json:"response"`
+	}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatalf("audit log is not one JSON record: %q", data)
+	}
+	if rec.Provider != "mock" || rec.Response != "feat: add main.go" {
+		t.Errorf("audit record = %+v", rec)
+	}
+}
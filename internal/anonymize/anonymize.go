@@ -0,0 +1,228 @@
+// Package anonymize replaces file paths and identifiers in prompts with
+// placeholders such as dir1/file2.go and name3, and puts the real names back
+// into the model's reply. It is meant for remote providers that must not
+// see even the names of a repository's files and functions.
+package anonymize
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// token is a word that may be an identifier or a path segment
+	token = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+	// pathPatterns find the paths in the structural lines of a prompt: diff
+	// headers, file section headings and the change manifest
+	pathPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^diff --git a/(\S+) b/(\S+)$`),
+		regexp.MustCompile(`(?m)^(?:---|\+\+\+) [ab]/(\S+)$`),
+		regexp.MustCompile(`(?m)^(?:rename|copy) (?:from|to) (\S+)$`),
+		regexp.MustCompile(`(?m)^### (\S+)`),
+		regexp.MustCompile(`"(?:old_)?path":"([^"]+)"`),
+	}
+
+	// scopeList is the line of the repository style guide naming the scopes
+	// used in the history
+	scopeList = regexp.MustCompile(`^(- Use a scope [^:]*: )(.+?)(\. .*)$`)
+	// packageLine names the package being committed in the context section
+	packageLine = regexp.MustCompile(`^Package: (\S+) \(([^,]+), (.+)/\)$`)
+)
+
+// Sections of the user prompt written from the repository rather than the
+// changes: the learned commit style and the context added by callers, such
+// as the package being committed or the subjects being squashed
+const (
+	styleHeading   = "=== REPOSITORY STYLE ==="
+	contextHeading = "=== CONTEXT ==="
+)
+
+// Mapping holds the placeholders chosen for one prompt
+type Mapping struct {
+	forward map[string]string
+	reverse map[string]string
+	// taken holds the words of the original text, which placeholders must
+	// not collide with
+	taken  map[string]bool
+	counts map[string]int
+}
+
+// Anonymize returns userPrompt with its paths and identifiers replaced, and
+// the mapping to restore them. Paths are recognized in diff headers, file
+// headings and the change manifest, and are replaced segment by segment
+// everywhere, keeping file extensions. In diff hunks and symbol lists,
+// identifiers written in camelCase, PascalCase with an inner capital, or
+// snake_case are replaced too; keywords and single words are kept, so a
+// name such as Billing or ledger still reaches the provider. The scopes of
+// the style section and the package named in the context section are
+// replaced whatever their form. systemPrompt is only read, so placeholders
+// don't collide with its words; it is built in and names nothing from the
+// repository.
+func Anonymize(systemPrompt, userPrompt string) (string, *Mapping) {
+	m := &Mapping{forward: map[string]string{}, reverse: map[string]string{}, taken: map[string]bool{}, counts: map[string]int{}}
+	for _, text := range []string{systemPrompt, userPrompt} {
+		for _, word := range token.FindAllString(text, -1) {
+			m.taken[word] = true
+		}
+	}
+
+	paths := map[string]string{}
+	for _, re := range pathPatterns {
+		for _, match := range re.FindAllStringSubmatch(userPrompt, -1) {
+			for _, p := range match[1:] {
+				if _, ok := paths[p]; !ok {
+					paths[p] = m.path(p)
+				}
+			}
+		}
+	}
+	if len(paths) > 0 {
+		// Longer paths first, so a path is not replaced inside a longer one
+		keys := make([]string, 0, len(paths))
+		for p := range paths {
+			keys = append(keys, p)
+		}
+		sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+		pairs := make([]string, 0, 2*len(keys))
+		for _, p := range keys {
+			pairs = append(pairs, p, paths[p])
+		}
+		userPrompt = strings.NewReplacer(pairs...).Replace(userPrompt)
+	}
+
+	lines := strings.Split(userPrompt, "\n")
+	inHunk, inSection := false, false
+	for i, line := range lines {
+		switch {
+		case line == styleHeading, line == contextHeading:
+			inSection = true
+			continue
+		case inSection && line == "":
+			inSection = false
+			continue
+		case inSection:
+			lines[i] = m.sectionLine(line)
+			continue
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case inHunk && line != "" && strings.ContainsRune("+- \\", rune(line[0])):
+		case strings.HasPrefix(line, "Symbols: "), strings.HasPrefix(line, "Changed in: "):
+			inHunk = false
+		default:
+			inHunk = false
+			continue
+		}
+		lines[i] = token.ReplaceAllStringFunc(line, m.identifier)
+	}
+	return strings.Join(lines, "\n"), m
+}
+
+// sectionLine anonymizes a line of the style or context section. Scopes and
+// package names are always replaced, being names from the repository even
+// when they are single words; other text only loses its compound
+// identifiers and the names already replaced in paths.
+func (m *Mapping) sectionLine(line string) string {
+	if match := scopeList.FindStringSubmatch(line); match != nil {
+		scopes := strings.Split(match[2], ", ")
+		for i, scope := range scopes {
+			scopes[i] = m.name(scope, "scope")
+		}
+		return match[1] + strings.Join(scopes, ", ") + match[3]
+	}
+	if match := packageLine.FindStringSubmatch(line); match != nil {
+		return fmt.Sprintf("Package: %s (%s, %s/)", m.name(match[1], "scope"), match[2], m.path(match[3]))
+	}
+	return token.ReplaceAllStringFunc(line, m.identifier)
+}
+
+// path maps each directory and the file name of p, keeping the extension
+func (m *Mapping) path(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." {
+			continue
+		}
+		if i < len(segments)-1 {
+			segments[i] = m.name(segment, "dir")
+			continue
+		}
+		ext := path.Ext(segment)
+		stem := strings.TrimSuffix(segment, ext)
+		if stem == "" {
+			// Dotfiles such as .gitignore say nothing about the project
+			continue
+		}
+		segments[i] = m.name(stem, "file") + ext
+	}
+	return strings.Join(segments, "/")
+}
+
+// identifier replaces word if it is a path segment or a compound identifier
+func (m *Mapping) identifier(word string) string {
+	if placeholder, ok := m.forward[word]; ok {
+		return placeholder
+	}
+	if !compound(word) {
+		return word
+	}
+	prefix := "name"
+	if unicode.IsUpper(rune(word[0])) {
+		prefix = "Name"
+	}
+	return m.name(word, prefix)
+}
+
+// name returns the placeholder for word, choosing the next free one with
+// prefix the first time
+func (m *Mapping) name(word, prefix string) string {
+	if placeholder, ok := m.forward[word]; ok {
+		return placeholder
+	}
+	for {
+		m.counts[prefix]++
+		placeholder := prefix + strconv.Itoa(m.counts[prefix])
+		if !m.taken[placeholder] {
+			m.forward[word] = placeholder
+			m.reverse[placeholder] = word
+			return placeholder
+		}
+	}
+}
+
+// compound reports whether word is an identifier made of several words,
+// e.g. readDiff, NewClient or user_id
+func compound(word string) bool {
+	if len(word) < 3 {
+		return false
+	}
+	runes := []rune(word)
+	for i := 1; i < len(runes); i++ {
+		prev, r := runes[i-1], runes[i]
+		if unicode.IsLower(prev) && unicode.IsUpper(r) {
+			return true
+		}
+		if r == '_' && unicode.IsLetter(prev) && i+1 < len(runes) && unicode.IsLetter(runes[i+1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Restore puts the real names back in place of the placeholders in text
+func (m *Mapping) Restore(text string) string {
+	if len(m.reverse) == 0 {
+		return text
+	}
+	return token.ReplaceAllStringFunc(text, func(word string) string {
+		if original, ok := m.reverse[word]; ok {
+			return original
+		}
+		return word
+	})
+}
@@ -0,0 +1,49 @@
+package anonymize
+
+import (
+	"auto-git/internal/git"
+	"auto-git/internal/provider"
+)
+
+// Wrap returns a provider that anonymizes every user prompt before prov
+// sees it and restores the names in the replies
+func Wrap(prov provider.Provider) provider.Provider {
+	return &anonymizedProvider{Provider: prov}
+}
+
+type anonymizedProvider struct {
+	provider.Provider
+}
+
+func (p *anonymizedProvider) Unwrap() provider.Provider {
+	return p.Provider
+}
+
+func (p *anonymizedProvider) Generate(model string, systemPrompt, userPrompt string) (*provider.Completion, error) {
+	userPrompt, m := Anonymize(systemPrompt, userPrompt)
+	completion, err := p.Provider.Generate(model, systemPrompt, userPrompt)
+	if completion != nil {
+		completion.Content = m.Restore(completion.Content)
+	}
+	return completion, err
+}
+
+// GenerateChanges anonymizes the user prompt as Generate does. The changes
+// are passed as they are: only providers that read them in process, such as
+// the mock provider, implement ChangesGenerator.
+func (p *anonymizedProvider) GenerateChanges(changes *git.Changes, model string, systemPrompt, userPrompt string) (*provider.Completion, error) {
+	userPrompt, m := Anonymize(systemPrompt, userPrompt)
+	completion, err := provider.GenerateChanges(p.Provider, changes, model, systemPrompt, userPrompt)
+	if completion != nil {
+		completion.Content = m.Restore(completion.Content)
+	}
+	return completion, err
+}
+
+func (p *anonymizedProvider) GenerateCommitMessage(model string, systemPrompt, userPrompt string) (string, error) {
+	completion, err := p.Generate(model, systemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	return completion.Content, nil
+}
@@ -0,0 +1,148 @@
+// Package audit records every prompt sent to a provider and the raw reply, so
+// teams can review what code left the machine and where it went.
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"auto-git/internal/git"
+	"auto-git/internal/logging"
+	"auto-git/internal/provider"
+)
+
+const (
+	// DefaultMaxSizeMB is the size at which the audit log is rotated
+	DefaultMaxSizeMB = 10
+	// DefaultBackups is the number of rotated files kept
+	DefaultBackups = 3
+)
+
+// Record is one line of the audit log
+type Record struct {
+	Time         time.Time      `json:"time"`
+	Provider     string         `json:"provider"`
+	Endpoint     string         `json:"endpoint,omitempty"`
+	Model        string         `json:"model"`
+	SystemPrompt string         `json:"system_prompt"`
+	UserPrompt   string         `json:"user_prompt"`
+	Response     string         `json:"response,omitempty"`
+	Usage        provider.Usage `json:"usage"`
+	DurationMS   int64          `json:"duration_ms"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// Logger appends redacted records to a rotating JSON Lines file
+type Logger struct {
+	out     *rotatingFile
+	secrets []string
+}
+
+// NewLogger writes to path, rotating at maxSizeMB and keeping DefaultBackups
+// old files. Occurrences of any of secrets (such as the provider's API key) are redacted
+// in addition to the patterns known to logging.RedactSecrets.
+func NewLogger(path string, maxSizeMB int, secrets ...string) *Logger {
+	if maxSizeMB <= 0 {
+		maxSizeMB = DefaultMaxSizeMB
+	}
+
+	var known []string
+	for _, s := range secrets {
+		if s = strings.TrimSpace(s); s != "" {
+			known = append(known, s)
+		}
+	}
+
+	return &Logger{
+		out:     &rotatingFile{path: path, maxBytes: int64(maxSizeMB) << 20, backups: DefaultBackups},
+		secrets: known,
+	}
+}
+
+// Log redacts and appends rec. Failures are reported through the debug log
+// rather than interrupting the commit.
+func (l *Logger) Log(rec Record) {
+	rec.SystemPrompt = l.redact(rec.SystemPrompt)
+	rec.UserPrompt = l.redact(rec.UserPrompt)
+	rec.Response = l.redact(rec.Response)
+	rec.Error = l.redact(rec.Error)
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		logging.Warn("failed to encode audit record", "error", err)
+		return
+	}
+	if _, err := l.out.Write(append(line, '\n')); err != nil {
+		logging.Warn("failed to write audit record", "error", err)
+	}
+}
+
+func (l *Logger) redact(text string) string {
+	for _, s := range l.secrets {
+		text = strings.ReplaceAll(text, s, logging.Redacted)
+	}
+	return logging.RedactSecrets(text)
+}
+
+// Wrap returns a provider that records every generation made through prov
+func Wrap(prov provider.Provider, name, endpoint string, l *Logger) provider.Provider {
+	return &auditedProvider{Provider: prov, name: name, endpoint: endpoint, log: l}
+}
+
+type auditedProvider struct {
+	provider.Provider
+	name     string
+	endpoint string
+	log      *Logger
+}
+
+func (p *auditedProvider) Unwrap() provider.Provider {
+	return p.Provider
+}
+
+func (p *auditedProvider) Generate(model string, systemPrompt, userPrompt string) (*provider.Completion, error) {
+	return p.record(model, systemPrompt, userPrompt, func() (*provider.Completion, error) {
+		return p.Provider.Generate(model, systemPrompt, userPrompt)
+	})
+}
+
+func (p *auditedProvider) GenerateChanges(changes *git.Changes, model string, systemPrompt, userPrompt string) (*provider.Completion, error) {
+	return p.record(model, systemPrompt, userPrompt, func() (*provider.Completion, error) {
+		return provider.GenerateChanges(p.Provider, changes, model, systemPrompt, userPrompt)
+	})
+}
+
+// record runs generate and logs the generation
+func (p *auditedProvider) record(model string, systemPrompt, userPrompt string, generate func() (*provider.Completion, error)) (*provider.Completion, error) {
+	start := time.Now()
+	completion, err := generate()
+
+	rec := Record{
+		Time:         start,
+		Provider:     p.name,
+		Endpoint:     p.endpoint,
+		Model:        model,
+		SystemPrompt: systemPrompt,
+		UserPrompt:   userPrompt,
+		DurationMS:   time.Since(start).Milliseconds(),
+	}
+	if completion != nil {
+		rec.Response = completion.Content
+		rec.Usage = completion.Usage
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	p.log.Log(rec)
+
+	return completion, err
+}
+
+func (p *auditedProvider) GenerateCommitMessage(model string, systemPrompt, userPrompt string) (string, error) {
+	completion, err := p.Generate(model, systemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	return completion.Content, nil
+}
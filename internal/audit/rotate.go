@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// rotatingFile appends to a file, renaming it to path.1, path.2, … once it
+// grows past maxBytes and deleting the oldest beyond the backup count
+type rotatingFile struct {
+	path     string
+	maxBytes int64
+	backups  int
+	mu       sync.Mutex
+}
+
+// Write appends p, rotating first if p would push the file past maxBytes. The
+// file is opened per write since audit records are infrequent.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	if info, err := os.Stat(r.path); err == nil && info.Size() > 0 && info.Size()+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	return f.Write(p)
+}
+
+func (r *rotatingFile) rotate() error {
+	if r.backups < 1 {
+		return os.Remove(r.path)
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.backups))
+	for i := r.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	return nil
+}
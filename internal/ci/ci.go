@@ -0,0 +1,58 @@
+// Package ci detects whether auto-git runs inside a CI pipeline or bot.
+package ci
+
+import (
+	"os"
+	"strings"
+)
+
+// EnvOverride forces detection on ("1", "true") or off ("0", "false")
+const EnvOverride = "AUTO_GIT_CI"
+
+// providers maps environment variables set by CI systems to their names, in
+// the order they are checked
+var providers = []struct {
+	env  string
+	name string
+}{
+	{"GITHUB_ACTIONS", "GitHub Actions"},
+	{"GITLAB_CI", "GitLab CI"},
+	{"BUILDKITE", "Buildkite"},
+	{"CIRCLECI", "CircleCI"},
+	{"TRAVIS", "Travis CI"},
+	{"JENKINS_URL", "Jenkins"},
+	{"TF_BUILD", "Azure Pipelines"},
+	{"BITBUCKET_BUILD_NUMBER", "Bitbucket Pipelines"},
+	{"TEAMCITY_VERSION", "TeamCity"},
+	{"DRONE", "Drone"},
+	{"CI", "CI"},
+}
+
+// Detect returns the name of the CI system auto-git runs in, if any
+func Detect() (string, bool) {
+	if v, ok := os.LookupEnv(EnvOverride); ok {
+		if !truthy(v) {
+			return "", false
+		}
+		if name, ok := detect(); ok {
+			return name, true
+		}
+		return "CI", true
+	}
+	return detect()
+}
+
+func detect() (string, bool) {
+	for _, p := range providers {
+		if truthy(os.Getenv(p.env)) {
+			return p.name, true
+		}
+	}
+	return "", false
+}
+
+// truthy treats any value except empty, "0" and "false" as set
+func truthy(v string) bool {
+	v = strings.TrimSpace(strings.ToLower(v))
+	return v != "" && v != "0" && v != "false"
+}
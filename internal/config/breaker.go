@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	BreakerFile = "breaker.yaml"
+	// DefaultBreakerThreshold is how many failures in a row open the breaker
+	DefaultBreakerThreshold = 3
+	// DefaultBreakerCooldown is how long an open breaker skips the provider
+	DefaultBreakerCooldown = 5 * time.Minute
+)
+
+// BreakerEntry counts the failures in a row of one provider
+type BreakerEntry struct {
+	Failures    int       `yaml:"failures"`
+	LastFailure time.Time `yaml:"last_failure"`
+}
+
+// BreakerState maps provider names to their recent failures
+type BreakerState map[string]BreakerEntry
+
+func getBreakerPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, BreakerFile), nil
+}
+
+// LoadBreakerState reads the breaker state, returning an empty state if none
+// exists
+func LoadBreakerState() (BreakerState, error) {
+	path, err := getBreakerPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return BreakerState{}, nil
+		}
+		return nil, fmt.Errorf("failed to read breaker state: %w", err)
+	}
+
+	state := BreakerState{}
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse breaker state: %w", err)
+	}
+	return state, nil
+}
+
+func saveBreakerState(state BreakerState) error {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	path, err := getBreakerPath()
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal breaker state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write breaker state: %w", err)
+	}
+	return nil
+}
+
+// RecordProviderFailure counts another failure in a row of providerName
+func RecordProviderFailure(providerName string) error {
+	state, err := LoadBreakerState()
+	if err != nil {
+		// A corrupt state is simply rebuilt
+		state = BreakerState{}
+	}
+	entry := state[providerName]
+	entry.Failures++
+	entry.LastFailure = time.Now()
+	state[providerName] = entry
+	return saveBreakerState(state)
+}
+
+// RecordProviderSuccess closes the breaker of providerName. The state file is
+// only written when there were failures to forget.
+func RecordProviderSuccess(providerName string) error {
+	state, err := LoadBreakerState()
+	if err != nil {
+		state = BreakerState{}
+	} else if _, ok := state[providerName]; !ok {
+		return nil
+	}
+	delete(state, providerName)
+	return saveBreakerState(state)
+}
+
+// BreakerOpen reports whether providerName failed at least threshold times in
+// a row, the last time within cooldown, and how many times it failed
+func BreakerOpen(providerName string, threshold int, cooldown time.Duration) (BreakerEntry, bool) {
+	state, err := LoadBreakerState()
+	if err != nil {
+		return BreakerEntry{}, false
+	}
+	entry := state[providerName]
+	if threshold <= 0 || cooldown <= 0 || entry.Failures < threshold {
+		return entry, false
+	}
+	return entry, time.Since(entry.LastFailure) < cooldown
+}
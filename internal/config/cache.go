@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ModelCacheFile       = "models-cache.yaml"
+	DefaultModelCacheTTL = time.Hour
+)
+
+// ModelCacheEntry holds the model names last reported by a provider
+type ModelCacheEntry struct {
+	Models    []string  `yaml:"models"`
+	UpdatedAt time.Time `yaml:"updated_at"`
+}
+
+// ModelCache maps provider names to their cached model lists
+type ModelCache map[string]ModelCacheEntry
+
+func getModelCachePath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, ModelCacheFile), nil
+}
+
+// LoadModelCache reads the model cache, returning an empty cache if none exists
+func LoadModelCache() (ModelCache, error) {
+	cachePath, err := getModelCachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ModelCache{}, nil
+		}
+		return nil, fmt.Errorf("failed to read model cache: %w", err)
+	}
+
+	cache := ModelCache{}
+	if err := yaml.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("failed to parse model cache: %w", err)
+	}
+	return cache, nil
+}
+
+// CacheModels records the model names available from providerName
+func CacheModels(providerName string, models []string) error {
+	cache, err := LoadModelCache()
+	if err != nil {
+		// A corrupt cache is simply rebuilt
+		cache = ModelCache{}
+	}
+
+	cache[providerName] = ModelCacheEntry{
+		Models:    models,
+		UpdatedAt: time.Now(),
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	cachePath, err := getModelCachePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("failed to marshal model cache: %w", err)
+	}
+
+	if err := os.WriteFile(cachePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write model cache: %w", err)
+	}
+	return nil
+}
+
+// CachedModels returns the cached model names for providerName
+func CachedModels(providerName string) []string {
+	cache, err := LoadModelCache()
+	if err != nil {
+		return nil
+	}
+	return cache[providerName].Models
+}
+
+// FreshCachedModels returns the cached model names for providerName if they
+// were recorded within ttl
+func FreshCachedModels(providerName string, ttl time.Duration) ([]string, bool) {
+	cache, err := LoadModelCache()
+	if err != nil {
+		return nil, false
+	}
+	entry, ok := cache[providerName]
+	if !ok || time.Since(entry.UpdatedAt) > ttl {
+		return nil, false
+	}
+	return entry.Models, true
+}
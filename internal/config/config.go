@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,12 +15,270 @@ const (
 	DefaultProvider = "siliconflow"
 	ConfigDir       = ".config/auto-git"
 	ConfigFile      = "config.yaml"
+	PluginDir       = "plugins"
+
+	// PrivacyLocalOnly refuses providers whose endpoint is not on this machine
+	PrivacyLocalOnly = "local_only"
+
+	// What to do when commit hooks change the committed files, see HookFixes
+	HookFixesRestage    = "restage"
+	HookFixesAnnotate   = "annotate"
+	HookFixesRegenerate = "regenerate"
+	HookFixesOff        = "off"
 )
 
 type Config struct {
 	Provider string `yaml:"provider"`
 	Endpoint string `yaml:"endpoint"`
 	Model    string `yaml:"model"`
+	// LogFile enables debug logging to a file; relative paths are resolved
+	// against the config directory
+	LogFile string      `yaml:"log_file,omitempty"`
+	Theme   ThemeConfig `yaml:"theme,omitempty"`
+	// Keys binds TUI actions to other keys, e.g. quit: "ctrl+q esc"; each
+	// value lists the keys of an action separated by spaces
+	Keys map[string]string `yaml:"keys,omitempty"`
+	// Review shows the diff and generated message for confirmation before committing
+	Review bool `yaml:"review,omitempty"`
+	// UseEditor edits messages in $GIT_EDITOR/$EDITOR instead of the built-in editor
+	UseEditor bool `yaml:"use_editor,omitempty"`
+	// Fast skips the connection check and model validation before generating;
+	// both only run if generation fails
+	Fast bool `yaml:"fast,omitempty"`
+	// ModelCacheTTL is how long a fetched model list is trusted, e.g. "30m"
+	ModelCacheTTL string `yaml:"model_cache_ttl,omitempty"`
+	// NoFallback exits when the provider is unreachable instead of using a
+	// rule-based message
+	NoFallback bool `yaml:"no_fallback,omitempty"`
+	// Signoff adds a Signed-off-by trailer to every commit (git commit -s)
+	Signoff bool `yaml:"signoff,omitempty"`
+	// AutoStash stashes unstaged changes during --staged runs and restores
+	// them afterwards
+	AutoStash bool `yaml:"auto_stash,omitempty"`
+	// Author and Date are the defaults for --author and --date
+	Author string `yaml:"author,omitempty"`
+	Date   string `yaml:"date,omitempty"`
+	// NoPush keeps commits local instead of pushing them
+	NoPush bool `yaml:"no_push,omitempty"`
+	// VerifyCommand runs after committing and before pushing, e.g. "go test ./...";
+	// if it fails the commit is kept local
+	VerifyCommand string `yaml:"verify_command,omitempty"`
+	// NoStyle stops adapting the prompt to the style of the repository's history
+	NoStyle bool `yaml:"no_style,omitempty"`
+	// FunctionContext shows the model the whole function around each change
+	// (git diff --function-context) instead of three lines of context
+	FunctionContext bool `yaml:"function_context,omitempty"`
+	// AuditLog records every prompt and model reply (secrets redacted) to this
+	// file; relative paths are resolved against the config directory
+	AuditLog string `yaml:"audit_log,omitempty"`
+	// AuditLogMaxSizeMB is the size at which the audit log is rotated
+	AuditLogMaxSizeMB int `yaml:"audit_log_max_size_mb,omitempty"`
+	// Redact masks data in the diff before it is sent to the provider
+	Redact RedactConfig `yaml:"redact,omitempty"`
+	// Privacy restricts where diffs may be sent; see PrivacyLocalOnly
+	Privacy string `yaml:"privacy,omitempty"`
+	// Anonymize replaces file paths and identifiers in prompts sent to a
+	// remote provider with placeholders, and restores them in the reply
+	Anonymize bool `yaml:"anonymize,omitempty"`
+	// SizeGuard asks before large diffs are sent to a remote provider
+	SizeGuard SizeGuardConfig `yaml:"size_guard,omitempty"`
+	// ContextWindow is the model's context size in tokens, for models auto-git
+	// doesn't know; prompts that don't fit have their diff shortened
+	ContextWindow int `yaml:"context_window,omitempty"`
+	// UILanguage is the language of auto-git's own messages, e.g. "ja" or
+	// "zh-CN"; empty follows the locale. Commit messages are not affected.
+	UILanguage string `yaml:"ui_language,omitempty"`
+	// Plain uses sequential prompts and plain text output without spinners,
+	// colors or full-screen TUIs, for screen readers and terminal logging
+	Plain bool `yaml:"plain,omitempty"`
+	// PerPackage offers one commit per monorepo package the changes touch
+	PerPackage bool `yaml:"per_package,omitempty"`
+	// PackageRoots are globs of package directories, e.g. "services/*", in
+	// addition to the detected Go modules and npm workspaces
+	PackageRoots []string `yaml:"package_roots,omitempty"`
+	// StrictConventional rejects and regenerates messages that don't follow
+	// Conventional Commits 1.0.0, instead of normalizing them
+	StrictConventional bool `yaml:"strict_conventional,omitempty"`
+	// Templates render messages per commit type from the parts the model
+	// returns, e.g. fix: "fix({{scope}}): {{subject}} (closes #{{issue}})"
+	Templates map[string]string `yaml:"templates,omitempty"`
+	// Emoji is "auto" (keep the model's choice), "always" or "never"
+	Emoji string `yaml:"emoji,omitempty"`
+	// EmojiPosition is "before_type" or "after_colon"; empty keeps the
+	// emoji where the model put it
+	EmojiPosition string `yaml:"emoji_position,omitempty"`
+	// EmojiMap sets the emoji of commit types, e.g. perf: "🚀", replacing
+	// whatever the model chose
+	EmojiMap map[string]string `yaml:"emoji_map,omitempty"`
+	// BannedWords are words and phrases, such as "WIP", that generated
+	// subjects must not contain
+	BannedWords []string `yaml:"banned_words,omitempty"`
+	// BannedWordsAction is "regenerate" (the default) or "block"
+	BannedWordsAction string `yaml:"banned_words_action,omitempty"`
+	// NoSpellCheck skips looking for typos in generated subjects
+	NoSpellCheck bool `yaml:"no_spell_check,omitempty"`
+	// ConfirmTimeout accepts the message on the review screen after this long
+	// without input, e.g. "15s"
+	ConfirmTimeout string `yaml:"confirm_timeout,omitempty"`
+	// KeyRotation is how several comma-separated API keys are used:
+	// "on_error" (the default) or "round_robin"
+	KeyRotation string `yaml:"key_rotation,omitempty"`
+	// OpenAIOrganization and OpenAIProject are sent as the OpenAI-Organization
+	// and OpenAI-Project headers, overriding OPENAI_ORG_ID and OPENAI_PROJECT_ID
+	OpenAIOrganization string `yaml:"openai_organization,omitempty"`
+	OpenAIProject      string `yaml:"openai_project,omitempty"`
+	// ModelFilter is a regular expression; only matching models are offered
+	// for selection, e.g. "(?i)qwen|deepseek"
+	ModelFilter string `yaml:"model_filter,omitempty"`
+	// Headers are added to every request of a provider, keyed by provider
+	// name, e.g. openai: {X-Tenant-ID: acme}; "${VAR}" reads the environment
+	Headers map[string]map[string]string `yaml:"headers,omitempty"`
+	// BreakerThreshold is how many connection failures in a row make later
+	// runs skip the provider; 0 uses DefaultBreakerThreshold
+	BreakerThreshold int `yaml:"breaker_threshold,omitempty"`
+	// BreakerCooldown is how long the provider is skipped, e.g. "10m"; "0"
+	// always tries the provider
+	BreakerCooldown string `yaml:"breaker_cooldown,omitempty"`
+	// ReasoningEffort is "minimal", "low", "medium" or "high" for reasoning
+	// models; empty leaves it to the model
+	ReasoningEffort string `yaml:"reasoning_effort,omitempty"`
+	// ThinkingBudget caps the thinking tokens of models that take a budget,
+	// such as Claude and Qwen3
+	ThinkingBudget int `yaml:"thinking_budget,omitempty"`
+	// PromptCache is "openai" or "anthropic" to ask OpenAI-compatible
+	// endpoints to cache the system prompt
+	PromptCache string `yaml:"prompt_cache,omitempty"`
+	// ModelRules pick the provider and model per run from the size and paths
+	// of the changes; the first matching rule wins
+	ModelRules []ModelRule `yaml:"model_rules,omitempty"`
+	// LargeContextModel is used when the provider rejects a prompt as too
+	// long for the model; without it the diff is shortened further
+	LargeContextModel string `yaml:"large_context_model,omitempty"`
+	// DiffCompression shrinks the diff in the prompt: "off" (the default),
+	// "light" or "aggressive"
+	DiffCompression string `yaml:"diff_compression,omitempty"`
+	// HookFixes is what happens when commit hooks such as formatters change
+	// files: "restage" (the default) commits their fixes, "annotate" also
+	// mentions them in the message, "regenerate" describes the final commit
+	// anew and "off" leaves the fixes to the user
+	HookFixes string `yaml:"hook_fixes,omitempty"`
+	// DeepenShallow fetches more history into shallow clones when style
+	// learning or --against need it, instead of making do with what is there
+	DeepenShallow bool `yaml:"deepen_shallow,omitempty"`
+}
+
+// GetModelCacheTTL parses ModelCacheTTL, falling back to DefaultModelCacheTTL
+func (c *Config) GetModelCacheTTL() time.Duration {
+	if c.ModelCacheTTL == "" {
+		return DefaultModelCacheTTL
+	}
+	ttl, err := time.ParseDuration(c.ModelCacheTTL)
+	if err != nil {
+		return DefaultModelCacheTTL
+	}
+	return ttl
+}
+
+// GetBreakerThreshold returns BreakerThreshold or its default
+func (c *Config) GetBreakerThreshold() int {
+	if c.BreakerThreshold <= 0 {
+		return DefaultBreakerThreshold
+	}
+	return c.BreakerThreshold
+}
+
+// GetBreakerCooldown parses BreakerCooldown; 0 disables the breaker
+func (c *Config) GetBreakerCooldown() time.Duration {
+	if c.BreakerCooldown == "" {
+		return DefaultBreakerCooldown
+	}
+	cooldown, err := time.ParseDuration(c.BreakerCooldown)
+	if err != nil || cooldown < 0 {
+		return DefaultBreakerCooldown
+	}
+	return cooldown
+}
+
+// GetHookFixes returns HookFixes, HookFixesRestage when it is empty
+func (c *Config) GetHookFixes() (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(c.HookFixes)); mode {
+	case "":
+		return HookFixesRestage, nil
+	case HookFixesRestage, HookFixesAnnotate, HookFixesRegenerate, HookFixesOff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown hook_fixes %q (supported: %s, %s, %s, %s)", c.HookFixes, HookFixesRestage, HookFixesAnnotate, HookFixesRegenerate, HookFixesOff)
+	}
+}
+
+// GetConfirmTimeout parses ConfirmTimeout; 0, the default, waits for the user
+func (c *Config) GetConfirmTimeout() time.Duration {
+	if c.ConfirmTimeout == "" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(c.ConfirmTimeout)
+	if err != nil || timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
+// ThemeConfig selects a built-in TUI theme and optionally overrides its colors
+// with ANSI codes ("170") or hex values ("#ff87d7")
+type ThemeConfig struct {
+	Name     string `yaml:"name,omitempty"`
+	Selected string `yaml:"selected,omitempty"`
+	Title    string `yaml:"title,omitempty"`
+	Spinner  string `yaml:"spinner,omitempty"`
+}
+
+// DefaultMaxPromptTokens is the size guard limit when none is configured
+const DefaultMaxPromptTokens = 20000
+
+// ModelRule selects a model for changes meeting all of its conditions. A rule
+// without conditions matches any changes.
+type ModelRule struct {
+	// MaxLines and MinLines bound the number of added and deleted lines
+	MaxLines int `yaml:"max_lines,omitempty"`
+	MinLines int `yaml:"min_lines,omitempty"`
+	// MaxFiles bounds the number of changed files
+	MaxFiles int `yaml:"max_files,omitempty"`
+	// Paths match when any changed file matches one of the globs, e.g.
+	// "migrations/**"
+	Paths []string `yaml:"paths,omitempty"`
+
+	// Provider and Endpoint default to the configured ones; the endpoint is
+	// dropped when the rule switches provider
+	Provider string `yaml:"provider,omitempty"`
+	Endpoint string `yaml:"endpoint,omitempty"`
+	Model    string `yaml:"model"`
+}
+
+// SizeGuardConfig sets the diff size above which auto-git asks for confirmation.
+// A zero limit is not checked; with no limits set DefaultMaxPromptTokens applies.
+type SizeGuardConfig struct {
+	Disabled  bool `yaml:"disabled,omitempty"`
+	MaxLines  int  `yaml:"max_lines,omitempty"`
+	MaxBytes  int  `yaml:"max_bytes,omitempty"`
+	MaxTokens int  `yaml:"max_tokens,omitempty"`
+	// CostPer1KTokens is the provider's input price, used to estimate the cost
+	CostPer1KTokens float64 `yaml:"cost_per_1k_tokens,omitempty"`
+}
+
+// Limits returns the line, byte, and token limits with the default applied
+func (g SizeGuardConfig) Limits() (lines, bytes, tokens int) {
+	if g.MaxLines == 0 && g.MaxBytes == 0 && g.MaxTokens == 0 {
+		return 0, 0, DefaultMaxPromptTokens
+	}
+	return g.MaxLines, g.MaxBytes, g.MaxTokens
+}
+
+// RedactConfig selects what is removed from prompts; commits are unaffected
+type RedactConfig struct {
+	Emails    bool     `yaml:"emails,omitempty"`
+	IPs       bool     `yaml:"ips,omitempty"`
+	EnvValues bool     `yaml:"env_values,omitempty"`
+	Patterns  []string `yaml:"patterns,omitempty"`
 }
 
 func GetConfigPath() (string, error) {
@@ -37,6 +297,38 @@ func GetConfigDir() (string, error) {
 	return filepath.Join(homeDir, ConfigDir), nil
 }
 
+// ResolveLogFile returns the absolute path of the configured log file, or ""
+// when file logging is disabled
+func (c *Config) ResolveLogFile() (string, error) {
+	return resolveInConfigDir(c.LogFile)
+}
+
+// ResolveAuditLog returns the absolute path of the audit log, or "" when
+// auditing is disabled
+func (c *Config) ResolveAuditLog() (string, error) {
+	return resolveInConfigDir(c.AuditLog)
+}
+
+func resolveInConfigDir(path string) (string, error) {
+	if path == "" || filepath.IsAbs(path) {
+		return path, nil
+	}
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, path), nil
+}
+
+// GetPluginDir returns the directory searched for provider plugins
+func GetPluginDir() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, PluginDir), nil
+}
+
 func LoadConfig() (*Config, error) {
 	configPath, err := GetConfigPath()
 	if err != nil {
@@ -127,4 +419,3 @@ func SetEndpoint(endpoint string) error {
 	config.Endpoint = endpoint
 	return SaveConfig(config)
 }
-
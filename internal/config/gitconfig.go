@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GitConfigSection is the git config section read by ApplyGitConfig, e.g.
+// git config autogit.provider ollama
+const GitConfigSection = "autogit"
+
+// gitConfigSetters apply one git config value. Keys are YAML names without
+// underscores, since git config names may not contain them.
+var gitConfigSetters = map[string]func(c *Config, value string) error{
+	"provider":           func(c *Config, v string) error { c.Provider = strings.ToLower(v); return nil },
+	"endpoint":           func(c *Config, v string) error { c.Endpoint = v; return nil },
+	"model":              func(c *Config, v string) error { c.Model = v; return nil },
+	"logfile":            func(c *Config, v string) error { c.LogFile = v; return nil },
+	"theme":              func(c *Config, v string) error { c.Theme.Name = v; return nil },
+	"keys":               setKeys,
+	"review":             boolSetter(func(c *Config, b bool) { c.Review = b }),
+	"useeditor":          boolSetter(func(c *Config, b bool) { c.UseEditor = b }),
+	"fast":               boolSetter(func(c *Config, b bool) { c.Fast = b }),
+	"modelcachettl":      func(c *Config, v string) error { c.ModelCacheTTL = v; return nil },
+	"nofallback":         boolSetter(func(c *Config, b bool) { c.NoFallback = b }),
+	"signoff":            boolSetter(func(c *Config, b bool) { c.Signoff = b }),
+	"autostash":          boolSetter(func(c *Config, b bool) { c.AutoStash = b }),
+	"author":             func(c *Config, v string) error { c.Author = v; return nil },
+	"date":               func(c *Config, v string) error { c.Date = v; return nil },
+	"verifycommand":      func(c *Config, v string) error { c.VerifyCommand = v; return nil },
+	"nostyle":            boolSetter(func(c *Config, b bool) { c.NoStyle = b }),
+	"functioncontext":    boolSetter(func(c *Config, b bool) { c.FunctionContext = b }),
+	"auditlog":           func(c *Config, v string) error { c.AuditLog = v; return nil },
+	"auditlogmaxsizemb":  intSetter(func(c *Config, n int) { c.AuditLogMaxSizeMB = n }),
+	"privacy":            func(c *Config, v string) error { c.Privacy = v; return nil },
+	"anonymize":          boolSetter(func(c *Config, b bool) { c.Anonymize = b }),
+	"contextwindow":      intSetter(func(c *Config, n int) { c.ContextWindow = n }),
+	"push":               boolSetter(func(c *Config, b bool) { c.NoPush = !b }),
+	"nopush":             boolSetter(func(c *Config, b bool) { c.NoPush = b }),
+	"uilanguage":         func(c *Config, v string) error { c.UILanguage = v; return nil },
+	"plain":              boolSetter(func(c *Config, b bool) { c.Plain = b }),
+	"perpackage":         boolSetter(func(c *Config, b bool) { c.PerPackage = b }),
+	"packageroots":       func(c *Config, v string) error { c.PackageRoots = splitList(v); return nil },
+	"strictconventional": boolSetter(func(c *Config, b bool) { c.StrictConventional = b }),
+	"emoji":              func(c *Config, v string) error { c.Emoji = v; return nil },
+	"emojiposition":      func(c *Config, v string) error { c.EmojiPosition = v; return nil },
+	"emojimap":           setEmojiMap,
+	"bannedwords":        func(c *Config, v string) error { c.BannedWords = splitList(v); return nil },
+	"bannedwordsaction":  func(c *Config, v string) error { c.BannedWordsAction = v; return nil },
+	"nospellcheck":       boolSetter(func(c *Config, b bool) { c.NoSpellCheck = b }),
+	"confirmtimeout":     func(c *Config, v string) error { c.ConfirmTimeout = v; return nil },
+	"keyrotation":        func(c *Config, v string) error { c.KeyRotation = v; return nil },
+	"openaiorganization": func(c *Config, v string) error { c.OpenAIOrganization = v; return nil },
+	"openaiproject":      func(c *Config, v string) error { c.OpenAIProject = v; return nil },
+	"modelfilter":        func(c *Config, v string) error { c.ModelFilter = v; return nil },
+	"breakerthreshold":   intSetter(func(c *Config, n int) { c.BreakerThreshold = n }),
+	"breakercooldown":    func(c *Config, v string) error { c.BreakerCooldown = v; return nil },
+	"reasoningeffort":    func(c *Config, v string) error { c.ReasoningEffort = v; return nil },
+	"thinkingbudget":     intSetter(func(c *Config, n int) { c.ThinkingBudget = n }),
+	"promptcache":        func(c *Config, v string) error { c.PromptCache = v; return nil },
+	"largecontextmodel":  func(c *Config, v string) error { c.LargeContextModel = v; return nil },
+	"diffcompression":    func(c *Config, v string) error { c.DiffCompression = v; return nil },
+	"hookfixes":          func(c *Config, v string) error { c.HookFixes = v; return nil },
+	"deepenshallow":      boolSetter(func(c *Config, b bool) { c.DeepenShallow = b }),
+}
+
+// ApplyGitConfig overrides settings with values read from the autogit git
+// config section, as returned by git.ConfigSection. Names are matched
+// case-insensitively and may use dashes, so autogit.verifyCommand and
+// autogit.verify-command both set verify_command. It returns the keys it
+// does not know.
+func (c *Config) ApplyGitConfig(values map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	provider := c.Provider
+	var unknown []string
+	for _, key := range keys {
+		set, ok := gitConfigSetters[strings.ReplaceAll(strings.ToLower(key), "-", "")]
+		if !ok {
+			unknown = append(unknown, GitConfigSection+"."+key)
+			continue
+		}
+		if err := set(c, strings.TrimSpace(values[key])); err != nil {
+			return unknown, fmt.Errorf("invalid git config %s.%s: %w", GitConfigSection, key, err)
+		}
+	}
+
+	// The saved endpoint belongs to the saved provider
+	if _, ok := values["endpoint"]; !ok && c.Provider != provider {
+		c.Endpoint = ""
+	}
+	return unknown, nil
+}
+
+func boolSetter(set func(c *Config, b bool)) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		b, err := parseGitBool(value)
+		if err != nil {
+			return err
+		}
+		set(c, b)
+		return nil
+	}
+}
+
+func intSetter(set func(c *Config, n int)) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		set(c, n)
+		return nil
+	}
+}
+
+// parseGitBool accepts the boolean spellings git config does; a key without
+// a value is true
+func parseGitBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not a boolean", value)
+}
+
+// setEmojiMap reads "type=emoji" pairs, e.g. "perf=🚀,del=🔥"
+func setEmojiMap(c *Config, value string) error {
+	mapping := map[string]string{}
+	for _, item := range splitList(value) {
+		typ, e, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("%q is not type=emoji", item)
+		}
+		mapping[strings.TrimSpace(typ)] = strings.TrimSpace(e)
+	}
+	c.EmojiMap = mapping
+	return nil
+}
+
+// setKeys reads key bindings written as action=keys pairs, e.g.
+// "quit=ctrl+q esc,up=up w"
+func setKeys(c *Config, value string) error {
+	bindings := map[string]string{}
+	for _, item := range splitList(value) {
+		action, keys, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("%q is not action=keys", item)
+		}
+		bindings[strings.TrimSpace(action)] = strings.TrimSpace(keys)
+	}
+	c.Keys = bindings
+	return nil
+}
+
+// splitList splits a comma-separated git config value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
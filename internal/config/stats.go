@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const ModelStatsFile = "model-stats.yaml"
+
+// ModelStatsEntry counts how often a model took part in a --compare run and
+// how often its message was picked
+type ModelStatsEntry struct {
+	Compared int `yaml:"compared"`
+	Wins     int `yaml:"wins"`
+}
+
+// ModelStats maps "provider/model" keys to their comparison results
+type ModelStats map[string]ModelStatsEntry
+
+// ModelStatsKey identifies a model of a provider in ModelStats
+func ModelStatsKey(providerName, model string) string {
+	return providerName + "/" + model
+}
+
+func getModelStatsPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, ModelStatsFile), nil
+}
+
+// LoadModelStats reads the comparison results, returning empty stats if none
+// exist
+func LoadModelStats() (ModelStats, error) {
+	path, err := getModelStatsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ModelStats{}, nil
+		}
+		return nil, fmt.Errorf("failed to read model stats: %w", err)
+	}
+
+	stats := ModelStats{}
+	if err := yaml.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse model stats: %w", err)
+	}
+	return stats, nil
+}
+
+// RecordComparison counts a comparison of models of providerName in which the
+// message of winner was picked
+func RecordComparison(providerName string, models []string, winner string) error {
+	stats, err := LoadModelStats()
+	if err != nil {
+		// Corrupt stats are simply started afresh
+		stats = ModelStats{}
+	}
+	for _, model := range models {
+		key := ModelStatsKey(providerName, model)
+		entry := stats[key]
+		entry.Compared++
+		if model == winner {
+			entry.Wins++
+		}
+		stats[key] = entry
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	path, err := getModelStatsPath()
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal model stats: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write model stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Package dataset writes commit message examples as JSON lines in the
+// formats fine-tuning tools read.
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format selects the layout of each example
+type Format string
+
+const (
+	// OpenAI is the chat format of OpenAI fine-tuning:
+	// {"messages": [{"role": "system", ...}, {"role": "user", ...}, {"role": "assistant", ...}]}
+	OpenAI Format = "openai"
+	// Alpaca is the instruction format axolotl reads as type "alpaca":
+	// {"instruction": ..., "input": ..., "output": ...}
+	Alpaca Format = "axolotl"
+)
+
+// ParseFormat reads a --format value
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case OpenAI, Alpaca:
+		return f, nil
+	case "alpaca":
+		return Alpaca, nil
+	default:
+		return "", fmt.Errorf("unknown dataset format %q (supported: %s, %s)", name, OpenAI, Alpaca)
+	}
+}
+
+// Example is one prompt with the message that was written for it
+type Example struct {
+	SystemPrompt string
+	UserPrompt   string
+	Message      string
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatExample struct {
+	Messages []chatMessage `json:"messages"`
+}
+
+type alpacaExample struct {
+	Instruction string `json:"instruction"`
+	Input       string `json:"input"`
+	Output      string `json:"output"`
+}
+
+// Writer writes examples to w, one JSON object per line
+type Writer struct {
+	format Format
+	enc    *json.Encoder
+}
+
+// NewWriter returns a Writer for format
+func NewWriter(w io.Writer, format Format) *Writer {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &Writer{format: format, enc: enc}
+}
+
+// Write writes one example
+func (w *Writer) Write(e Example) error {
+	if w.format == Alpaca {
+		return w.enc.Encode(alpacaExample{Instruction: e.SystemPrompt, Input: e.UserPrompt, Output: e.Message})
+	}
+	return w.enc.Encode(chatExample{Messages: []chatMessage{
+		{Role: "system", Content: e.SystemPrompt},
+		{Role: "user", Content: e.UserPrompt},
+		{Role: "assistant", Content: e.Message},
+	}})
+}
@@ -0,0 +1,185 @@
+// Package emoji adds, moves or removes the emoji of a conventional commit
+// message, so that emoji use follows the configuration rather than whatever
+// the model happened to write.
+package emoji
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Mode says whether messages carry an emoji
+type Mode string
+
+const (
+	// Auto keeps the emoji the model chose, if any
+	Auto Mode = "auto"
+	// Always gives every message the emoji of its type
+	Always Mode = "always"
+	// Never removes emoji
+	Never Mode = "never"
+)
+
+// Position says where the emoji goes
+type Position string
+
+const (
+	// BeforeType renders "✨ feat(cli): add flag"
+	BeforeType Position = "before_type"
+	// AfterColon renders "feat(cli): ✨ add flag", which is valid
+	// Conventional Commits
+	AfterColon Position = "after_colon"
+)
+
+// Defaults are the emoji of each commit type, matching the system prompt
+var Defaults = map[string]string{
+	"feat":     "✨",
+	"fix":      "🐛",
+	"core":     "🎯",
+	"edit":     "✏️",
+	"del":      "🗑️",
+	"chore":    "🔧",
+	"docs":     "📝",
+	"style":    "🎨",
+	"refactor": "♻️",
+	"perf":     "⚡",
+	"test":     "✅",
+	"ci":       "👷",
+}
+
+// Style is the configured emoji use. The zero value keeps messages as they are.
+type Style struct {
+	Mode Mode
+	// Position is empty to keep the emoji where the model put it, or before
+	// the type when an emoji is added
+	Position Position
+	// Custom maps types to the emoji they always get, unless Mode is Never,
+	// replacing the model's. An empty emoji removes it.
+	Custom map[string]string
+}
+
+// Parse reads the emoji, emoji_position and emoji_map settings. An empty
+// mode selects Auto.
+func Parse(mode, position string, mapping map[string]string) (Style, error) {
+	s := Style{Mode: Mode(strings.ToLower(strings.TrimSpace(mode))), Position: Position(strings.ToLower(strings.TrimSpace(position)))}
+	for typ, e := range mapping {
+		e = strings.TrimSpace(e)
+		if e != "" && !isEmoji(e) {
+			return Style{}, fmt.Errorf("emoji_map: %q for %s is not an emoji", e, typ)
+		}
+		if s.Custom == nil {
+			s.Custom = map[string]string{}
+		}
+		s.Custom[strings.ToLower(strings.TrimSpace(typ))] = e
+	}
+	switch s.Mode {
+	case "":
+		s.Mode = Auto
+	case Auto, Always, Never:
+	default:
+		return Style{}, fmt.Errorf("unknown emoji mode %q (supported: %s, %s, %s)", mode, Auto, Always, Never)
+	}
+	switch s.Position {
+	case "", BeforeType, AfterColon:
+	default:
+		return Style{}, fmt.Errorf("unknown emoji position %q (supported: %s, %s)", position, BeforeType, AfterColon)
+	}
+	return s, nil
+}
+
+var (
+	// header matches "type(scope)!:" and the space after it
+	header = regexp.MustCompile(`^[A-Za-z]+(?:\([^)]*\))?!?:\s*`)
+	// shortcode matches emoji written as ":sparkles:"
+	shortcode = regexp.MustCompile(`^:[a-z0-9_+-]+:$`)
+)
+
+// Apply rewrites the subject of message, the first line, to follow the
+// style. Messages without a conventional header only lose their emoji in
+// Never mode.
+func (s Style) Apply(message string) string {
+	if s.Mode == "" || (s.Mode == Auto && s.Position == "" && len(s.Custom) == 0) {
+		return message
+	}
+	subject, body, hasBody := strings.Cut(message, "\n")
+	subject = s.apply(strings.TrimSpace(subject))
+	if hasBody {
+		return subject + "\n" + body
+	}
+	return subject
+}
+
+func (s Style) apply(subject string) string {
+	// An emoji may come before the type or after the colon
+	found, rest := leadingEmoji(subject)
+	m := header.FindString(rest)
+	if m == "" {
+		if s.Mode == Never {
+			return rest
+		}
+		return subject
+	}
+	typ := strings.ToLower(m[:strings.IndexAny(m, "(!:")])
+	description := rest[len(m):]
+	position := s.Position
+	if after, desc := leadingEmoji(description); after != "" {
+		if found == "" {
+			found = after
+			if position == "" {
+				position = AfterColon
+			}
+		}
+		description = desc
+	}
+
+	custom, hasCustom := s.Custom[typ]
+	switch {
+	case s.Mode == Never:
+		found = ""
+	case hasCustom:
+		found = custom
+	case s.Mode == Always:
+		if e, ok := Defaults[typ]; ok {
+			found = e
+		}
+	}
+
+	head := strings.TrimSpace(m)
+	switch {
+	case found == "":
+		return head + " " + description
+	case position == AfterColon:
+		return head + " " + found + " " + description
+	default:
+		return found + " " + head + " " + description
+	}
+}
+
+// leadingEmoji splits an emoji off the start of s
+func leadingEmoji(s string) (string, string) {
+	token, rest, _ := strings.Cut(s, " ")
+	if !isEmoji(token) {
+		return "", s
+	}
+	return token, strings.TrimSpace(rest)
+}
+
+// isEmoji reports whether token is an emoji or a shortcode such as
+// ":sparkles:". Symbols outside ASCII count as emoji, letters of any script
+// don't.
+func isEmoji(token string) bool {
+	if token == "" {
+		return false
+	}
+	if shortcode.MatchString(token) {
+		return true
+	}
+	for _, r := range token {
+		if r < 128 || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+// Package eval scores generated commit messages against the messages the
+// commits were actually written with, so that prompt and model changes can
+// be compared on a repository's own history.
+package eval
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"auto-git/pkg/conventional"
+)
+
+// Score rates one generated message against the real one
+type Score struct {
+	// TypeMatch is set when both subjects are conventional and share the type
+	TypeMatch bool
+	// Typed is set when the real subject has a conventional type to compare
+	Typed bool
+	// Similarity is the share of words the two descriptions have in common,
+	// from 0 to 1
+	Similarity float64
+	// LengthOK is set when the generated subject fits the length limit
+	LengthOK bool
+}
+
+// Split returns the lowercased conventional type and the description of a
+// subject. Subjects without a type have an empty one and are described by
+// their whole text, minus a leading emoji.
+func Split(subject string) (string, string) {
+	_, rest := conventional.SplitEmoji(strings.TrimSpace(subject))
+	if m, err := conventional.Parse(rest); err == nil {
+		return strings.ToLower(m.Type), m.Description
+	}
+	return "", rest
+}
+
+// Rate scores generated against reference. Only the subject lines are
+// compared; limit is the maximum subject length in characters.
+func Rate(generated, reference string, limit int) Score {
+	genSubject, _, _ := strings.Cut(strings.TrimSpace(generated), "\n")
+	refSubject, _, _ := strings.Cut(strings.TrimSpace(reference), "\n")
+
+	genType, genText := Split(genSubject)
+	refType, refText := Split(refSubject)
+	return Score{
+		TypeMatch:  refType != "" && genType == refType,
+		Typed:      refType != "",
+		Similarity: similarity(words(genText), words(refText)),
+		LengthOK:   len([]rune(strings.TrimSpace(genSubject))) <= limit,
+	}
+}
+
+// words splits text into lowercase words, dropping punctuation
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// similarity is the Jaccard index of the two word sets
+func similarity(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	set := map[string]int{}
+	for _, w := range a {
+		set[w] |= 1
+	}
+	for _, w := range b {
+		set[w] |= 2
+	}
+	shared := 0
+	for _, in := range set {
+		if in == 3 {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(set))
+}
+
+// Result is the outcome of one variant on one commit
+type Result struct {
+	Commit  string
+	Message string
+	Latency time.Duration
+	Err     error
+	Score   Score
+}
+
+// Summary aggregates the results of one variant
+type Summary struct {
+	Cases  int
+	Errors int
+	// Typed is the number of generated messages whose commit has a
+	// conventional real subject
+	Typed int
+	// TypeAccuracy is the share of commits with a conventional real subject
+	// whose type was matched
+	TypeAccuracy float64
+	// Similarity is the mean similarity of the generated messages
+	Similarity float64
+	// LengthCompliance is the share of generated subjects within the limit
+	LengthCompliance float64
+	// Latency is the mean time per generated message
+	Latency time.Duration
+}
+
+// Summarize aggregates results. Failed generations count as errors and are
+// left out of the scores.
+func Summarize(results []Result) Summary {
+	s := Summary{Cases: len(results)}
+	var typed, matched, fit, ok int
+	var latency time.Duration
+	for _, r := range results {
+		if r.Err != nil {
+			s.Errors++
+			continue
+		}
+		ok++
+		latency += r.Latency
+		s.Similarity += r.Score.Similarity
+		if r.Score.LengthOK {
+			fit++
+		}
+		if r.Score.Typed {
+			typed++
+			if r.Score.TypeMatch {
+				matched++
+			}
+		}
+	}
+	if ok > 0 {
+		s.Similarity /= float64(ok)
+		s.LengthCompliance = float64(fit) / float64(ok)
+		s.Latency = latency / time.Duration(ok)
+	}
+	s.Typed = typed
+	if typed > 0 {
+		s.TypeAccuracy = float64(matched) / float64(typed)
+	}
+	return s
+}
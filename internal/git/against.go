@@ -0,0 +1,76 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// deepenStep and maxDeepenSteps bound how much history CollectAgainst
+// fetches into a shallow clone to find the merge base
+const (
+	deepenStep     = 100
+	maxDeepenSteps = 5
+)
+
+// CollectAgainst gathers everything the current branch changes relative to
+// ref: the commits since the merge base of ref and HEAD (as git diff
+// ref...HEAD) plus the uncommitted changes, or only the staged ones with
+// staged. Paths are limited to filter.
+func CollectAgainst(dir, ref string, filter PathFilter, staged bool) (*Changes, string, error) {
+	gitRoot, err := FindGitRoot(dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	output, err := runGit(gitRoot, "merge-base", ref, "HEAD")
+	if err != nil && deepenShallow && isShallow(gitRoot) {
+		// Fetch history until the branches meet, a few hundred commits at most
+		for range maxDeepenSteps {
+			if deepen(gitRoot, deepenStep) != nil {
+				break
+			}
+			if output, err = runGit(gitRoot, "merge-base", ref, "HEAD"); err == nil || !isShallow(gitRoot) {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, "", shallowError(gitRoot, fmt.Errorf("failed to find the merge base of %s and HEAD: %w", ref, err))
+	}
+	base := strings.TrimSpace(string(output))
+
+	args := append([]string{"diff", "--raw", "--numstat"}, patchArgs()...)
+	if staged {
+		args = append([]string{"diff", "--cached", "--raw", "--numstat"}, patchArgs()...)
+	}
+	args = append(args, base)
+	if specs := filter.Pathspecs(); specs != nil {
+		args = append(append(args, "--"), specs...)
+	}
+	output, err = runGit(gitRoot, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to diff against %s: %w", ref, err)
+	}
+
+	numstat, patch := splitNumstatPatch(string(output))
+	files, err := parseDiffOutput(numstat, true)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(files) == 0 {
+		return nil, "", ErrNoChanges
+	}
+	markModeChanges(files, patch)
+	files, patch = markGeneratedChanges(gitRoot, files, patch)
+	if staged {
+		markSymbolChanges(gitRoot, base, indexVersion, files)
+	} else {
+		markSymbolChanges(gitRoot, base, worktreeVersion, files)
+	}
+
+	return &Changes{
+		Staged:  files,
+		Summary: summarizeGroup("Changes since "+ref, files),
+		Context: "These are all the changes since " + ref + ".",
+	}, patch, nil
+}
@@ -0,0 +1,66 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// noLazyFetch keeps git from downloading objects a partial clone left out
+// (git 2.44 and later); older versions ignore it
+var noLazyFetch = []string{"GIT_NO_LAZY_FETCH=1"}
+
+// deepenShallow is set by SetDeepenShallow
+var deepenShallow bool
+
+// SetDeepenShallow lets features that read history, such as style learning
+// and --against, fetch more of it when the repository is a shallow clone
+func SetDeepenShallow(on bool) {
+	deepenShallow = on
+}
+
+// IsShallow reports whether the current repository is a shallow clone
+func IsShallow() bool {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return false
+	}
+	return isShallow(gitRoot)
+}
+
+// IsPartial reports whether the current repository is a partial clone, which
+// downloads file contents when they are first read
+func IsPartial() bool {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return false
+	}
+	return isPartial(gitRoot)
+}
+
+func isShallow(gitRoot string) bool {
+	output, err := runGit(gitRoot, "rev-parse", "--is-shallow-repository")
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+func isPartial(gitRoot string) bool {
+	output, err := runGit(gitRoot, "config", "--get-regexp", `^remote\..*\.promisor$`)
+	return err == nil && strings.Contains(string(output), "true")
+}
+
+// deepen fetches up to commits more commits of history from origin
+func deepen(gitRoot string, commits int) error {
+	if _, err := runGitEnv(gitRoot, []string{"GIT_TERMINAL_PROMPT=0"}, "fetch", "--quiet", "--deepen="+strconv.Itoa(commits), defaultRemote); err != nil {
+		return fmt.Errorf("failed to deepen the shallow clone: %w", err)
+	}
+	return nil
+}
+
+// shallowError adds a hint to err when the repository is a shallow clone, in
+// which case err is likely caused by the missing history
+func shallowError(gitRoot string, err error) error {
+	if !isShallow(gitRoot) {
+		return err
+	}
+	return fmt.Errorf("%w (this is a shallow clone; git fetch --unshallow fetches the full history)", err)
+}
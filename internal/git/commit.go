@@ -1,6 +1,7 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,6 +10,10 @@ import (
 
 const defaultRemote = "origin"
 
+// ErrPushFailed is wrapped by errors returned when the commit was created but
+// could not be pushed
+var ErrPushFailed = errors.New("push failed")
+
 func getGitRoot() (string, error) {
 	workDir, err := os.Getwd()
 	if err != nil {
@@ -24,47 +29,213 @@ func getGitRoot() (string, error) {
 }
 
 func StageAll() error {
+	return StagePaths(PathFilter{})
+}
+
+// StagePaths stages every change, including deletions and new files, that
+// matches filter
+func StagePaths(filter PathFilter) error {
 	gitRoot, err := getGitRoot()
 	if err != nil {
 		return err
 	}
 
-	cmd := exec.Command("git", "add", "-A")
-	cmd.Dir = gitRoot
-	if err := cmd.Run(); err != nil {
+	args := []string{"add", "-A"}
+	specs := filter.Pathspecs()
+	if dirs := sparseCone(gitRoot); dirs != nil {
+		// Only what is checked out, so that paths outside the cone stay as they are
+		paths, err := conePaths(gitRoot, specs, dirs)
+		if err != nil {
+			return fmt.Errorf("failed to list changes: %w", err)
+		}
+		if len(paths) == 0 {
+			return nil
+		}
+		args = append(args, "--pathspec-from-file=-", "--pathspec-file-nul")
+		if _, err := runGitInput(gitRoot, strings.Join(paths, "\x00"), args...); err != nil {
+			return fmt.Errorf("failed to stage changes: %w", err)
+		}
+		return nil
+	}
+	if specs != nil {
+		args = append(append(args, "--"), specs...)
+	}
+	if _, err := runGit(gitRoot, args...); err != nil {
 		return fmt.Errorf("failed to stage changes: %w", err)
 	}
 	return nil
 }
 
+// UnstagePaths removes the staged changes that match filter from the index,
+// leaving the worktree as it is
+func UnstagePaths(filter PathFilter) error {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"reset", "-q", "--"}
+	if _, err := runGit(gitRoot, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		// Before the first commit there is no HEAD to reset the index to
+		args = []string{"rm", "--cached", "-r", "-q", "--"}
+	}
+	specs := filter.Pathspecs()
+	if specs == nil {
+		specs = []string{"."}
+	}
+	if _, err := runGit(gitRoot, append(args, specs...)...); err != nil {
+		return fmt.Errorf("failed to unstage changes: %w", err)
+	}
+	return nil
+}
+
+// CommitOptions adjusts how CommitWith records a commit
+type CommitOptions struct {
+	// Paths limits the commit to matching paths; other staged changes stay in
+	// the index
+	Paths PathFilter
+	// Signoff appends a Signed-off-by trailer for the committer (git commit -s)
+	Signoff bool
+	// Author overrides the commit author, as "Name <email>" (git commit --author)
+	Author string
+	// Date overrides the author date in any format git understands (git commit --date)
+	Date string
+	// AllowEmpty permits a commit without changes, e.g. to trigger CI
+	AllowEmpty bool
+}
+
 func Commit(message string) error {
+	return CommitWith(message, CommitOptions{})
+}
+
+// CommitWith creates a commit from the index with message and opts
+func CommitWith(message string, opts CommitOptions) error {
 	if strings.TrimSpace(message) == "" {
 		return fmt.Errorf("commit message cannot be empty")
 	}
 
+	return commit([]string{"commit", "-m", message}, opts)
+}
+
+// CommitFixup creates a "fixup! <subject>" commit for target from the index,
+// ready for git rebase --autosquash
+func CommitFixup(target string, opts CommitOptions) error {
+	return commit([]string{"commit", "--fixup=" + target}, opts)
+}
+
+func commit(args []string, opts CommitOptions) error {
 	gitRoot, err := getGitRoot()
 	if err != nil {
 		return err
 	}
 
-	cmd := exec.Command("git", "commit", "-m", message)
-	cmd.Dir = gitRoot
-	if err := cmd.Run(); err != nil {
+	if opts.Signoff {
+		args = append(args, "--signoff")
+	}
+	if opts.AllowEmpty {
+		args = append(args, "--allow-empty")
+	}
+	if opts.Author != "" {
+		args = append(args, "--author="+opts.Author)
+	}
+	if opts.Date != "" {
+		args = append(args, "--date="+opts.Date)
+	}
+	if specs := opts.Paths.Pathspecs(); specs != nil {
+		args = append(append(args, "--"), specs...)
+	}
+	if _, err := runGit(gitRoot, args...); err != nil {
 		return fmt.Errorf("failed to create commit: %w", err)
 	}
 	return nil
 }
 
+// credentialPrompts is set by SetCredentialPrompts
+var credentialPrompts bool
+
+// SetCredentialPrompts lets Push ask for usernames, passwords and SSH
+// passphrases on the terminal. Without it git is told not to prompt, so that
+// a push needing credentials fails at once instead of waiting for input no
+// one can give. A GIT_ASKPASS or SSH_ASKPASS program is used either way.
+func SetCredentialPrompts(on bool) {
+	credentialPrompts = on
+}
+
+// pushProgress is set by SetPushProgress
+var pushProgress func(string)
+
+// SetPushProgress makes Push pass each progress line of git push, such as
+// "Writing objects: 40% (8/20)" or git-lfs uploads, to fn while it runs. It
+// has no effect while credential prompts are on, as git then draws its
+// progress on the terminal itself. nil turns it off.
+func SetPushProgress(fn func(string)) {
+	pushProgress = fn
+}
+
+// Push pushes the current branch. Well-known failures wrap ErrPushRejected,
+// ErrNoUpstream, ErrPushAuth or ErrProtectedBranch.
 func Push() error {
+	return push("push")
+}
+
+// PushSetUpstream pushes the current branch to a branch of the same name on
+// origin and makes it the upstream branch
+func PushSetUpstream() error {
+	return push("push", "--set-upstream", defaultRemote, "HEAD")
+}
+
+func push(args ...string) error {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return err
+	}
+
+	if credentialPrompts {
+		// git's error is already on the terminal
+		if stderr, err := runGitAttached(gitRoot, args...); err != nil {
+			return pushError(err, stderr, false)
+		}
+		return nil
+	}
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	if pushProgress != nil {
+		// git only reports progress to a terminal unless asked to
+		args = append(args, "--progress")
+		if stderr, err := runGitProgress(gitRoot, env, pushProgress, args...); err != nil {
+			return pushError(err, stderr, true)
+		}
+		return nil
+	}
+	_, err = runGitEnv(gitRoot, env, args...)
+	if err != nil {
+		var stderr string
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			stderr = string(exitErr.Stderr)
+		}
+		return pushError(err, stderr, true)
+	}
+	return nil
+}
+
+// PullRebase rebases the current branch onto its upstream branch, setting
+// local changes aside meanwhile. A rebase that stops on conflicts is aborted.
+func PullRebase() error {
 	gitRoot, err := getGitRoot()
 	if err != nil {
 		return err
 	}
 
-	cmd := exec.Command("git", "push")
-	cmd.Dir = gitRoot
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to push: %w", err)
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	if credentialPrompts {
+		env = nil
+	}
+	if _, err := runGitEnv(gitRoot, env, "pull", "--rebase", "--autostash"); err != nil {
+		if _, statErr := runGit(gitRoot, "rev-parse", "--verify", "--quiet", "REBASE_HEAD"); statErr == nil {
+			runGit(gitRoot, "rebase", "--abort")
+			return fmt.Errorf("the upstream changes conflict with yours; run git pull --rebase and resolve the conflicts")
+		}
+		return fmt.Errorf("failed to pull: %w", err)
 	}
 	return nil
 }
@@ -74,9 +245,9 @@ func CommitAndPush(message string) (bool, error) {
 		return false, err
 	}
 
-	pushed, err := pushIfRemoteExists()
+	pushed, err := PushIfRemoteExists()
 	if err != nil {
-		return false, fmt.Errorf("commit successful but push failed: %w", err)
+		return false, fmt.Errorf("commit successful but %w", err)
 	}
 
 	return pushed, nil
@@ -91,38 +262,48 @@ func StageAndCommitAndPush(message string) (bool, error) {
 		return false, err
 	}
 
-	pushed, err := pushIfRemoteExists()
+	pushed, err := PushIfRemoteExists()
 	if err != nil {
-		return false, fmt.Errorf("commit successful but push failed: %w", err)
+		return false, fmt.Errorf("commit successful but %w", err)
 	}
 
 	return pushed, nil
 }
 
-func pushIfRemoteExists() (bool, error) {
-	hasOrigin, err := hasRemote(defaultRemote)
+// PushIfRemoteExists pushes to origin and reports whether it did; without an
+// origin remote there is nothing to push. Failures wrap ErrPushFailed.
+func PushIfRemoteExists() (bool, error) {
+	hasOrigin, err := HasOrigin()
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("%w: %w", ErrPushFailed, err)
 	}
 	if !hasOrigin {
 		return false, nil
 	}
 
 	if err := Push(); err != nil {
-		return false, err
+		return false, fmt.Errorf("%w: %w", ErrPushFailed, err)
 	}
 	return true, nil
 }
 
+// HasOrigin reports whether the repository has an origin remote to push to
+func HasOrigin() (bool, error) {
+	return hasRemote(defaultRemote)
+}
+
+// Root returns the top-level directory of the current repository
+func Root() (string, error) {
+	return getGitRoot()
+}
+
 func hasRemote(remoteName string) (bool, error) {
 	gitRoot, err := getGitRoot()
 	if err != nil {
 		return false, err
 	}
 
-	cmd := exec.Command("git", "remote")
-	cmd.Dir = gitRoot
-	output, err := cmd.Output()
+	output, err := runGit(gitRoot, "remote")
 	if err != nil {
 		return false, fmt.Errorf("failed to list git remotes: %w", err)
 	}
@@ -140,3 +321,24 @@ func hasRemote(remoteName string) (bool, error) {
 
 	return false, nil
 }
+
+// SignoffTrailer returns the Signed-off-by line git commit -s would add,
+// using the committer identity
+func SignoffTrailer() (string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := runGit(gitRoot, "var", "GIT_COMMITTER_IDENT")
+	if err != nil {
+		return "", fmt.Errorf("failed to read committer identity: %w", err)
+	}
+
+	// "Name <email> 1700000000 +0100": drop the timestamp and zone
+	ident := strings.TrimSpace(string(output))
+	if end := strings.LastIndex(ident, ">"); end >= 0 {
+		ident = ident[:end+1]
+	}
+	return "Signed-off-by: " + ident, nil
+}
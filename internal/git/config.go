@@ -0,0 +1,79 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// GetGlobalConfig reads a key from the user's global git config, returning ""
+// when it is unset
+func GetGlobalConfig(key string) (string, error) {
+	output, err := runGit(globalConfigDir(), "config", "--global", "--get", key)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read git config %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// SetGlobalConfig writes a key to the user's global git config
+func SetGlobalConfig(key, value string) error {
+	if _, err := runGit(globalConfigDir(), "config", "--global", key, value); err != nil {
+		return fmt.Errorf("failed to set git config %s: %w", key, err)
+	}
+	return nil
+}
+
+// UnsetGlobalConfig removes a key from the user's global git config
+func UnsetGlobalConfig(key string) error {
+	if _, err := runGit(globalConfigDir(), "config", "--global", "--unset", key); err != nil {
+		return fmt.Errorf("failed to unset git config %s: %w", key, err)
+	}
+	return nil
+}
+
+// globalConfigDir runs global config commands from the home directory so they
+// work outside a repository
+func globalConfigDir() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		return home
+	}
+	return ""
+}
+
+// ConfigSection returns the variables of a git config section, such as
+// "autogit", from every config file that applies to the current directory,
+// with repository settings overriding global ones. Keys are the lowercased
+// names after the section, e.g. "provider" for autogit.provider.
+func ConfigSection(section string) (map[string]string, error) {
+	dir := globalConfigDir()
+	if gitRoot, err := getGitRoot(); err == nil {
+		dir = gitRoot
+	}
+
+	output, err := runGit(dir, "config", "--null", "--get-regexp", "^"+section+`\.`)
+	values := map[string]string{}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return values, nil
+		}
+		return nil, fmt.Errorf("failed to read git config %s.*: %w", section, err)
+	}
+
+	// With --null each entry is "key\nvalue\x00", or "key\x00" for a bare key
+	for _, entry := range strings.Split(string(output), "\x00") {
+		if entry == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(entry, "\n")
+		values[strings.TrimPrefix(key, section+".")] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Editor returns the editor command git would use for commit messages,
+// honoring GIT_EDITOR, core.editor, VISUAL, and EDITOR in that order
+func Editor() (string, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	output, err := runGit(workDir, "var", "GIT_EDITOR")
+	if err != nil {
+		// Outside a repository git var still works, but fall back to the
+		// environment in case git itself is unusable
+		for _, env := range []string{"GIT_EDITOR", "VISUAL", "EDITOR"} {
+			if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+				return v, nil
+			}
+		}
+		return "vi", nil
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// EditMessagePath returns the file used to edit commit messages: COMMIT_EDITMSG
+// inside .git when in a repository, so editors apply commit-message syntax
+func EditMessagePath() string {
+	if gitRoot, err := getGitRoot(); err == nil {
+		return filepath.Join(gitRoot, ".git", "COMMIT_EDITMSG")
+	}
+	return filepath.Join(os.TempDir(), "COMMIT_EDITMSG")
+}
+
+// PlainSummary lists the changes as git-style comment lines, without colors
+func PlainSummary(changes *Changes) []string {
+	var lines []string
+	appendGroup := func(title string, files []FileChange) {
+		if len(files) == 0 {
+			return
+		}
+		lines = append(lines, title)
+		for _, f := range files {
+			lines = append(lines, fmt.Sprintf("\t%-7s %s (+%d -%d)", string(f.Type)+":", f.Path, f.Additions, f.Deletions))
+		}
+	}
+	appendGroup("Staged changes:", changes.Staged)
+	appendGroup("Unstaged changes:", changes.Unstaged)
+	return lines
+}
@@ -0,0 +1,136 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"auto-git/internal/logging"
+)
+
+// ShellQuote quotes s for the POSIX shell git runs editors, exec lines and
+// "!" aliases with. Words without special characters are left as they are.
+func ShellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runGit runs git with args in dir and returns its standard output.
+// Every invocation is logged at debug level together with its duration.
+func runGit(dir string, args ...string) ([]byte, error) {
+	return runGitEnv(dir, nil, args...)
+}
+
+// runGitEnv is like runGit with extra environment variables, as "KEY=value"
+func runGitEnv(dir string, env []string, args ...string) ([]byte, error) {
+	return runGitStdin(dir, env, nil, args...)
+}
+
+// runGitInput is like runGit with input on git's standard input
+func runGitInput(dir, input string, args ...string) ([]byte, error) {
+	return runGitStdin(dir, nil, strings.NewReader(input), args...)
+}
+
+func runGitStdin(dir string, env []string, stdin io.Reader, args ...string) ([]byte, error) {
+	start := time.Now()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdin = stdin
+	output, err := cmd.Output()
+
+	attrs := []any{"args", strings.Join(args, " "), "dir", dir, "duration", time.Since(start)}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			attrs = append(attrs, "stderr", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+	}
+	logging.Debug("git command", attrs...)
+
+	return output, err
+}
+
+// runGitAttached runs git with args in dir with stdin attached, so that it
+// can ask for credentials, and its output shown on stderr. It returns what
+// git wrote to stderr.
+func runGitAttached(dir string, args ...string) (string, error) {
+	start := time.Now()
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	// Keep stdout free for the commit message and JSON logs
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	err := cmd.Run()
+
+	attrs := []any{"args", strings.Join(args, " "), "dir", dir, "duration", time.Since(start), "attached", true}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	logging.Debug("git command", attrs...)
+
+	return stderr.String(), err
+}
+
+// runGitProgress runs git with args in dir like runGitEnv, passing each line
+// git writes to stderr, including the ones it redraws with a carriage return,
+// to progress as it arrives. It returns what git wrote to stderr.
+func runGitProgress(dir string, env []string, progress func(string), args ...string) (string, error) {
+	start := time.Now()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	pipe, err := cmd.StderrPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	var stderr bytes.Buffer
+	scanner := bufio.NewScanner(io.TeeReader(pipe, &stderr))
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			progress(line)
+		}
+	}
+	err = cmd.Wait()
+
+	attrs := []any{"args", strings.Join(args, " "), "dir", dir, "duration", time.Since(start)}
+	if err != nil {
+		attrs = append(attrs, "error", err, "stderr", strings.TrimSpace(stderr.String()))
+	}
+	logging.Debug("git command", attrs...)
+
+	return stderr.String(), err
+}
+
+// scanProgressLines is a bufio.SplitFunc for lines ending in \n or \r
+func scanProgressLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
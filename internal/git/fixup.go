@@ -0,0 +1,195 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoFixupTarget is returned when no earlier commit could be matched to the changes
+var ErrNoFixupTarget = errors.New("could not determine which commit the changes belong to")
+
+// FixupTarget guesses the earlier commit that pending changes matching filter
+// correct: the one that last touched most of the modified lines, or failing
+// that the last one to touch the changed files. With staged only the index is
+// considered. When the branch has an upstream, only unpushed commits qualify.
+func FixupTarget(filter PathFilter, staged bool) (string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"diff", "-U0", "--no-renames", "HEAD"}
+	if staged {
+		args = []string{"diff", "-U0", "--no-renames", "--cached"}
+	}
+	if specs := filter.Pathspecs(); specs != nil {
+		args = append(append(args, "--"), specs...)
+	}
+	output, err := runGit(gitRoot, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff changes: %w", err)
+	}
+	hunks := parseZeroContextHunks(string(output))
+	if len(hunks) == 0 {
+		return "", ErrNoChanges
+	}
+
+	// Commits already on the upstream branch should not be rewritten
+	var allowed map[string]bool
+	logRange := "HEAD"
+	if _, err := runGit(gitRoot, "rev-parse", "--verify", "--quiet", "@{upstream}"); err == nil {
+		logRange = "@{upstream}..HEAD"
+		output, err := runGit(gitRoot, "rev-list", logRange)
+		if err != nil {
+			return "", fmt.Errorf("failed to list unpushed commits: %w", err)
+		}
+		allowed = map[string]bool{}
+		for _, sha := range strings.Fields(string(output)) {
+			allowed[sha] = true
+		}
+	}
+
+	// Reading old file versions would download them one by one
+	var env []string
+	if isPartial(gitRoot) {
+		env = noLazyFetch
+	}
+	votes := map[string]int{}
+	var paths []string
+	for _, h := range hunks {
+		paths = append(paths, h.path)
+		start, count := h.oldStart, h.oldCount
+		if count == 0 {
+			// Pure insertion: attribute it to the line it follows
+			if start == 0 {
+				continue
+			}
+			count = 1
+		}
+		blame, err := runGitEnv(gitRoot, env, "blame", "--line-porcelain", "-L", fmt.Sprintf("%d,+%d", start, count), "HEAD", "--", h.path)
+		if err != nil {
+			continue
+		}
+		// Each line starts with a header naming its commit; the oldest commit
+		// of a shallow clone is marked "boundary" and takes the blame for all
+		// the history before it, so it gets no vote
+		sha, boundary := "", false
+		for _, line := range strings.Split(string(blame), "\n") {
+			switch {
+			case strings.HasPrefix(line, "\t"):
+				if sha != "" && !boundary && (allowed == nil || allowed[sha]) {
+					votes[sha]++
+				}
+				sha, boundary = "", false
+			case line == "boundary":
+				boundary = true
+			case sha == "":
+				if id, _, _ := strings.Cut(line, " "); len(id) == 40 && strings.Trim(id, "0123456789abcdef") == "" {
+					sha = id
+				}
+			}
+		}
+	}
+
+	if best := mostVoted(gitRoot, votes); best != "" {
+		return best, nil
+	}
+
+	output, err = runGit(gitRoot, append([]string{"log", "-1", "--format=%H", logRange, "--"}, paths...)...)
+	if err == nil {
+		if sha := strings.TrimSpace(string(output)); sha != "" {
+			return sha, nil
+		}
+	}
+	return "", ErrNoFixupTarget
+}
+
+// mostVoted returns the commit with the most votes, preferring the newest on a tie
+func mostVoted(gitRoot string, votes map[string]int) string {
+	if len(votes) == 0 {
+		return ""
+	}
+
+	shas := make([]string, 0, len(votes))
+	for sha := range votes {
+		shas = append(shas, sha)
+	}
+	// Newest first, so the first maximum wins ties
+	if output, err := runGit(gitRoot, append([]string{"log", "--no-walk=sorted", "--format=%H"}, shas...)...); err == nil {
+		shas = strings.Fields(string(output))
+	}
+
+	best := ""
+	for _, sha := range shas {
+		if best == "" || votes[sha] > votes[best] {
+			best = sha
+		}
+	}
+	return best
+}
+
+// zeroContextHunk is the old side of a hunk from git diff -U0
+type zeroContextHunk struct {
+	path     string
+	oldStart int
+	oldCount int
+}
+
+func parseZeroContextHunks(diff string) []zeroContextHunk {
+	var hunks []zeroContextHunk
+	path := ""
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			path = ""
+		case strings.HasPrefix(line, "--- "):
+			path = stripPatchPrefix(strings.TrimPrefix(line, "--- "))
+		case strings.HasPrefix(line, "+++ ") && path == "":
+			// New files have no old side to blame but still count as changed
+			path = stripPatchPrefix(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "@@ ") && path != "":
+			h := zeroContextHunk{path: path, oldCount: 1}
+			for _, field := range strings.Fields(line) {
+				if strings.HasPrefix(field, "-") {
+					start, count, hasCount := strings.Cut(field[1:], ",")
+					fmt.Sscanf(start, "%d", &h.oldStart)
+					if hasCount {
+						fmt.Sscanf(count, "%d", &h.oldCount)
+					}
+					break
+				}
+			}
+			hunks = append(hunks, h)
+		}
+	}
+	return hunks
+}
+
+// ResolveCommit returns the full hash of the commit rev names
+func ResolveCommit(rev string) (string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := runGit(gitRoot, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown commit %q", rev)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// DescribeCommit returns the abbreviated hash and subject of rev
+func DescribeCommit(rev string) (string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := runGit(gitRoot, "log", "-1", "--format=%h %s", rev)
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit %s: %w", rev, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
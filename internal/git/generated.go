@@ -0,0 +1,144 @@
+package git
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// vendorDirs hold third-party code checked into the repository
+var vendorDirs = []string{"vendor/", "node_modules/", "bower_components/", "third_party/"}
+
+// lockFiles are regenerated by package managers and never edited by hand
+var lockFiles = map[string]bool{
+	"go.sum": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"cargo.lock": true, "poetry.lock": true, "composer.lock": true, "gemfile.lock": true,
+	"pipfile.lock": true, "npm-shrinkwrap.json": true,
+}
+
+// generatedMarker matches the comment generators put at the top of their
+// output, such as Go's "// Code generated by stringer; DO NOT EDIT."
+var generatedMarker = regexp.MustCompile(`(?m)^\s*(?://|#|/?\*+|<!--|--|;)\s*(?:Code generated\b|@generated\b)`)
+
+// generatedHeadSize is how much of a file is searched for generatedMarker
+const generatedHeadSize = 4096
+
+// markGeneratedChanges flags vendored and generated files. Vendored patches
+// are dropped, since the summary already names the dependency update, and
+// generated ones are replaced with a one-line note.
+func markGeneratedChanges(gitRoot string, files []FileChange, patch string) ([]FileChange, string) {
+	if len(files) == 0 {
+		return files, patch
+	}
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	attrs := linguistAttributes(gitRoot, paths)
+
+	vendored := map[string]bool{}
+	generated := map[string]bool{}
+	for i, f := range files {
+		attr := attrs[f.Path]
+		files[i].Vendored = attrOr(attr["linguist-vendored"], isVendoredPath(f.Path))
+		files[i].Generated = !files[i].Vendored &&
+			attrOr(attr["linguist-generated"], isLockFile(f.Path) || hasGeneratedMarker(filepath.Join(gitRoot, f.Path)))
+		vendored[f.Path] = files[i].Vendored
+		generated[f.Path] = files[i].Generated
+	}
+
+	patch = replaceFilePatches(patch, generated, "Generated file; the diff is omitted.")
+	sections := splitFilePatches(patch)
+	kept := sections[:0]
+	for _, section := range sections {
+		header, _, _ := strings.Cut(section, "\n")
+		if !vendored[pathFromDiffHeader(header)] {
+			kept = append(kept, section)
+		}
+	}
+	return files, strings.Join(kept, "")
+}
+
+// linguistAttributes reads the linguist-vendored and linguist-generated
+// attributes that .gitattributes sets for paths
+func linguistAttributes(gitRoot string, paths []string) map[string]map[string]string {
+	attrs := map[string]map[string]string{}
+	args := append([]string{"check-attr", "-z", "linguist-vendored", "linguist-generated", "--"}, paths...)
+	output, err := runGit(gitRoot, args...)
+	if err != nil {
+		return attrs
+	}
+
+	// With -z the output is "path\x00attribute\x00value\x00" per attribute
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		p, attr, value := fields[i], fields[i+1], fields[i+2]
+		if attrs[p] == nil {
+			attrs[p] = map[string]string{}
+		}
+		attrs[p][attr] = value
+	}
+	return attrs
+}
+
+// attrOr interprets a git attribute value, falling back to def when the
+// attribute is not specified. "-linguist-generated" in .gitattributes turns
+// detection off for a path.
+func attrOr(value string, def bool) bool {
+	switch value {
+	case "set", "true":
+		return true
+	case "unset", "false":
+		return false
+	default:
+		return def
+	}
+}
+
+func isVendoredPath(p string) bool {
+	for _, dir := range vendorDirs {
+		if strings.HasPrefix(p, dir) || strings.Contains(p, "/"+dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLockFile(p string) bool {
+	return lockFiles[strings.ToLower(path.Base(p))]
+}
+
+// hasGeneratedMarker reports whether the start of the file carries a
+// generator comment; missing files, e.g. deleted ones, have none
+func hasGeneratedMarker(file string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	head := make([]byte, generatedHeadSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false
+	}
+	return generatedMarker.Match(head[:n])
+}
+
+// VendorRoot returns the vendor directory containing p, such as "vendor/" or
+// "web/node_modules/", or "" if p is not vendored by path
+func VendorRoot(p string) string {
+	for _, dir := range vendorDirs {
+		if strings.HasPrefix(p, dir) {
+			return dir
+		}
+		if i := strings.Index(p, "/"+dir); i >= 0 {
+			return p[:i+1+len(dir)]
+		}
+	}
+	return ""
+}
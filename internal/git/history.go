@@ -0,0 +1,94 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"auto-git/internal/logging"
+)
+
+// StateDirName is the directory inside .git where auto-git keeps per-repository data
+const StateDirName = "auto-git"
+
+// StateDir returns the auto-git directory inside the repository's git directory.
+// It is not created.
+func StateDir() (string, error) {
+	dir, err := gitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, StateDirName), nil
+}
+
+// gitDir returns the absolute path of the repository's git directory, which
+// for a linked worktree is not .git in its root
+func gitDir() (string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := runGit(gitRoot, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", fmt.Errorf("failed to locate git directory: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// RecentSubjects returns the subject lines of up to limit of the most recent
+// non-merge commits, newest first. A repository without commits has none. A
+// shallow clone with fewer commits is deepened first if SetDeepenShallow
+// allows it; otherwise the commits it has are used.
+func RecentSubjects(limit int) ([]string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := runGit(gitRoot, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return nil, nil
+	}
+
+	if deepenShallow && isShallow(gitRoot) {
+		if n, err := countCommits(gitRoot, "HEAD"); err == nil && n < limit {
+			if err := deepen(gitRoot, limit-n); err != nil {
+				logging.Debug("using the history of the shallow clone", "commits", n, "error", err)
+			}
+		}
+	}
+
+	output, err := runGit(gitRoot, "log", "-n", strconv.Itoa(limit), "--no-merges", "--format=%s")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit history: %w", err)
+	}
+
+	var subjects []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			subjects = append(subjects, line)
+		}
+	}
+	return subjects, nil
+}
+
+// RecentCommits returns the full hashes of up to limit of the most recent
+// commits with exactly one parent, newest first. Merges and root commits are
+// left out because they have no single diff to describe.
+func RecentCommits(limit int) ([]string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := runGit(gitRoot, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return nil, nil
+	}
+
+	output, err := runGit(gitRoot, "log", "-n", strconv.Itoa(limit), "--min-parents=1", "--max-parents=1", "--format=%H")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit history: %w", err)
+	}
+	return strings.Fields(string(output)), nil
+}
@@ -0,0 +1,141 @@
+package git
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// HookFixes describes what commit hooks, such as formatters, changed in a
+// commit compared to the content auto-git staged
+type HookFixes struct {
+	// Paths are the changed files, relative to the repository root
+	Paths []string
+	// Diff is the patch from the staged content to the committed one
+	Diff string
+}
+
+// CommitChecked is CommitWith for hooks that modify files. Fixes a hook
+// leaves unstaged in files that had no unstaged changes before are staged:
+// when the hook failed because of them the commit is retried once, and
+// otherwise the commit is amended without running the hooks again. It
+// returns what ended up in the commit besides the staged content, including
+// fixes the hooks staged themselves, or nil when the hooks changed nothing.
+func CommitChecked(message string, opts CommitOptions) (*HookFixes, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, fmt.Errorf("commit message cannot be empty")
+	}
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := runGit(gitRoot, "write-tree")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+	staged := strings.TrimSpace(string(output))
+	specs := opts.Paths.Pathspecs()
+	before, err := unstagedPaths(gitRoot, specs)
+	if err != nil {
+		return nil, err
+	}
+
+	commitErr := commit([]string{"commit", "-m", message}, opts)
+	after, err := unstagedPaths(gitRoot, specs)
+	if err != nil {
+		return nil, err
+	}
+	var fixed []string
+	for _, path := range after {
+		if !slices.Contains(before, path) {
+			fixed = append(fixed, path)
+		}
+	}
+
+	if len(fixed) > 0 {
+		if _, err := runGit(gitRoot, append([]string{"add", "--"}, fixed...)...); err != nil {
+			return nil, fmt.Errorf("failed to stage the changes of commit hooks: %w", err)
+		}
+	}
+	switch {
+	case commitErr != nil && len(fixed) == 0:
+		return nil, commitErr
+	case commitErr != nil:
+		// The hook rejected its own fixes; with them staged it may pass
+		if err := commit([]string{"commit", "-m", message}, opts); err != nil {
+			return nil, err
+		}
+	case len(fixed) > 0:
+		// Only the fixed paths, so that other staged changes stay out of it
+		args := append([]string{"commit", "--amend", "--no-edit", "--no-verify", "--"}, fixed...)
+		if _, err := runGit(gitRoot, args...); err != nil {
+			return nil, fmt.Errorf("failed to add the changes of commit hooks to the commit: %w", err)
+		}
+	}
+
+	args := []string{"diff", "--name-only", "-z", staged, "HEAD"}
+	if specs != nil {
+		args = append(append(args, "--"), specs...)
+	}
+	output, err = runGit(gitRoot, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare the commit with the index: %w", err)
+	}
+	paths := splitNul(string(output))
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	output, err = runGit(gitRoot, append([]string{"diff", staged, "HEAD", "--"}, paths...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare the commit with the index: %w", err)
+	}
+	return &HookFixes{Paths: paths, Diff: string(output)}, nil
+}
+
+// AmendMessage replaces the message of the last commit, keeping its content
+// and author, without running the commit hooks again
+func AmendMessage(message string, signoff bool) error {
+	if strings.TrimSpace(message) == "" {
+		return fmt.Errorf("commit message cannot be empty")
+	}
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return err
+	}
+
+	// --only without paths leaves anything else in the index out of the commit
+	args := []string{"commit", "--amend", "--only", "--no-verify", "-m", message}
+	if signoff {
+		args = append(args, "--signoff")
+	}
+	if _, err := runGit(gitRoot, args...); err != nil {
+		return fmt.Errorf("failed to amend the commit message: %w", err)
+	}
+	return nil
+}
+
+// unstagedPaths lists the tracked files matching specs whose worktree
+// content differs from the index
+func unstagedPaths(gitRoot string, specs []string) ([]string, error) {
+	args := []string{"diff", "--name-only", "-z"}
+	if specs != nil {
+		args = append(append(args, "--"), specs...)
+	}
+	output, err := runGit(gitRoot, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unstaged changes: %w", err)
+	}
+	return splitNul(string(output)), nil
+}
+
+// splitNul splits the NUL-terminated output of a -z git command
+func splitNul(output string) []string {
+	var items []string
+	for _, item := range strings.Split(output, "\x00") {
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,176 @@
+package git
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// LockFileName is the file in the git directory that auto-git holds while it
+// stages and commits, so that two runs don't race each other
+const LockFileName = "auto-git.lock"
+
+// StaleLockAge is how old a lock may get before it is taken over even when
+// the process holding it cannot be checked, e.g. on another host
+const StaleLockAge = time.Hour
+
+// ErrLocked is returned by AcquireLock while another auto-git run holds the
+// repository
+var ErrLocked = errors.New("another auto-git run is in progress in this repository")
+
+// lockOwner is written to the lock file to tell whether its holder still runs
+type lockOwner struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Started time.Time `json:"started"`
+}
+
+// Lock is a held repository lock
+type Lock struct {
+	path string
+}
+
+// AcquireLock takes the repository lock in the git directory. A lock left
+// behind by a process that no longer runs on this host, or older than
+// StaleLockAge, is taken over; any other held lock returns ErrLocked.
+func AcquireLock() (*Lock, error) {
+	dir, err := gitDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, LockFileName)
+
+	host, _ := os.Hostname()
+	owner, err := json.Marshal(lockOwner{PID: os.Getpid(), Host: host, Started: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	owner = append(owner, '\n')
+
+	// The owner is written in full before the lock appears: linking the
+	// temporary file fails if the lock exists, so only one run can create it
+	tmp, err := os.CreateTemp(dir, LockFileName+".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(owner)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+
+	// Further attempts follow the removal of a stale lock
+	for attempt := 0; attempt < 3; attempt++ {
+		err := os.Link(tmp.Name(), path)
+		if err == nil {
+			// Another run can't have replaced the lock, but make sure
+			if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, owner) {
+				return nil, fmt.Errorf("%w; the lock %s was replaced while being taken", ErrLocked, path)
+			}
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create %s: %w", path, err)
+		}
+
+		holder, data, stale := inspectLock(path, host)
+		if !stale {
+			if holder != nil {
+				return nil, fmt.Errorf("%w (pid %d on %s since %s); remove %s if it is no longer running",
+					ErrLocked, holder.PID, holder.Host, holder.Started.Local().Format(time.DateTime), path)
+			}
+			return nil, fmt.Errorf("%w; remove %s if it is no longer running", ErrLocked, path)
+		}
+		if data != nil {
+			if err := removeStaleLock(path, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w; remove %s if it is no longer running", ErrLocked, path)
+}
+
+// removeStaleLock removes the lock at path if it still holds stale, the
+// content it was judged stale by. Another run that judged the same lock
+// stale may already have replaced it with its own, fresh lock; moving the
+// lock aside before comparing makes sure that one is put back rather than
+// removed.
+func removeStaleLock(path string, stale []byte) error {
+	aside := fmt.Sprintf("%s.stale.%d", path, os.Getpid())
+	if err := os.Rename(path, aside); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove stale %s: %w", path, err)
+	}
+	defer os.Remove(aside)
+	data, err := os.ReadFile(aside)
+	if err != nil {
+		return fmt.Errorf("failed to remove stale %s: %w", path, err)
+	}
+	if !bytes.Equal(data, stale) {
+		// Linking fails if yet another run created the lock in the meantime,
+		// which then holds it
+		os.Link(aside, path)
+	}
+	return nil
+}
+
+// Release removes the lock
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// inspectLock reads the owner of the lock at path and reports whether the
+// lock is stale, returning its content as read. A lock that can't be parsed,
+// e.g. one written by an older version that is still writing it, is only
+// stale once it is older than StaleLockAge. A lock released in the meantime
+// is stale with no content.
+func inspectLock(path, host string) (*lockOwner, []byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, true
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, true
+	}
+	var owner lockOwner
+	if json.Unmarshal(data, &owner) != nil || owner.PID <= 0 {
+		return nil, data, time.Since(info.ModTime()) > StaleLockAge
+	}
+	if time.Since(owner.Started) > StaleLockAge {
+		return &owner, data, true
+	}
+	if owner.Host == host && !processRunning(owner.PID) {
+		return &owner, data, true
+	}
+	return &owner, data, false
+}
+
+// processRunning reports whether a process with pid exists on this host
+func processRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess opens the process, which fails once it has exited
+		p.Release()
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,142 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MergeState describes a merge that is waiting to be committed
+type MergeState struct {
+	// Subject is the first line git proposes in MERGE_MSG, e.g.
+	// "Merge branch 'feature' into main"
+	Subject string
+	// Commits are the subjects of the commits being merged, oldest first
+	Commits []string
+	// Conflicts are the files git reported as conflicting
+	Conflicts []string
+	// Resolutions say how each resolved conflict was settled, keyed by path
+	Resolutions map[string]Resolution
+	// Unresolved are the files that still have unmerged entries in the index
+	Unresolved []string
+}
+
+// Resolution is how a conflicting file was resolved
+type Resolution string
+
+const (
+	// ResolvedOurs kept the version of the current branch
+	ResolvedOurs Resolution = "ours"
+	// ResolvedTheirs took the version of the merged branch
+	ResolvedTheirs Resolution = "theirs"
+	// ResolvedCombined is an edit matching neither side
+	ResolvedCombined Resolution = "combined"
+	// ResolvedDeleted removed the file
+	ResolvedDeleted Resolution = "deleted"
+)
+
+// CurrentMerge returns the state of an in-progress merge, or nil when
+// MERGE_HEAD does not exist
+func CurrentMerge() (*MergeState, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := runGit(gitRoot, "rev-parse", "-q", "--verify", "MERGE_HEAD"); err != nil {
+		return nil, nil
+	}
+
+	state := &MergeState{}
+	if output, err := runGit(gitRoot, "rev-parse", "--git-path", "MERGE_MSG"); err == nil {
+		// The path is relative to the directory git ran in
+		path := strings.TrimSpace(string(output))
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(gitRoot, path)
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			state.Subject, state.Conflicts = parseMergeMsg(string(data))
+		}
+	}
+	if state.Subject == "" {
+		state.Subject = "Merge commit"
+	}
+
+	output, err := runGit(gitRoot, "log", "--reverse", "--no-merges", "--format=%s", "HEAD..MERGE_HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merged commits: %w", err)
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			state.Commits = append(state.Commits, line)
+		}
+	}
+
+	output, err = runGit(gitRoot, "diff", "--name-only", "-z", "--diff-filter=U")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unmerged files: %w", err)
+	}
+	state.Unresolved = splitNul(string(output))
+
+	unresolved := map[string]bool{}
+	for _, path := range state.Unresolved {
+		unresolved[path] = true
+	}
+	for _, path := range state.Conflicts {
+		if unresolved[path] {
+			continue
+		}
+		if state.Resolutions == nil {
+			state.Resolutions = map[string]Resolution{}
+		}
+		state.Resolutions[path] = resolutionOf(gitRoot, path)
+	}
+	return state, nil
+}
+
+// resolutionOf compares the staged version of a resolved conflict with both
+// sides of the merge
+func resolutionOf(gitRoot, path string) Resolution {
+	staged := blobID(gitRoot, ":"+path)
+	switch staged {
+	case "":
+		return ResolvedDeleted
+	case blobID(gitRoot, "HEAD:"+path):
+		return ResolvedOurs
+	case blobID(gitRoot, "MERGE_HEAD:"+path):
+		return ResolvedTheirs
+	default:
+		return ResolvedCombined
+	}
+}
+
+// blobID returns the object rev names, or "" when it does not exist
+func blobID(gitRoot, rev string) string {
+	output, err := runGit(gitRoot, "rev-parse", "-q", "--verify", rev)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// parseMergeMsg returns the subject of a MERGE_MSG file and the files listed
+// in its "# Conflicts:" section
+func parseMergeMsg(msg string) (string, []string) {
+	var subject string
+	var conflicts []string
+	inConflicts := false
+	for _, line := range strings.Split(msg, "\n") {
+		switch {
+		case subject == "" && strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "#"):
+			subject = strings.TrimSpace(line)
+		case strings.HasPrefix(line, "# Conflicts:"):
+			inConflicts = true
+		case inConflicts && strings.HasPrefix(line, "#\t"):
+			conflicts = append(conflicts, strings.TrimSpace(strings.TrimPrefix(line, "#\t")))
+		case inConflicts && strings.TrimSpace(strings.TrimPrefix(line, "#")) != "":
+			inConflicts = false
+		}
+	}
+	return subject, conflicts
+}
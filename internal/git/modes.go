@@ -0,0 +1,67 @@
+package git
+
+import "strings"
+
+// File modes git records
+const (
+	modeFile       = "100644"
+	modeExecutable = "100755"
+	modeSymlink    = "120000"
+)
+
+// markModeChanges fills in the mode changes and symlink targets that the
+// extended header lines of patch show, which numstat counts as 0 lines
+func markModeChanges(files []FileChange, patch string) {
+	if len(files) == 0 || patch == "" {
+		return
+	}
+	index := map[string]int{}
+	for i, f := range files {
+		index[f.Path] = i
+	}
+	for _, section := range splitFilePatches(patch) {
+		header, _, _ := strings.Cut(section, "\n")
+		i, ok := index[pathFromDiffHeader(header)]
+		if !ok {
+			continue
+		}
+		f := &files[i]
+		symlink := false
+		for _, line := range strings.Split(section, "\n") {
+			switch {
+			case strings.HasPrefix(line, "old mode "), strings.HasPrefix(line, "deleted file mode "):
+				f.OldMode = line[strings.LastIndexByte(line, ' ')+1:]
+			case strings.HasPrefix(line, "new mode "), strings.HasPrefix(line, "new file mode "):
+				f.NewMode = line[strings.LastIndexByte(line, ' ')+1:]
+				symlink = f.NewMode == modeSymlink
+			case strings.HasPrefix(line, "index "):
+				// "index 1a2b..3c4d 120000" when the mode didn't change
+				symlink = symlink || strings.HasSuffix(line, " "+modeSymlink)
+			case symlink && strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++"):
+				// A symlink's content is its target
+				f.LinkTarget = line[1:]
+			}
+		}
+	}
+}
+
+// ModeNote describes a change of the file's mode or symlink target, e.g.
+// "made executable" or "symlink to ../shared/config.yaml", or returns ""
+func (f FileChange) ModeNote() string {
+	switch {
+	case f.NewMode == modeSymlink && f.OldMode != "" && f.OldMode != modeSymlink:
+		return "replaced by a symlink to " + f.LinkTarget
+	case f.OldMode == modeSymlink && f.NewMode != "" && f.NewMode != modeSymlink:
+		return "symlink replaced by a file"
+	case f.LinkTarget != "":
+		return "symlink to " + f.LinkTarget
+	case f.OldMode == "" || f.NewMode == "" || f.OldMode == f.NewMode:
+		return ""
+	case f.OldMode == modeFile && f.NewMode == modeExecutable:
+		return "made executable"
+	case f.OldMode == modeExecutable && f.NewMode == modeFile:
+		return "no longer executable"
+	default:
+		return "mode " + f.OldMode + " → " + f.NewMode
+	}
+}
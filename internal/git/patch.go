@@ -0,0 +1,198 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParsePatch builds a change set from a unified diff (as produced by git diff
+// or diff -u), so patches that are not in a local repository can be described
+func ParsePatch(patch string) (*Changes, error) {
+	var files []FileChange
+	var current *FileChange
+	// Remaining old/new line counts of the hunk being read
+	oldLeft, newLeft := 0, 0
+
+	flush := func() {
+		if current != nil && current.Path != "" {
+			current.Type = determineChangeType(current.Additions, current.Deletions)
+			files = append(files, *current)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(patch, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		inHunk := oldLeft > 0 || newLeft > 0
+
+		switch {
+		case inHunk && strings.HasPrefix(line, "+"):
+			current.Additions++
+			newLeft--
+		case inHunk && strings.HasPrefix(line, "-"):
+			current.Deletions++
+			oldLeft--
+		case inHunk && strings.HasPrefix(line, "\\"):
+			// "\ No newline at end of file"
+		case inHunk:
+			oldLeft--
+			newLeft--
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			current = &FileChange{Path: pathFromDiffHeader(line)}
+		case strings.HasPrefix(line, "--- "):
+			// Plain diff -u output has no "diff --git" header, so a new
+			// ---/+++ pair after a finished hunk starts the next file
+			if current != nil && (current.Additions > 0 || current.Deletions > 0) {
+				flush()
+			}
+			if current == nil {
+				current = &FileChange{}
+			}
+			if p := stripPatchPrefix(strings.TrimPrefix(line, "--- ")); p != "" && current.Path == "" {
+				current.Path = p
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if current == nil {
+				current = &FileChange{}
+			}
+			if p := stripPatchPrefix(strings.TrimPrefix(line, "+++ ")); p != "" {
+				current.Path = p
+			}
+		case strings.HasPrefix(line, "@@"):
+			if current == nil {
+				current = &FileChange{}
+			}
+			oldLeft, newLeft = parseHunkHeader(line)
+		}
+	}
+	flush()
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file changes found in patch")
+	}
+	markModeChanges(files, patch)
+
+	return &Changes{
+		Staged:  files,
+		Summary: buildSummary(files, nil),
+	}, nil
+}
+
+// FilePatch is the part of a diff that changes a single file
+type FilePatch struct {
+	Path string
+	// Staged and Unstaged tell which side of a diff returned by Collect the
+	// patch belongs to; both are false for other diffs
+	Staged   bool
+	Unstaged bool
+	// Patch starts with the "diff --git" line
+	Patch string
+}
+
+// SplitPatch splits a diff, such as the one returned by Collect, into one
+// FilePatch per file. Text before the first file is dropped.
+func SplitPatch(diff string) []FilePatch {
+	var patches []FilePatch
+	staged, unstaged := false, false
+	// start is the offset of the last patch while it is being read, else -1
+	start := -1
+	closePatch := func(end int) {
+		if start >= 0 {
+			patches[len(patches)-1].Patch = diff[start:end]
+			start = -1
+		}
+	}
+	for offset := 0; offset < len(diff); {
+		lineEnd := len(diff)
+		if i := strings.IndexByte(diff[offset:], '\n'); i >= 0 {
+			lineEnd = offset + i + 1
+		}
+		line := strings.TrimRight(diff[offset:lineEnd], "\r\n")
+		switch {
+		case line == stagedDiffHeader:
+			closePatch(offset)
+			staged, unstaged = true, false
+		case line == unstagedDiffHeader:
+			closePatch(offset)
+			staged, unstaged = false, true
+		case strings.HasPrefix(line, "diff --git "):
+			closePatch(offset)
+			patches = append(patches, FilePatch{Path: pathFromDiffHeader(line), Staged: staged, Unstaged: unstaged})
+			start = offset
+		}
+		offset = lineEnd
+	}
+	closePatch(len(diff))
+	return patches
+}
+
+// parseHunkHeader returns the old and new line counts of a "@@ -a,b +c,d @@" header.
+// Omitted counts default to 1, as in the unified diff format. The function
+// context git prints after the closing "@@" is ignored.
+func parseHunkHeader(line string) (int, int) {
+	oldCount, newCount := 1, 1
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[0] != "@@" {
+		return oldCount, newCount
+	}
+	for _, field := range fields[1:3] {
+		if len(field) < 2 || (field[0] != '-' && field[0] != '+') {
+			continue
+		}
+		count := 1
+		if idx := strings.Index(field, ","); idx >= 0 {
+			fmt.Sscanf(field[idx+1:], "%d", &count)
+		}
+		if field[0] == '-' {
+			oldCount = count
+		} else {
+			newCount = count
+		}
+	}
+	return oldCount, newCount
+}
+
+// pathFromDiffHeader extracts the destination path from a "diff --git a/x b/y" line
+func pathFromDiffHeader(line string) string {
+	rest := strings.TrimSuffix(strings.TrimPrefix(line, "diff --git "), "\r")
+	// Paths with special characters, such as backslashes, are C-quoted
+	if strings.HasSuffix(rest, `"`) {
+		if idx := strings.LastIndex(rest, ` "b/`); idx >= 0 {
+			return strings.TrimPrefix(unquotePath(rest[idx+1:]), "b/")
+		}
+	}
+	if idx := strings.LastIndex(rest, " b/"); idx >= 0 {
+		return rest[idx+len(" b/"):]
+	}
+	return ""
+}
+
+// unquotePath decodes a path that git C-quoted because it contains special
+// characters (see core.quotePath); other paths are returned unchanged
+func unquotePath(path string) string {
+	if len(path) < 2 || path[0] != '"' || path[len(path)-1] != '"' {
+		return path
+	}
+	if unquoted, err := strconv.Unquote(path); err == nil {
+		return unquoted
+	}
+	return path
+}
+
+// stripPatchPrefix removes the a/ or b/ prefix and any trailing timestamp from a
+// ---/+++ header path. It returns "" for /dev/null.
+func stripPatchPrefix(path string) string {
+	if idx := strings.Index(path, "\t"); idx >= 0 {
+		path = path[:idx]
+	}
+	path = unquotePath(strings.TrimSpace(path))
+	if path == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		return path[2:]
+	}
+	return path
+}
@@ -0,0 +1,48 @@
+package git
+
+import "strings"
+
+// PathFilter restricts which changed files are collected, staged, and
+// committed. Patterns are globs relative to the repository root, where "**"
+// matches across directories.
+type PathFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// Empty reports whether the filter matches every path
+func (f PathFilter) Empty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Pathspecs converts the filter to git pathspecs, or nil when it is empty
+func (f PathFilter) Pathspecs() []string {
+	if f.Empty() {
+		return nil
+	}
+
+	var specs []string
+	for _, p := range f.Include {
+		specs = append(specs, ":(glob)"+p)
+	}
+	if len(specs) == 0 {
+		// Exclusions need something to exclude from
+		specs = append(specs, ".")
+	}
+	for _, p := range f.Exclude {
+		specs = append(specs, ":(exclude,glob)"+p)
+	}
+	return specs
+}
+
+// ExactPaths returns a filter matching exactly paths, escaping any glob
+// characters in them
+func ExactPaths(paths []string) PathFilter {
+	include := make([]string, len(paths))
+	for i, p := range paths {
+		include[i] = globEscaper.Replace(p)
+	}
+	return PathFilter{Include: include}
+}
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
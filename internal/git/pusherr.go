@@ -0,0 +1,59 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Well-known reasons for a push to fail, wrapped by the errors of Push
+var (
+	ErrPushRejected    = errors.New("the remote branch has commits that are not in the local branch")
+	ErrNoUpstream      = errors.New("the current branch has no upstream branch")
+	ErrPushAuth        = errors.New("the remote refused the credentials")
+	ErrProtectedBranch = errors.New("the remote branch is protected")
+)
+
+// pushFailures match git's and the hosting services' messages for each
+// reason; a protected branch is checked first as it is reported as rejected
+var pushFailures = []struct {
+	err     error
+	pattern *regexp.Regexp
+}{
+	{ErrProtectedBranch, regexp.MustCompile(`(?i)protected branch|GH006|not allowed to (force )?push|pre-receive hook declined`)},
+	{ErrNoUpstream, regexp.MustCompile(`(?i)has no upstream branch`)},
+	{ErrPushAuth, regexp.MustCompile(`(?i)authentication failed|permission denied|could not read (username|password)|terminal prompts disabled|invalid username or password|returned error: 40[13]`)},
+	{ErrPushRejected, regexp.MustCompile(`(?i)\[rejected\].*\((non-fast-forward|fetch first)\)|updates were rejected because`)},
+}
+
+// pushError describes a failed push from git's stderr. With showOutput the
+// last lines of it are included; otherwise git already printed them.
+func pushError(err error, stderr string, showOutput bool) error {
+	output := strings.TrimSpace(stderr)
+	for _, f := range pushFailures {
+		if f.pattern.MatchString(output) {
+			err = f.err
+			break
+		}
+	}
+	if showOutput && output != "" {
+		return fmt.Errorf("failed to push: %w: %s", err, lastLines(output, 3))
+	}
+	return fmt.Errorf("failed to push: %w", err)
+}
+
+// lastLines returns the last n lines of s, leaving out git's advice, which
+// the hint auto-git prints replaces
+func lastLines(s string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if !strings.HasPrefix(line, "hint:") {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
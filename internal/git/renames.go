@@ -0,0 +1,188 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"auto-git/internal/logging"
+)
+
+const (
+	// minRenameSimilarity is the share of lines, in percent, a deleted and an
+	// untracked file must have in common to be shown as a rename, as git's
+	// default for rename detection
+	minRenameSimilarity = 50
+	// maxRenameCandidates bounds the untracked files compared with deletions
+	maxRenameCandidates = 200
+	// maxRenameFileSize skips larger files, which are unlikely to be moved
+	// source files and slow to compare
+	maxRenameFileSize = 1 << 20
+)
+
+// pairRenames finds untracked files whose content closely matches a file
+// deleted in the worktree and presents each pair as a rename, as git will
+// record it once both are staged. files and patch are the worktree diff.
+func pairRenames(gitRoot string, files []FileChange, patch string, filter PathFilter) ([]FileChange, string) {
+	deleted := map[string]int{}
+	for i, f := range files {
+		if f.Type == ChangeTypeDeleted && f.OldPath == "" {
+			deleted[f.Path] = i
+		}
+	}
+	if len(deleted) == 0 {
+		return files, patch
+	}
+
+	args := []string{"ls-files", "--others", "--exclude-standard", "-z"}
+	if specs := filter.Pathspecs(); specs != nil {
+		args = append(append(args, "--"), specs...)
+	}
+	output, err := runGit(gitRoot, args...)
+	if err != nil {
+		logging.Debug("failed to list untracked files", "error", err)
+		return files, patch
+	}
+	untracked := splitNul(string(output))
+	if len(untracked) == 0 {
+		return files, patch
+	}
+	if len(untracked) > maxRenameCandidates {
+		untracked = untracked[:maxRenameCandidates]
+	}
+
+	candidates := map[string][]byte{}
+	for _, path := range untracked {
+		if data, ok := readComparable(filepath.Join(gitRoot, filepath.FromSlash(path))); ok {
+			candidates[path] = data
+		}
+	}
+
+	sections := splitFilePatches(patch)
+	for i, section := range sections {
+		header, _, _ := strings.Cut(section, "\n")
+		oldPath := pathFromDiffHeader(header)
+		index, ok := deleted[oldPath]
+		if !ok || !strings.Contains(section, "\ndeleted file mode ") {
+			continue
+		}
+		old, err := runGit(gitRoot, "cat-file", "blob", ":"+oldPath)
+		if err != nil || len(old) > maxRenameFileSize || bytes.IndexByte(old, 0) >= 0 {
+			continue
+		}
+
+		best, bestScore := "", 0
+		for path, data := range candidates {
+			if score := similarity(old, data); score > bestScore || (score == bestScore && path < best) {
+				best, bestScore = path, score
+			}
+		}
+		if bestScore < minRenameSimilarity {
+			continue
+		}
+		renamed, additions, deletions, err := renamePatch(gitRoot, oldPath, best, old, bestScore)
+		if err != nil {
+			logging.Debug("failed to diff a renamed file", "from", oldPath, "to", best, "error", err)
+			continue
+		}
+		delete(candidates, best)
+		sections[i] = renamed
+		files[index] = FileChange{
+			Path:      best,
+			OldPath:   oldPath,
+			Type:      ChangeTypeRenamed,
+			Additions: additions,
+			Deletions: deletions,
+		}
+		logging.Debug("paired an untracked file with a deletion", "from", oldPath, "to", best, "similarity", bestScore)
+	}
+	return files, strings.Join(sections, "")
+}
+
+// readComparable reads a text file that is small enough to compare
+func readComparable(path string) ([]byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxRenameFileSize {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data, 0) >= 0 {
+		return nil, false
+	}
+	return data, true
+}
+
+// similarity returns the share of lines, in percent, that a and b have in
+// common, counting repeated lines as often as both contain them
+func similarity(a, b []byte) int {
+	linesA, linesB := bytes.Split(a, []byte("\n")), bytes.Split(b, []byte("\n"))
+	if len(a) == 0 && len(b) == 0 {
+		return 100
+	}
+	counts := map[string]int{}
+	for _, line := range linesA {
+		counts[string(bytes.TrimRight(line, "\r"))]++
+	}
+	common := 0
+	for _, line := range linesB {
+		key := string(bytes.TrimRight(line, "\r"))
+		if counts[key] > 0 {
+			counts[key]--
+			common++
+		}
+	}
+	return 200 * common / (len(linesA) + len(linesB))
+}
+
+// renamePatch builds the patch git shows for a rename of oldPath, whose
+// content was old, to newPath, and counts its added and deleted lines
+func renamePatch(gitRoot, oldPath, newPath string, old []byte, score int) (string, int, int, error) {
+	tmp, err := os.CreateTemp("", "auto-git-rename-*")
+	if err != nil {
+		return "", 0, 0, err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(old)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	// Exit status 1 only means that the files differ
+	output, err := runGit(gitRoot, "diff", "--no-index", "--no-color", "--", tmp.Name(), newPath)
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return "", 0, 0, err
+	}
+
+	var hunks []string
+	additions, deletions := 0, 0
+	inHunks := false
+	for _, line := range strings.SplitAfter(string(output), "\n") {
+		if strings.HasPrefix(line, "@@") {
+			inHunks = true
+		}
+		if !inHunks {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			additions++
+		case strings.HasPrefix(line, "-"):
+			deletions++
+		}
+		hunks = append(hunks, line)
+	}
+
+	header := fmt.Sprintf("diff --git a/%s b/%s\nsimilarity index %d%%\nrename from %s\nrename to %s\n", oldPath, newPath, score, oldPath, newPath)
+	if len(hunks) == 0 {
+		return header, 0, 0, nil
+	}
+	return header + fmt.Sprintf("--- a/%s\n+++ b/%s\n", oldPath, newPath) + strings.Join(hunks, ""), additions, deletions, nil
+}
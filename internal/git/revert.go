@@ -0,0 +1,106 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RevertNoCommit applies the inverse of commit to the index and worktree
+// without committing (git revert --no-commit). If the revert conflicts it is
+// aborted and the conflicting files are reported.
+func RevertNoCommit(commit string) error {
+	return applyNoCommit("revert", "reverting", commit)
+}
+
+// AbortRevert undoes a revert started with RevertNoCommit
+func AbortRevert() error {
+	return abortSequencer("revert")
+}
+
+// CherryPickNoCommit applies commit to the index and worktree without
+// committing (git cherry-pick --no-commit). If it conflicts it is aborted and
+// the conflicting files are reported.
+func CherryPickNoCommit(commit string) error {
+	return applyNoCommit("cherry-pick", "cherry-picking", commit)
+}
+
+// AbortCherryPick undoes a cherry-pick started with CherryPickNoCommit
+func AbortCherryPick() error {
+	return abortSequencer("cherry-pick")
+}
+
+// applyNoCommit runs git revert or git cherry-pick with --no-commit on a
+// clean worktree, aborting on conflicts
+func applyNoCommit(op, action, commit string) error {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return err
+	}
+
+	if err := checkClean(gitRoot, action); err != nil {
+		return err
+	}
+	parents, err := runGit(gitRoot, "rev-list", "--parents", "-n", "1", commit)
+	if err != nil {
+		return fmt.Errorf("failed to read commit %.12s: %w", commit, err)
+	}
+	if len(strings.Fields(string(parents))) > 2 {
+		return fmt.Errorf("cannot %s merge commit %.12s", op, commit)
+	}
+
+	if _, err := runGit(gitRoot, op, "--no-commit", commit); err != nil {
+		conflicts, _ := runGit(gitRoot, "diff", "--name-only", "-z", "--diff-filter=U")
+		runGit(gitRoot, op, "--abort")
+		if files := splitNul(string(conflicts)); len(files) > 0 {
+			return fmt.Errorf("%s %.12s conflicts with changes in %s; resolve it manually with git %s", action, commit, strings.Join(files, ", "), op)
+		}
+		return fmt.Errorf("failed to %s %.12s: %w", op, commit, err)
+	}
+	return nil
+}
+
+func abortSequencer(op string) error {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return err
+	}
+
+	if _, err := runGit(gitRoot, op, "--abort"); err == nil {
+		return nil
+	}
+	// A --no-commit run without conflicts leaves no sequencer state to abort;
+	// the worktree was clean before, so resetting the applied changes is enough
+	if _, err := runGit(gitRoot, "reset", "--merge"); err != nil {
+		return fmt.Errorf("failed to abort %s: %w", op, err)
+	}
+	return nil
+}
+
+// AuthorOf returns the author, as "Name <email>", and author date of commit
+func AuthorOf(commit string) (string, string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return "", "", err
+	}
+
+	output, err := runGit(gitRoot, "log", "-1", "--format=%an <%ae>%x00%aI", commit)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read commit author: %w", err)
+	}
+	author, date, _ := strings.Cut(strings.TrimSpace(string(output)), "\x00")
+	return author, date, nil
+}
+
+// CurrentBranch returns the name of the checked-out branch, or "" when HEAD is detached
+func CurrentBranch() string {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return ""
+	}
+
+	output, err := runGit(gitRoot, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,137 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RewordRange lists the commits in revRange, oldest first, and the commit
+// they are based on. The commits must be unpushed, non-merge ancestors of
+// HEAD; commits already on the upstream branch are only allowed with
+// allowPushed.
+func RewordRange(revRange string, allowPushed bool) ([]string, string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return nil, "", err
+	}
+
+	output, err := runGit(gitRoot, "rev-list", "--reverse", revRange)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid range %q: %w", revRange, err)
+	}
+	commits := strings.Fields(string(output))
+	if len(commits) == 0 {
+		return nil, "", fmt.Errorf("range %q contains no commits", revRange)
+	}
+
+	for _, c := range commits {
+		if _, err := runGit(gitRoot, "merge-base", "--is-ancestor", c, "HEAD"); err != nil {
+			return nil, "", fmt.Errorf("commit %.12s is not on the current branch", c)
+		}
+	}
+
+	output, err = runGit(gitRoot, "rev-parse", "--verify", "--quiet", commits[0]+"^")
+	if err != nil {
+		return nil, "", shallowError(gitRoot, fmt.Errorf("cannot reword the root commit"))
+	}
+	base := strings.TrimSpace(string(output))
+
+	merges, err := runGit(gitRoot, "rev-list", "--merges", "--count", base+"..HEAD")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to inspect commits: %w", err)
+	}
+	if strings.TrimSpace(string(merges)) != "0" {
+		return nil, "", fmt.Errorf("cannot reword across merge commits")
+	}
+
+	if !allowPushed {
+		if _, err := runGit(gitRoot, "rev-parse", "--verify", "--quiet", "@{upstream}"); err == nil {
+			if _, err := runGit(gitRoot, "merge-base", "--is-ancestor", commits[0], "@{upstream}"); err == nil {
+				return nil, "", fmt.Errorf("%w: %.12s is on the upstream branch", ErrAlreadyPushed, commits[0])
+			}
+		}
+	}
+
+	return commits, base, nil
+}
+
+// CommitMessageOf returns the full message of commit
+func CommitMessageOf(commit string) (string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := runGit(gitRoot, "log", "-1", "--format=%B", commit)
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit %.12s: %w", commit, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Reword replaces the messages of the commits in messages, keyed by full
+// hash, by rebasing the branch onto base with a generated todo list. Authors,
+// dates and trees are kept. On failure the rebase is aborted.
+func Reword(base string, messages map[string]string) error {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return err
+	}
+
+	if err := checkClean(gitRoot, "rewording"); err != nil {
+		return err
+	}
+
+	output, err := runGit(gitRoot, "rev-list", "--reverse", base+"..HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	tmp, err := os.MkdirTemp("", "auto-git-reword-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	var todo strings.Builder
+	for _, commit := range strings.Fields(string(output)) {
+		fmt.Fprintf(&todo, "pick %s\n", commit)
+		message, ok := messages[commit]
+		if !ok {
+			continue
+		}
+		file := filepath.Join(tmp, commit+".txt")
+		if err := os.WriteFile(file, []byte(message+"\n"), 0o600); err != nil {
+			return fmt.Errorf("failed to write message: %w", err)
+		}
+		fmt.Fprintf(&todo, "exec git commit --amend --only --no-verify --allow-empty --cleanup=strip -F %s\n", ShellQuote(filepath.ToSlash(file)))
+	}
+
+	todoFile := filepath.Join(tmp, "todo")
+	if err := os.WriteFile(todoFile, []byte(todo.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to write rebase todo: %w", err)
+	}
+
+	// git runs the sequence editor through the shell with the todo path appended
+	env := []string{"GIT_SEQUENCE_EDITOR=cp " + ShellQuote(filepath.ToSlash(todoFile))}
+	if _, err := runGitEnv(gitRoot, env, "rebase", "-i", base); err != nil {
+		runGit(gitRoot, "rebase", "--abort")
+		return fmt.Errorf("failed to reword commits: %w", err)
+	}
+	return nil
+}
+
+// checkClean fails when tracked files have uncommitted changes, which would
+// get in the way of action
+func checkClean(gitRoot, action string) error {
+	output, err := runGit(gitRoot, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return fmt.Errorf("failed to read status: %w", err)
+	}
+	if len(strings.TrimSpace(string(output))) > 0 {
+		return fmt.Errorf("uncommitted changes; commit or stash them before %s", action)
+	}
+	return nil
+}
@@ -1,15 +1,21 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"auto-git/internal/symbols"
 
 	"github.com/fatih/color"
 )
 
+// ErrNoChanges is returned when the repository has nothing to commit
+var ErrNoChanges = errors.New("no uncommitted changes found")
+
 type ChangeType string
 
 const (
@@ -20,16 +26,42 @@ const (
 )
 
 type FileChange struct {
-	Path      string
+	Path string
+	// OldPath is the previous path of a renamed file
+	OldPath   string
 	Type      ChangeType
 	Additions int
 	Deletions int
+	// LineEndingsOnly is set when only CRLF/LF line endings changed
+	LineEndingsOnly bool
+	// WhitespaceOnly is set when the diff is empty ignoring whitespace and
+	// blank lines, e.g. after running a formatter
+	WhitespaceOnly bool
+	// Vendored is set for third-party code, e.g. under vendor/ or
+	// node_modules/, whose diff is left out of the prompt
+	Vendored bool
+	// Generated is set for lockfiles and files carrying a generator comment
+	// or the linguist-generated attribute
+	Generated bool
+	// Symbols are the functions and types the change adds, modifies or
+	// removes, for languages the symbols package supports
+	Symbols []symbols.Change
+	// OldMode and NewMode are the file modes from the patch header, e.g.
+	// "100644" and "100755" for a script made executable; see ModeNote
+	OldMode string
+	NewMode string
+	// LinkTarget is the new target of a symbolic link
+	LinkTarget string
 }
 
 type Changes struct {
 	Staged   []FileChange
 	Unstaged []FileChange
-	Summary  string
+	// Summary lists the changes for the terminal, with colors
+	Summary string
+	// Context is extra text for the model about the changes, such as the
+	// package being committed or the commits being squashed
+	Context string
 }
 
 func IsGitRepo(dir string) (bool, error) {
@@ -70,68 +102,286 @@ func FindGitRoot(startDir string) (string, error) {
 	}
 }
 
-func GetChanges() (*Changes, error) {
+// Collect gathers the change summary and the diff content in one pass. The
+// index and the worktree are read concurrently, each with a single git diff
+// invocation that produces both numstat and patch output.
+func Collect() (*Changes, string, error) {
 	workDir, err := os.Getwd()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get working directory: %w", err)
+		return nil, "", fmt.Errorf("failed to get working directory: %w", err)
 	}
+	return CollectAt(workDir)
+}
+
+// CollectAt is like Collect for the repository containing dir
+func CollectAt(dir string) (*Changes, string, error) {
+	return CollectFiltered(dir, PathFilter{})
+}
+
+// CollectFiltered is like CollectAt but only reports paths matching filter
+func CollectFiltered(dir string, filter PathFilter) (*Changes, string, error) {
+	return collect(dir, filter, true)
+}
+
+// CollectStaged is like CollectFiltered but ignores the worktree, reporting
+// only what is already in the index
+func CollectStaged(dir string, filter PathFilter) (*Changes, string, error) {
+	return collect(dir, filter, false)
+}
 
-	gitRoot, err := FindGitRoot(workDir)
+func collect(dir string, filter PathFilter, worktree bool) (*Changes, string, error) {
+	gitRoot, err := FindGitRoot(dir)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	staged, err := getStagedChanges(gitRoot)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get staged changes: %w", err)
+	var staged, unstaged diffSide
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		staged = readDiff(gitRoot, true, filter)
+	}()
+	if worktree {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unstaged = readDiff(gitRoot, false, filter)
+		}()
 	}
+	wg.Wait()
 
-	unstaged, err := getUnstagedChanges(gitRoot)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get unstaged changes: %w", err)
+	if staged.err != nil {
+		return nil, "", fmt.Errorf("failed to get staged changes: %w", staged.err)
+	}
+	if unstaged.err != nil {
+		return nil, "", fmt.Errorf("failed to get unstaged changes: %w", unstaged.err)
 	}
 
-	if len(staged) == 0 && len(unstaged) == 0 {
-		return nil, fmt.Errorf("no uncommitted changes found")
+	if len(staged.files) == 0 && len(unstaged.files) == 0 {
+		return nil, "", ErrNoChanges
 	}
 
-	summary := buildSummary(staged, unstaged)
+	changes := &Changes{
+		Staged:   staged.files,
+		Unstaged: unstaged.files,
+		Summary:  buildSummary(staged.files, unstaged.files),
+	}
+
+	return changes, joinDiffs(staged.patch, unstaged.patch), nil
+}
 
-	return &Changes{
-		Staged:   staged,
-		Unstaged: unstaged,
-		Summary:  summary,
-	}, nil
+// diffSide is the parsed output of git diff for either the index or the worktree
+type diffSide struct {
+	files []FileChange
+	patch string
+	err   error
 }
 
-func getStagedChanges(gitRoot string) ([]FileChange, error) {
-	cmd := exec.Command("git", "diff", "--cached", "--numstat")
-	cmd.Dir = gitRoot
-	output, err := cmd.Output()
+// functionContext is set by SetFunctionContext
+var functionContext bool
+
+// SetFunctionContext makes the collected patches show the whole function
+// around each change (git diff --function-context) instead of three lines
+func SetFunctionContext(on bool) {
+	functionContext = on
+}
+
+// patchArgs returns the git diff options for the patches shown to the model
+func patchArgs() []string {
+	if functionContext {
+		return []string{"--patch", "--function-context"}
+	}
+	return []string{"--patch"}
+}
+
+func readDiff(gitRoot string, cached bool, filter PathFilter) diffSide {
+	args := append([]string{"diff", "--raw", "--numstat"}, patchArgs()...)
+	if cached {
+		args = append([]string{"diff", "--cached", "--raw", "--numstat"}, patchArgs()...)
+	}
+	if specs := filter.Pathspecs(); specs != nil {
+		args = append(append(args, "--"), specs...)
+	}
+
+	output, err := runGit(gitRoot, args...)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 0 {
-			return []FileChange{}, nil
+		return diffSide{err: fmt.Errorf("failed to run git %s: %w", strings.Join(args, " "), err)}
+	}
+
+	numstat, patch := splitNumstatPatch(string(output))
+	files, err := parseDiffOutput(numstat, cached)
+	if err == nil {
+		markModeChanges(files, patch)
+		files, patch = markFormattingChanges(gitRoot, args, files, patch)
+		files, patch = markGeneratedChanges(gitRoot, files, patch)
+		if cached {
+			markSymbolChanges(gitRoot, "HEAD", indexVersion, files)
+		} else {
+			// What was staged outside the cone is committed, but StagePaths
+			// doesn't add worktree changes there
+			if dirs := sparseCone(gitRoot); dirs != nil {
+				files, patch = keepInCone(files, patch, dirs)
+			}
+			// git only sees a moved file as renamed once both paths are staged
+			files, patch = pairRenames(gitRoot, files, patch, filter)
+			markSymbolChanges(gitRoot, indexVersion, worktreeVersion, files)
+		}
+	}
+	return diffSide{files: files, patch: patch, err: err}
+}
+
+// markFormattingChanges flags files whose diff disappears when line endings
+// or whitespace are ignored, and replaces their patch with a one-line note so
+// the model isn't shown a file full of noise lines. args is the git diff
+// command that produced files and patch.
+func markFormattingChanges(gitRoot string, args []string, files []FileChange, patch string) ([]FileChange, string) {
+	if strings.Contains(patch, "\r") {
+		eolOnly := unchangedIgnoring(gitRoot, args, files, "--ignore-cr-at-eol")
+		for i := range files {
+			files[i].LineEndingsOnly = eolOnly[files[i].Path]
 		}
-		return nil, fmt.Errorf("failed to run git diff --cached: %w", err)
+		patch = replaceFilePatches(patch, eolOnly, "Only line endings changed (CRLF/LF); the content is identical.")
 	}
 
-	return parseDiffOutput(string(output), true)
+	// Indentation is code in some languages, so only trailing whitespace is
+	// ignored in those
+	var loose, strict []FileChange
+	for _, f := range files {
+		if indentationSignificant(f.Path) {
+			strict = append(strict, f)
+		} else {
+			loose = append(loose, f)
+		}
+	}
+	whitespaceOnly := unchangedIgnoring(gitRoot, args, loose, "--ignore-all-space", "--ignore-blank-lines")
+	for path := range unchangedIgnoring(gitRoot, args, strict, "--ignore-space-at-eol") {
+		whitespaceOnly[path] = true
+	}
+	for i, f := range files {
+		if f.LineEndingsOnly {
+			delete(whitespaceOnly, f.Path)
+		}
+		files[i].WhitespaceOnly = whitespaceOnly[f.Path]
+	}
+	patch = replaceFilePatches(patch, whitespaceOnly, "Only whitespace changed (formatting only); no code or text changed.")
+	return files, patch
 }
 
-func getUnstagedChanges(gitRoot string) ([]FileChange, error) {
-	cmd := exec.Command("git", "diff", "--numstat")
-	cmd.Dir = gitRoot
-	output, err := cmd.Output()
+// indentationSignificant reports whether indentation changes the meaning of
+// path, as in Python, YAML and Makefiles
+func indentationSignificant(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	switch name {
+	case "makefile", "gnumakefile":
+		return true
+	}
+	switch filepath.Ext(name) {
+	case ".py", ".pyi", ".pyw", ".yaml", ".yml", ".mk", ".coffee", ".sass", ".haml", ".pug", ".nim":
+		return true
+	}
+	return false
+}
+
+// unchangedIgnoring returns the paths among files that have changes, but none
+// when the diff in args is rerun with the extra flags
+func unchangedIgnoring(gitRoot string, args []string, files []FileChange, flags ...string) map[string]bool {
+	unchanged := map[string]bool{}
+	for _, f := range files {
+		if f.Additions > 0 || f.Deletions > 0 {
+			unchanged[f.Path] = true
+		}
+	}
+	if len(unchanged) == 0 {
+		return unchanged
+	}
+
+	ignoring := append([]string{"diff"}, flags...)
+	for _, arg := range args[1:] {
+		if arg != "--patch" {
+			ignoring = append(ignoring, arg)
+		}
+	}
+	output, err := runGit(gitRoot, ignoring...)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 0 {
-			return []FileChange{}, nil
+		return map[string]bool{}
+	}
+	changed, err := parseDiffOutput(string(output), false)
+	if err != nil {
+		return map[string]bool{}
+	}
+	for _, f := range changed {
+		if f.Additions > 0 || f.Deletions > 0 {
+			delete(unchanged, f.Path)
 		}
-		return nil, fmt.Errorf("failed to run git diff: %w", err)
 	}
+	return unchanged
+}
 
-	return parseDiffOutput(string(output), false)
+// replaceFilePatches replaces the patch of each file in paths with note
+func replaceFilePatches(patch string, paths map[string]bool, note string) string {
+	if len(paths) == 0 {
+		return patch
+	}
+	sections := splitFilePatches(patch)
+	for i, section := range sections {
+		header, _, _ := strings.Cut(section, "\n")
+		if path := pathFromDiffHeader(header); paths[path] {
+			sections[i] = header + "\n" + note + "\n"
+		}
+	}
+	return strings.Join(sections, "")
 }
 
+// splitFilePatches splits a git diff into one section per file, each starting
+// with its "diff --git" line
+func splitFilePatches(patch string) []string {
+	var sections []string
+	for {
+		next := strings.Index(patch[1:], "\ndiff --git ")
+		if next < 0 {
+			return append(sections, patch)
+		}
+		sections = append(sections, patch[:next+2])
+		patch = patch[next+2:]
+	}
+}
+
+// splitNumstatPatch separates the numstat block from the patch that follows it
+func splitNumstatPatch(output string) (string, string) {
+	if idx := strings.Index(output, "\n\ndiff --git "); idx >= 0 {
+		return output[:idx], output[idx+2:]
+	}
+	if strings.HasPrefix(output, "diff --git ") {
+		return "", output
+	}
+	return output, ""
+}
+
+// Headers that separate the index and worktree diffs returned by Collect
+const (
+	stagedDiffHeader   = "=== STAGED CHANGES ==="
+	unstagedDiffHeader = "=== UNSTAGED CHANGES ==="
+)
+
+func joinDiffs(stagedDiff, unstagedDiff string) string {
+	var parts []string
+	if stagedDiff != "" {
+		parts = append(parts, stagedDiffHeader)
+		parts = append(parts, stagedDiff)
+	}
+	if unstagedDiff != "" {
+		parts = append(parts, unstagedDiffHeader)
+		parts = append(parts, unstagedDiff)
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// parseDiffOutput parses the --numstat lines of git diff, preceded by its
+// --raw lines if it was run with both. The raw lines tell whether each file
+// was added, deleted or renamed; without them the type is guessed from the
+// line counts.
 func parseDiffOutput(output string, staged bool) ([]FileChange, error) {
 	if output == "" {
 		return []FileChange{}, nil
@@ -139,15 +389,23 @@ func parseDiffOutput(output string, staged bool) ([]FileChange, error) {
 
 	lines := strings.Split(strings.TrimSpace(output), "\n")
 	changes := make([]FileChange, 0, len(lines))
+	var raw []FileChange
 
 	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			if f, ok := parseRawLine(line); ok {
+				raw = append(raw, f)
+			}
 			continue
 		}
 
-		parts := strings.Fields(line)
-		if len(parts) < 2 {
+		// "<additions>\t<deletions>\t<path>"; paths may contain spaces
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
 			continue
 		}
 
@@ -155,10 +413,7 @@ func parseDiffOutput(output string, staged bool) ([]FileChange, error) {
 		fmt.Sscanf(parts[0], "%d", &additions)
 		fmt.Sscanf(parts[1], "%d", &deletions)
 
-		filePath := parts[2]
-		if len(parts) > 3 {
-			filePath = strings.Join(parts[2:], " ")
-		}
+		filePath := unquotePath(parts[2])
 
 		changeType := determineChangeType(additions, deletions)
 
@@ -170,9 +425,42 @@ func parseDiffOutput(output string, staged bool) ([]FileChange, error) {
 		})
 	}
 
+	// git lists the files in the same order in both formats
+	if len(raw) == len(changes) {
+		for i := range changes {
+			changes[i].Path, changes[i].OldPath, changes[i].Type = raw[i].Path, raw[i].OldPath, raw[i].Type
+		}
+	}
+
 	return changes, nil
 }
 
+// parseRawLine parses a line of git diff --raw, e.g.
+// ":100644 100644 1a2b3c4 5d6e7f8 R087\told.go\tnew.go"
+func parseRawLine(line string) (FileChange, bool) {
+	parts := strings.Split(line, "\t")
+	fields := strings.Fields(parts[0])
+	if len(parts) < 2 || len(fields) < 5 || fields[4] == "" {
+		return FileChange{}, false
+	}
+	f := FileChange{Path: unquotePath(parts[len(parts)-1])}
+	switch fields[4][0] {
+	case 'A':
+		f.Type = ChangeTypeAdded
+	case 'D':
+		f.Type = ChangeTypeDeleted
+	case 'R':
+		f.Type = ChangeTypeRenamed
+		f.OldPath = unquotePath(parts[1])
+	case 'C':
+		// A copy adds a file that starts out like another one
+		f.Type = ChangeTypeAdded
+	default:
+		f.Type = ChangeTypeModified
+	}
+	return f, true
+}
+
 func determineChangeType(additions, deletions int) ChangeType {
 	if additions > 0 && deletions == 0 {
 		return ChangeTypeAdded
@@ -185,68 +473,65 @@ func determineChangeType(additions, deletions int) ChangeType {
 
 func buildSummary(staged, unstaged []FileChange) string {
 	var parts []string
-	green := color.New(color.FgGreen).SprintFunc()
-	red := color.New(color.FgRed).SprintFunc()
-	yellow := color.New(color.FgYellow).SprintFunc()
-
 	if len(staged) > 0 {
-		parts = append(parts, fmt.Sprintf("%s: %d file(s)", yellow("Staged"), len(staged)))
-		for _, change := range staged {
-			addStr := green(fmt.Sprintf("+%d", change.Additions))
-			delStr := red(fmt.Sprintf("-%d", change.Deletions))
-			parts = append(parts, fmt.Sprintf("  %s %s %s", addStr, delStr, change.Path))
-		}
+		parts = append(parts, summarizeGroup("Staged", staged))
 	}
-
 	if len(unstaged) > 0 {
-		parts = append(parts, fmt.Sprintf("%s: %d file(s)", yellow("Unstaged"), len(unstaged)))
-		for _, change := range unstaged {
-			addStr := green(fmt.Sprintf("+%d", change.Additions))
-			delStr := red(fmt.Sprintf("-%d", change.Deletions))
-			parts = append(parts, fmt.Sprintf("  %s %s %s", addStr, delStr, change.Path))
-		}
+		parts = append(parts, summarizeGroup("Unstaged", unstaged))
 	}
-
 	return strings.Join(parts, "\n")
 }
 
-func GetDiffContent() (string, error) {
-	workDir, err := os.Getwd()
-	if err != nil {
-		return "", fmt.Errorf("failed to get working directory: %w", err)
-	}
-
-	gitRoot, err := FindGitRoot(workDir)
-	if err != nil {
-		return "", err
-	}
-
-	var stagedDiff, unstagedDiff string
-
-	cmd := exec.Command("git", "diff", "--cached")
-	cmd.Dir = gitRoot
-	output, err := cmd.Output()
-	if err == nil {
-		stagedDiff = string(output)
-	}
+// summarizeGroup lists files under a label with colored line counts
+func summarizeGroup(label string, files []FileChange) string {
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
 
-	cmd = exec.Command("git", "diff")
-	cmd.Dir = gitRoot
-	output, err = cmd.Output()
-	if err == nil {
-		unstagedDiff = string(output)
-	}
+	parts := []string{fmt.Sprintf("%s: %d file(s)", yellow(label), len(files))}
+	// Vendored files are collapsed into one line per vendor directory
+	vendored := map[string]*FileChange{}
+	vendorLine := map[string]int{}
+	vendorCounts := map[string]int{}
+	for _, change := range files {
+		if change.Vendored {
+			root := VendorRoot(change.Path)
+			if root == "" {
+				root = change.Path
+			}
+			if vendored[root] == nil {
+				vendored[root] = &FileChange{Path: root}
+				vendorLine[root] = len(parts)
+				parts = append(parts, "")
+			}
+			vendored[root].Additions += change.Additions
+			vendored[root].Deletions += change.Deletions
+			vendorCounts[root]++
+			continue
+		}
 
-	var parts []string
-	if stagedDiff != "" {
-		parts = append(parts, "=== STAGED CHANGES ===")
-		parts = append(parts, stagedDiff)
+		addStr := green(fmt.Sprintf("+%d", change.Additions))
+		delStr := red(fmt.Sprintf("-%d", change.Deletions))
+		line := fmt.Sprintf("  %s %s %s", addStr, delStr, change.Path)
+		if change.OldPath != "" {
+			line = fmt.Sprintf("  %s %s %s → %s (renamed)", addStr, delStr, change.OldPath, change.Path)
+		}
+		if note := change.ModeNote(); note != "" {
+			line += " (" + note + ")"
+		}
+		if change.LineEndingsOnly {
+			line += " (line endings only)"
+		} else if change.WhitespaceOnly {
+			line += " (formatting only)"
+		} else if change.Generated {
+			line += " (generated)"
+		}
+		parts = append(parts, line)
 	}
-	if unstagedDiff != "" {
-		parts = append(parts, "=== UNSTAGED CHANGES ===")
-		parts = append(parts, unstagedDiff)
+	for root, v := range vendored {
+		addStr := green(fmt.Sprintf("+%d", v.Additions))
+		delStr := red(fmt.Sprintf("-%d", v.Deletions))
+		parts[vendorLine[root]] = fmt.Sprintf("  %s %s %s (vendored deps updated, %d file(s))", addStr, delStr, root, vendorCounts[root])
 	}
-
-	return strings.Join(parts, "\n\n"), nil
+	return strings.Join(parts, "\n")
 }
-
@@ -0,0 +1,117 @@
+package git
+
+import (
+	"strings"
+
+	"auto-git/internal/logging"
+)
+
+// sparseCone returns the directories of a cone-mode sparse checkout, or nil
+// when the whole repository is checked out. Files directly in the root are
+// always part of the cone. Non-cone sparse checkouts are left to git.
+func sparseCone(gitRoot string) []string {
+	output, err := runGit(gitRoot, "config", "--bool", "core.sparseCheckout")
+	if err != nil || strings.TrimSpace(string(output)) != "true" {
+		return nil
+	}
+	// Cone mode is the default since git 2.37
+	if output, err := runGit(gitRoot, "config", "--bool", "core.sparseCheckoutCone"); err == nil && strings.TrimSpace(string(output)) == "false" {
+		logging.Debug("sparse checkout is not in cone mode; not limiting paths")
+		return nil
+	}
+	output, err = runGit(gitRoot, "sparse-checkout", "list")
+	if err != nil {
+		logging.Debug("failed to read the sparse checkout", "error", err)
+		return nil
+	}
+	dirs := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if dir := strings.Trim(strings.TrimSpace(line), "/"); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// SparseCone returns the directories of the sparse checkout of the current
+// repository, and whether there is one
+func SparseCone() ([]string, bool) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return nil, false
+	}
+	dirs := sparseCone(gitRoot)
+	return dirs, dirs != nil
+}
+
+// inCone reports whether path is inside the sparse checkout cone dirs. Like
+// git, the files directly inside the root and inside each parent directory
+// of a cone directory count as part of the cone, e.g. "a/x.txt" for "a/b".
+func inCone(path string, dirs []string) bool {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return true
+	}
+	parent := path[:i]
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, dir+"/") || dir == parent || strings.HasPrefix(dir, parent+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// keepInCone drops the files outside the sparse checkout cone dirs, and their
+// patches, from a diff
+func keepInCone(files []FileChange, patch string, dirs []string) ([]FileChange, string) {
+	var kept []FileChange
+	dropped := map[string]bool{}
+	for _, f := range files {
+		if inCone(f.Path, dirs) {
+			kept = append(kept, f)
+		} else {
+			dropped[f.Path] = true
+		}
+	}
+	if len(dropped) == 0 {
+		return files, patch
+	}
+	logging.Debug("ignoring changes outside the sparse checkout", "files", len(dropped))
+
+	var sections []string
+	for _, section := range splitFilePatches(patch) {
+		header, _, _ := strings.Cut(section, "\n")
+		if !dropped[pathFromDiffHeader(header)] {
+			sections = append(sections, section)
+		}
+	}
+	if kept == nil {
+		kept = []FileChange{}
+	}
+	return kept, strings.Join(sections, "")
+}
+
+// conePaths lists the changed and untracked files matching specs that are
+// inside the sparse checkout cone dirs, as literal pathspecs to pass to git
+// on stdin, since there may be too many for its arguments
+func conePaths(gitRoot string, specs []string, dirs []string) ([]string, error) {
+	var paths []string
+	for _, args := range [][]string{
+		{"diff", "--name-only", "-z", "--no-renames"},
+		{"ls-files", "--others", "--exclude-standard", "-z"},
+	} {
+		if specs != nil {
+			args = append(append(args, "--"), specs...)
+		}
+		output, err := runGit(gitRoot, args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range splitNul(string(output)) {
+			if inCone(path, dirs) {
+				paths = append(paths, ":(literal)"+path)
+			}
+		}
+	}
+	return paths, nil
+}
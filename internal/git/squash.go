@@ -0,0 +1,168 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrAlreadyPushed is returned when rewriting commits that the upstream
+// branch already contains
+var ErrAlreadyPushed = errors.New("commits have already been pushed")
+
+// SquashBase checks that the last n commits can be squashed into one and
+// returns the commit they will be squashed onto. Commits already on the
+// upstream branch are only allowed with allowPushed.
+func SquashBase(n int, allowPushed bool) (string, error) {
+	if n < 2 {
+		return "", fmt.Errorf("need at least 2 commits to squash, got %d", n)
+	}
+
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return "", err
+	}
+
+	total, err := countCommits(gitRoot, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if total <= n {
+		return "", shallowError(gitRoot, fmt.Errorf("cannot squash %d commits: the branch has %d and the root commit cannot be squashed", n, total))
+	}
+
+	base := "HEAD~" + strconv.Itoa(n)
+	merges, err := runGit(gitRoot, "rev-list", "--merges", "--count", base+"..HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect commits: %w", err)
+	}
+	if strings.TrimSpace(string(merges)) != "0" {
+		return "", fmt.Errorf("cannot squash across merge commits")
+	}
+
+	if _, err := runGit(gitRoot, "diff", "--cached", "--quiet"); err != nil {
+		return "", fmt.Errorf("staged changes would be included in the squashed commit; commit or unstage them first")
+	}
+
+	if !allowPushed {
+		if _, err := runGit(gitRoot, "rev-parse", "--verify", "--quiet", "@{upstream}"); err == nil {
+			unpushed, err := countCommits(gitRoot, "@{upstream}..HEAD")
+			if err != nil {
+				return "", err
+			}
+			if unpushed < n {
+				return "", fmt.Errorf("%w: only %d of the last %d commits are not on the upstream branch", ErrAlreadyPushed, unpushed, n)
+			}
+		}
+	}
+
+	output, err := runGit(gitRoot, "rev-parse", "--verify", base)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", base, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// DiffSince collects the combined changes between base and HEAD
+func DiffSince(base string) (*Changes, string, error) {
+	return DiffBetween(base, "HEAD")
+}
+
+// DiffBetween collects the changes from one commit to another
+func DiffBetween(from, to string) (*Changes, string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return nil, "", err
+	}
+
+	output, err := runGit(gitRoot, "diff", "--raw", "--numstat", "--patch", from, to)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to diff %s..%s: %w", from, to, err)
+	}
+
+	numstat, patch := splitNumstatPatch(string(output))
+	files, err := parseDiffOutput(numstat, true)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(files) == 0 {
+		return nil, "", ErrNoChanges
+	}
+	markModeChanges(files, patch)
+	markSymbolChanges(gitRoot, from, to, files)
+	return &Changes{Staged: files, Summary: buildSummary(files, nil)}, patch, nil
+}
+
+// SubjectsSince returns the subject lines of the commits after base, oldest first
+func SubjectsSince(base string) ([]string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := runGit(gitRoot, "log", "--reverse", "--format=%s", base+"..HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit history: %w", err)
+	}
+
+	var subjects []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			subjects = append(subjects, line)
+		}
+	}
+	return subjects, nil
+}
+
+// FirstAuthorSince returns the author, as "Name <email>", and author date of
+// the oldest commit after base
+func FirstAuthorSince(base string) (string, string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return "", "", err
+	}
+
+	output, err := runGit(gitRoot, "log", "--reverse", "--format=%an <%ae>%x00%aI", base+"..HEAD")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read commit author: %w", err)
+	}
+	first, _, _ := strings.Cut(string(output), "\n")
+	author, date, _ := strings.Cut(first, "\x00")
+	return author, date, nil
+}
+
+// Head returns the commit HEAD points to
+func Head() (string, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := runGit(gitRoot, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// SoftReset moves the branch to rev, keeping the changes staged
+func SoftReset(rev string) error {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return err
+	}
+
+	if _, err := runGit(gitRoot, "reset", "--soft", rev); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", rev, err)
+	}
+	return nil
+}
+
+func countCommits(gitRoot, revRange string) (int, error) {
+	output, err := runGit(gitRoot, "rev-list", "--count", revRange)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits: %w", err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
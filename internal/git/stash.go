@@ -0,0 +1,46 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+const stashMessage = "auto-git: unstaged changes"
+
+// StashUnstaged stashes worktree changes and untracked files that are not in
+// the index, leaving the index and its content in the worktree untouched. It
+// reports whether anything was stashed.
+func StashUnstaged() (bool, error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return false, err
+	}
+
+	before := stashHead(gitRoot)
+	if _, err := runGit(gitRoot, "stash", "push", "--keep-index", "--include-untracked", "-m", stashMessage); err != nil {
+		return false, fmt.Errorf("failed to stash unstaged changes: %w", err)
+	}
+	return stashHead(gitRoot) != before, nil
+}
+
+// PopStash restores the most recent stash, as created by StashUnstaged
+func PopStash() error {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return err
+	}
+
+	if _, err := runGit(gitRoot, "stash", "pop"); err != nil {
+		return fmt.Errorf("failed to restore stashed changes (run 'git stash pop' to retry): %w", err)
+	}
+	return nil
+}
+
+// stashHead returns the commit at the top of the stash, or "" when it is empty
+func stashHead(gitRoot string) string {
+	output, err := runGit(gitRoot, "rev-parse", "-q", "--verify", "refs/stash")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
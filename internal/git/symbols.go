@@ -0,0 +1,75 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+
+	"auto-git/internal/symbols"
+)
+
+// maxSymbolFiles bounds how many files are parsed for changed symbols
+const maxSymbolFiles = 50
+
+const (
+	// indexVersion names the version of a file in the index
+	indexVersion = ""
+	// worktreeVersion names the version of a file on disk
+	worktreeVersion = "\x00worktree"
+)
+
+// markSymbolChanges lists the functions and types that changed in each
+// supported file between the oldRev and newRev versions: a revision,
+// indexVersion or worktreeVersion
+func markSymbolChanges(gitRoot, oldRev, newRev string, files []FileChange) {
+	// A partial clone would download every old version it lacks
+	var env []string
+	if isPartial(gitRoot) {
+		env = noLazyFetch
+	}
+	parsed := 0
+	for i, f := range files {
+		if !symbols.Supported(f.Path) || f.Vendored || f.Generated || f.WhitespaceOnly || f.LineEndingsOnly {
+			continue
+		}
+		if parsed++; parsed > maxSymbolFiles {
+			return
+		}
+		oldPath := f.Path
+		if f.OldPath != "" {
+			oldPath = f.OldPath
+		}
+		before, after := readVersion(gitRoot, env, oldRev, oldPath), readVersion(gitRoot, env, newRev, f.Path)
+		if env != nil && (missingVersion(gitRoot, oldRev, oldPath, before) || missingVersion(gitRoot, newRev, f.Path, after)) {
+			// Comparing with nothing would list every symbol as added or removed
+			continue
+		}
+		files[i].Symbols = symbols.Compare(f.Path, before, after)
+	}
+}
+
+// missingVersion reports whether path exists at rev although readVersion
+// returned no content for it, as with an object a partial clone left out
+func missingVersion(gitRoot, rev, path string, data []byte) bool {
+	if data != nil || rev == worktreeVersion || rev == indexVersion {
+		return false
+	}
+	output, err := runGit(gitRoot, "ls-tree", "--name-only", rev, "--", path)
+	return err == nil && len(output) > 0
+}
+
+// readVersion returns the content of path at rev, or nil if it doesn't exist
+// there, e.g. because the file was added or deleted
+func readVersion(gitRoot string, env []string, rev, path string) []byte {
+	if rev == worktreeVersion {
+		data, err := os.ReadFile(filepath.Join(gitRoot, filepath.FromSlash(path)))
+		if err != nil {
+			return nil
+		}
+		return data
+	}
+	data, err := runGitEnv(gitRoot, env, "cat-file", "blob", rev+":"+path)
+	if err != nil {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,142 @@
+// Package i18n translates auto-git's own messages — prompts, progress and
+// errors — into the language selected by ui_language. It does not affect the
+// language of generated commit messages.
+//
+// Catalogs are keyed by the English text, so an untranslated message is
+// printed in English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// English is the built-in language; it needs no catalog
+const English = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var (
+	mu       sync.RWMutex
+	language = English
+	catalog  map[string]string
+)
+
+// SetLanguage selects the language for T and Sprintf. tag is a locale such as
+// "ja", "zh-CN" or "es_ES.UTF-8"; when it is empty the language is taken from
+// LC_ALL, LC_MESSAGES or LANG. Unsupported languages fall back to English.
+// It returns the language in use.
+func SetLanguage(tag string) string {
+	if strings.TrimSpace(tag) == "" {
+		tag = envLocale()
+	}
+
+	lang := resolve(tag)
+	var messages map[string]string
+	if lang != English {
+		var err error
+		if messages, err = load(lang); err != nil {
+			lang = English
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	language, catalog = lang, messages
+	return lang
+}
+
+// Language returns the language selected by SetLanguage
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return language
+}
+
+// Supported returns the bundled languages, including English
+func Supported() []string {
+	entries, _ := locales.ReadDir("locales")
+	langs := []string{English}
+	for _, entry := range entries {
+		langs = append(langs, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// T returns the translation of msg, or msg itself when it has none
+func T(msg string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if translated, ok := catalog[msg]; ok && translated != "" {
+		return translated
+	}
+	return msg
+}
+
+// Sprintf formats the translation of format
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// resolve maps a locale to a bundled language: the full tag is tried first,
+// then its base language. Chinese without a region or script is assumed to be
+// simplified.
+func resolve(tag string) string {
+	tag, _, _ = strings.Cut(tag, ".") // es_ES.UTF-8
+	tag, _, _ = strings.Cut(tag, "@") // de_DE@euro
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" || tag == "C" || tag == "POSIX" {
+		return English
+	}
+
+	base, region, _ := strings.Cut(tag, "-")
+	base = strings.ToLower(base)
+	if base == "zh" {
+		switch strings.ToUpper(region) {
+		case "", "CN", "SG", "HANS":
+			return "zh-CN"
+		}
+		return English
+	}
+
+	for _, candidate := range []string{base + "-" + strings.ToUpper(region), base} {
+		if has(candidate) {
+			return candidate
+		}
+	}
+	return English
+}
+
+func has(lang string) bool {
+	_, err := locales.Open("locales/" + lang + ".json")
+	return err == nil
+}
+
+func load(lang string) (map[string]string, error) {
+	data, err := locales.ReadFile("locales/" + lang + ".json")
+	if err != nil {
+		return nil, err
+	}
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("invalid %s catalog: %w", lang, err)
+	}
+	return messages, nil
+}
+
+// envLocale returns the locale of the environment, in the order gettext uses
+func envLocale() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(env); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Options controls where log records are written
+type Options struct {
+	// Debug writes debug-level records to stderr instead of only warnings
+	Debug bool
+	// File, when set, receives every record at debug level
+	File string
+	// JSON, when set, receives info-level records as JSON lines. Warnings
+	// then go only there, unless Debug is set.
+	JSON io.Writer
+}
+
+var logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+// Init configures the package logger. The returned function closes the log
+// file, if one was opened, and should be deferred by the caller.
+func Init(opts Options) (func() error, error) {
+	stderrLevel := slog.LevelWarn
+	if opts.Debug {
+		stderrLevel = slog.LevelDebug
+	}
+
+	var handlers []slog.Handler
+	if opts.JSON == nil || opts.Debug {
+		handlers = append(handlers, slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: stderrLevel}))
+	}
+	if opts.JSON != nil {
+		handlers = append(handlers, slog.NewJSONHandler(opts.JSON, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	}
+	closer := func() error { return nil }
+
+	if opts.File != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0755); err != nil {
+			return closer, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return closer, fmt.Errorf("failed to open log file: %w", err)
+		}
+		handlers = append(handlers, slog.NewJSONHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		closer = f.Close
+	}
+
+	logger = slog.New(teeHandler(handlers))
+	return closer, nil
+}
+
+// Logger returns the configured logger
+func Logger() *slog.Logger {
+	return logger
+}
+
+func Debug(msg string, args ...any) { logger.Debug(msg, args...) }
+func Info(msg string, args ...any)  { logger.Info(msg, args...) }
+func Warn(msg string, args ...any)  { logger.Warn(msg, args...) }
+func Error(msg string, args ...any) { logger.Error(msg, args...) }
+
+// teeHandler forwards each record to every handler that accepts its level
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range t {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// sensitiveHeaders lists request headers whose values must never be logged
+var sensitiveHeaders = []string{"Authorization", "X-Api-Key", "Api-Key", "Proxy-Authorization"}
+
+// MaskSecret hides all but the last four characters of a secret
+func MaskSecret(secret string) string {
+	const visible = 4
+	if len(secret) <= visible {
+		return strings.Repeat("*", len(secret))
+	}
+	return strings.Repeat("*", len(secret)-visible) + secret[len(secret)-visible:]
+}
+
+// RedactHeaders returns a copy of h with credential headers masked
+func RedactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range sensitiveHeaders {
+		values := out.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		out.Del(name)
+		for _, v := range values {
+			scheme, token, ok := strings.Cut(v, " ")
+			if ok {
+				out.Add(name, scheme+" "+MaskSecret(token))
+			} else {
+				out.Add(name, MaskSecret(v))
+			}
+		}
+	}
+	return out
+}
+
+// secretPatterns match credentials that commonly appear in source code
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`(?i)\bBearer\s+[A-Za-z0-9._~+/-]{8,}=*`),
+}
+
+// secretAssignment matches values assigned to secret-looking names, e.g. API_KEY=...
+var secretAssignment = regexp.MustCompile(`(?i)([A-Za-z0-9_.-]*(?:api[_-]?key|secret|token|passw(?:or)?d)[A-Za-z0-9_.-]*["']?\s*[:=]\s*["']?)([^\s"',;]{4,})`)
+
+// Redacted replaces secrets removed by RedactSecrets
+const Redacted = "[REDACTED]"
+
+// RedactSecrets replaces private keys, well-known token formats, and values
+// assigned to secret-looking names in free text
+func RedactSecrets(text string) string {
+	for _, re := range secretPatterns {
+		text = re.ReplaceAllString(text, Redacted)
+	}
+	return secretAssignment.ReplaceAllString(text, "${1}"+Redacted)
+}
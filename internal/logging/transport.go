@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// maxLoggedBody caps how much of a request or response body is written to the log
+const maxLoggedBody = 4096
+
+// Transport is an http.RoundTripper that logs each request and response at
+// debug level, with credentials redacted
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// NewTransport wraps base (or http.DefaultTransport when nil) with request logging
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !logger.Enabled(req.Context(), slog.LevelDebug) {
+		return t.Base.RoundTrip(req)
+	}
+
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	logger.Debug("http request",
+		"method", req.Method,
+		"url", req.URL.Redacted(),
+		"headers", RedactHeaders(req.Header),
+		"body", truncateBody(reqBody),
+	)
+
+	start := time.Now()
+	resp, err := t.Base.RoundTrip(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		logger.Debug("http request failed", "url", req.URL.Redacted(), "duration", elapsed, "error", err)
+		return nil, err
+	}
+
+	respBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	logger.Debug("http response",
+		"url", req.URL.Redacted(),
+		"status", resp.StatusCode,
+		"duration", elapsed,
+		"body", truncateBody(respBody),
+	)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	return resp, nil
+}
+
+func truncateBody(body []byte) string {
+	if len(body) > maxLoggedBody {
+		return string(body[:maxLoggedBody]) + "...(truncated)"
+	}
+	return string(body)
+}
@@ -0,0 +1,296 @@
+// Package mock implements an offline provider that derives deterministic
+// commit messages from the diff, for demos and end-to-end testing.
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"auto-git/internal/git"
+	"auto-git/internal/prompt"
+	"auto-git/internal/provider"
+)
+
+const (
+	// DefaultModel is the only model the mock provider offers
+	DefaultModel = "mock"
+	// EnvTemplate overrides the message template
+	EnvTemplate = "AUTO_GIT_MOCK_TEMPLATE"
+	// DefaultTemplate renders messages like "feat(cmd): add serve.go"
+	DefaultTemplate = "{{.Type}}{{if .Scope}}({{.Scope}}){{end}}: {{.Action}} {{.Subject}}"
+
+	// diffMarker precedes the diff in the user prompt
+	diffMarker = "=== DIFF CONTENT ==="
+	// filesMarker precedes the per-file sections that replace the diff when
+	// it contains file patches
+	filesMarker = "=== FILES ==="
+)
+
+// Data is passed to the message template
+type Data struct {
+	Type      string // conventional commit type
+	Scope     string // top-level directory shared by all files, if any
+	Action    string // add, remove, or update
+	Subject   string // file name, or "N files"
+	Files     []string
+	Additions int
+	Deletions int
+	Model     string
+}
+
+type Client struct {
+	tmpl *template.Template
+}
+
+// NewClient returns a mock provider using the template from AUTO_GIT_MOCK_TEMPLATE,
+// or DefaultTemplate when it is unset
+func NewClient() (*Client, error) {
+	text := strings.TrimSpace(os.Getenv(EnvTemplate))
+	if text == "" {
+		text = DefaultTemplate
+	}
+
+	tmpl, err := template.New("mock").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", EnvTemplate, err)
+	}
+	return &Client{tmpl: tmpl}, nil
+}
+
+func (c *Client) CheckConnection() error {
+	return nil
+}
+
+func (c *Client) ListModels() ([]provider.Model, error) {
+	return []provider.Model{{Name: DefaultModel, OwnedBy: "auto-git"}}, nil
+}
+
+// Generate describes the changes it reads back from the user prompt. The
+// engine calls GenerateChanges instead; this serves callers that only have
+// prompts, such as benchmarks.
+func (c *Client) Generate(model string, systemPrompt, userPrompt string) (*provider.Completion, error) {
+	changes, err := parsePrompt(userPrompt)
+	if err != nil {
+		// Empty commits have no diff to describe
+		changes = &git.Changes{}
+	}
+	return c.GenerateChanges(changes, model, systemPrompt, userPrompt)
+}
+
+// GenerateChanges describes changes; the prompts only tell which kind of
+// reply is asked for
+func (c *Client) GenerateChanges(changes *git.Changes, model string, systemPrompt, userPrompt string) (*provider.Completion, error) {
+	data := Describe(changes)
+	data.Model = model
+
+	var buf bytes.Buffer
+	if err := c.tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("mock provider: %w", err)
+	}
+
+	content := strings.TrimSpace(buf.String())
+	if prompt.IsPRPrompt(userPrompt) {
+		content = pullRequestJSON(content, data)
+	}
+	if prompt.IsFieldsPrompt(userPrompt) {
+		content = fieldsJSON(data, userPrompt)
+	}
+	return &provider.Completion{
+		Content: content,
+		Usage: provider.Usage{
+			PromptTokens:     len(strings.Fields(systemPrompt)) + len(strings.Fields(userPrompt)),
+			CompletionTokens: len(strings.Fields(content)),
+		},
+	}, nil
+}
+
+func (c *Client) GenerateCommitMessage(model string, systemPrompt, userPrompt string) (string, error) {
+	completion, err := c.Generate(model, systemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	return completion.Content, nil
+}
+
+// Describe derives the template data from a change set
+func Describe(changes *git.Changes) Data {
+	files := append(append([]git.FileChange{}, changes.Staged...), changes.Unstaged...)
+
+	var data Data
+	added, deleted := 0, 0
+	docs, tests, ci, vendored := true, true, true, true
+	for _, f := range files {
+		data.Files = append(data.Files, f.Path)
+		data.Additions += f.Additions
+		data.Deletions += f.Deletions
+
+		switch f.Type {
+		case git.ChangeTypeAdded:
+			added++
+		case git.ChangeTypeDeleted:
+			deleted++
+		}
+
+		vendored = vendored && f.Vendored
+		category := prompt.Categorize(f.Path)
+		docs = docs && category == prompt.CategoryDocs
+		tests = tests && category == prompt.CategoryTest
+		ci = ci && category == prompt.CategoryCI
+	}
+
+	switch {
+	case len(files) == 0:
+		data.Action = "create"
+	case added == len(files):
+		data.Action = "add"
+	case deleted == len(files):
+		data.Action = "remove"
+	default:
+		data.Action = "update"
+	}
+
+	switch {
+	case len(files) == 0, vendored:
+		data.Type = "chore"
+	case docs:
+		data.Type = "docs"
+	case tests:
+		data.Type = "test"
+	case ci:
+		data.Type = "ci"
+	case data.Action == "add":
+		data.Type = "feat"
+	case data.Action == "remove":
+		data.Type = "refactor"
+	default:
+		data.Type = "chore"
+	}
+
+	data.Scope = commonScope(data.Files)
+	switch {
+	case len(files) == 0:
+		data.Subject = "empty commit"
+	case vendored:
+		data.Scope, data.Subject = "deps", "vendored dependencies"
+	case len(files) == 1:
+		data.Subject = path.Base(files[0].Path)
+	default:
+		data.Subject = fmt.Sprintf("%d files", len(files))
+	}
+
+	return data
+}
+
+// pullRequestJSON answers a pull request prompt, reusing the commit subject
+// for the title and listing the files in the description
+func pullRequestJSON(subject string, data Data) string {
+	title := subject
+	if _, rest, ok := strings.Cut(subject, ": "); ok {
+		title = rest
+	}
+	if title != "" {
+		title = strings.ToUpper(title[:1]) + title[1:]
+	}
+
+	description := []string{fmt.Sprintf("%s (+%d -%d).", title, data.Additions, data.Deletions), ""}
+	for _, f := range data.Files {
+		description = append(description, "- `"+f+"`")
+	}
+	reply, _ := json.Marshal(prompt.PullRequest{
+		Subject:     subject,
+		Body:        fmt.Sprintf("Touches %d file(s).", len(data.Files)),
+		Title:       title,
+		Description: strings.TrimSpace(strings.Join(description, "\n")),
+	})
+	return string(reply)
+}
+
+// branchIssue finds an issue number in the branch named by a fields prompt,
+// e.g. 123 in "fix/123-login"
+var branchIssue = regexp.MustCompile(`The current branch is "[^"]*?(\d+)[^"\d]*"`)
+
+// fieldsJSON replies to a fields prompt with the template data
+func fieldsJSON(data Data, userPrompt string) string {
+	fields := prompt.Fields{
+		Type:    data.Type,
+		Scope:   data.Scope,
+		Subject: strings.TrimSpace(data.Action + " " + data.Subject),
+	}
+	if m := branchIssue.FindStringSubmatch(userPrompt); m != nil {
+		fields.Issue = m[1]
+	}
+	reply, _ := json.Marshal(fields)
+	return string(reply)
+}
+
+// parsePrompt rebuilds the change set from the change manifest of a user
+// prompt, or from its file sections or diff when it has none
+func parsePrompt(userPrompt string) (*git.Changes, error) {
+	if files, ok := prompt.ParseManifest(userPrompt); ok && len(files) > 0 {
+		return &git.Changes{Staged: files}, nil
+	}
+	if _, sections, ok := strings.Cut(userPrompt, filesMarker); ok {
+		return parseFileSections(sections)
+	}
+	return git.ParsePatch(extractDiff(userPrompt))
+}
+
+// parseFileSections reads the headings of the per-file sections, such as
+// "### cmd/root.go (modified, +3 -1, staged)"
+func parseFileSections(text string) (*git.Changes, error) {
+	var files []git.FileChange
+	for _, line := range strings.Split(text, "\n") {
+		heading, ok := strings.CutPrefix(line, "### ")
+		if !ok {
+			continue
+		}
+		f := git.FileChange{Path: heading}
+		if i := strings.LastIndex(heading, " ("); i >= 0 && strings.HasSuffix(heading, ")") {
+			f.Path = heading[:i]
+			for _, detail := range strings.Split(heading[i+2:len(heading)-1], ", ") {
+				fmt.Sscanf(detail, "+%d -%d", &f.Additions, &f.Deletions)
+			}
+		}
+		switch {
+		case f.Additions > 0 && f.Deletions == 0:
+			f.Type = git.ChangeTypeAdded
+		case f.Additions == 0 && f.Deletions > 0:
+			f.Type = git.ChangeTypeDeleted
+		default:
+			f.Type = git.ChangeTypeModified
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file sections found")
+	}
+	return &git.Changes{Staged: files}, nil
+}
+
+// extractDiff returns the diff section of a user prompt, or the whole prompt
+// when it has no such section
+func extractDiff(userPrompt string) string {
+	if _, diff, ok := strings.Cut(userPrompt, diffMarker); ok {
+		return diff
+	}
+	return userPrompt
+}
+
+// commonScope returns the top-level directory shared by all paths, or ""
+func commonScope(paths []string) string {
+	scope := ""
+	for _, p := range paths {
+		dir, _, ok := strings.Cut(p, "/")
+		if !ok || (scope != "" && dir != scope) {
+			return ""
+		}
+		scope = dir
+	}
+	return strings.TrimPrefix(scope, ".")
+}
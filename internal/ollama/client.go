@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"auto-git/internal/provider"
+	"auto-git/internal/transport"
 )
 
 const (
@@ -22,22 +23,56 @@ const (
 type Client struct {
 	BaseURL string
 	Client  *http.Client
-	APIKey  string
+	// Keys authenticate requests through Client's transport
+	Keys *transport.Keys
+	// Header is added to every request, e.g. a gateway's tenant ID
+	Header http.Header
+	// Socket is the Unix domain socket requests go to, set by a
+	// "unix://" endpoint; BaseURL then only supplies the path
+	Socket string
+	// Reasoning turns on thinking for models that support it when an
+	// effort is set; Ollama has no thinking budget
+	Reasoning provider.Reasoning
 }
 
 type ModelsResponse struct {
-	Models []provider.Model `json:"models"`
+	Models []ModelInfo `json:"models"`
+}
+
+type ModelInfo struct {
+	Name       string       `json:"name"`
+	ModifiedAt string       `json:"modified_at"`
+	Size       int64        `json:"size"`
+	Details    ModelDetails `json:"details"`
+}
+
+type ModelDetails struct {
+	Format            string `json:"format"`
+	Family            string `json:"family"`
+	ParameterSize     string `json:"parameter_size"`
+	QuantizationLevel string `json:"quantization_level"`
+}
+
+type ShowRequest struct {
+	Model string `json:"model"`
+}
+
+type ShowResponse struct {
+	Details ModelDetails `json:"details"`
 }
 
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// Thinking is the reasoning of a thinking model, separate from Content
+	Thinking string `json:"thinking,omitempty"`
 }
 
 type ChatRequest struct {
 	Model    string        `json:"model"`
 	Messages []ChatMessage `json:"messages"`
 	Stream   bool          `json:"stream"`
+	Think    bool          `json:"think,omitempty"`
 }
 
 type ChatResponse struct {
@@ -62,12 +97,19 @@ func NewClient(baseURL, apiKey string) *Client {
 		apiKey = strings.TrimSpace(getEnv(EnvAPIKey))
 	}
 
+	socket := ""
+	if path, base, ok := transport.SplitUnixEndpoint(baseURL); ok {
+		socket, baseURL = path, base
+	}
+
+	keys := transport.NewKeys(apiKey)
+	header := http.Header{}
 	return &Client{
 		BaseURL: baseURL,
-		Client: &http.Client{
-			Timeout: DefaultTimeout,
-		},
-		APIKey: strings.TrimSpace(apiKey),
+		Client:  transport.NewClient(DefaultTimeout, socket, keys, header),
+		Keys:    keys,
+		Header:  header,
+		Socket:  socket,
 	}
 }
 
@@ -78,7 +120,6 @@ func (c *Client) ListModels() ([]provider.Model, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	c.attachAuth(req)
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
@@ -88,7 +129,7 @@ func (c *Client) ListModels() ([]provider.Model, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return nil, transport.StatusError(resp, body)
 	}
 
 	var modelsResp ModelsResponse
@@ -96,10 +137,71 @@ func (c *Client) ListModels() ([]provider.Model, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return modelsResp.Models, nil
+	models := make([]provider.Model, 0, len(modelsResp.Models))
+	for _, m := range modelsResp.Models {
+		details := m.Details
+		if details.Family == "" && details.ParameterSize == "" {
+			// Older servers omit details from /api/tags
+			if shown, err := c.ShowModel(m.Name); err == nil {
+				details = shown
+			}
+		}
+		models = append(models, provider.Model{
+			Name:          m.Name,
+			ModifiedAt:    m.ModifiedAt,
+			Size:          m.Size,
+			Family:        details.Family,
+			ParameterSize: details.ParameterSize,
+			Quantization:  details.QuantizationLevel,
+		})
+	}
+
+	return models, nil
+}
+
+// ShowModel fetches a model's details (family, parameter size, quantization)
+func (c *Client) ShowModel(name string) (ModelDetails, error) {
+	url := fmt.Sprintf("%s/api/show", c.BaseURL)
+
+	jsonData, err := json.Marshal(ShowRequest{Model: name})
+	if err != nil {
+		return ModelDetails{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return ModelDetails{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return ModelDetails{}, fmt.Errorf("failed to fetch model details: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return ModelDetails{}, transport.StatusError(resp, body)
+	}
+
+	var showResp ShowResponse
+	if err := json.NewDecoder(resp.Body).Decode(&showResp); err != nil {
+		return ModelDetails{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return showResp.Details, nil
 }
 
 func (c *Client) GenerateCommitMessage(model string, systemPrompt, userPrompt string) (string, error) {
+	completion, err := c.Generate(model, systemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	return completion.Content, nil
+}
+
+func (c *Client) Generate(model string, systemPrompt, userPrompt string) (*provider.Completion, error) {
 	url := fmt.Sprintf("%s/api/chat", c.BaseURL)
 
 	messages := []ChatMessage{
@@ -117,42 +219,52 @@ func (c *Client) GenerateCommitMessage(model string, systemPrompt, userPrompt st
 		Model:    model,
 		Messages: messages,
 		Stream:   false,
+		Think:    c.Reasoning.Effort != "",
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	c.attachAuth(req)
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return nil, transport.StatusError(resp, body)
 	}
 
 	var chatResp ChatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	if chatResp.Message.Content == "" {
-		return "", fmt.Errorf("empty response from model")
+	content := provider.StripThinking(chatResp.Message.Content)
+	if content == "" {
+		if chatResp.Message.Thinking != "" || chatResp.Message.Content != "" {
+			return nil, provider.ErrReasoningOnly
+		}
+		return nil, fmt.Errorf("empty response from model")
 	}
 
-	return chatResp.Message.Content, nil
+	return &provider.Completion{
+		Content: content,
+		Usage: provider.Usage{
+			PromptTokens:     chatResp.PromptEvalCount,
+			CompletionTokens: chatResp.EvalCount,
+		},
+	}, nil
 }
 
 func (c *Client) CheckConnection() error {
@@ -162,7 +274,6 @@ func (c *Client) CheckConnection() error {
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
-	c.attachAuth(req)
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
@@ -177,13 +288,6 @@ func (c *Client) CheckConnection() error {
 	return nil
 }
 
-func (c *Client) attachAuth(req *http.Request) {
-	if c.APIKey == "" {
-		return
-	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
-}
-
 // getEnv gets environment variable value
 func getEnv(key string) string {
 	return os.Getenv(key)
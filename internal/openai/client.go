@@ -2,6 +2,8 @@ package openai
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,31 +13,93 @@ import (
 	"time"
 
 	"auto-git/internal/provider"
+	"auto-git/internal/transport"
 )
 
 const (
-	DefaultOpenAIBaseURL    = "https://api.openai.com/v1"
-	DefaultSiliconFlowURL   = "https://api.siliconflow.cn/v1"
-	DefaultTimeout          = 60 * time.Second
-	EnvOpenAIAPIKey         = "OPENAI_API_KEY"
-	EnvSiliconFlowAPIKey    = "SILICON_KEY"
+	DefaultOpenAIBaseURL  = "https://api.openai.com/v1"
+	DefaultSiliconFlowURL = "https://api.siliconflow.cn/v1"
+	DefaultTimeout        = 60 * time.Second
+	EnvOpenAIAPIKey       = "OPENAI_API_KEY"
+	EnvSiliconFlowAPIKey  = "SILICON_KEY"
+	// EnvOrganization and EnvProject select the OpenAI organization and
+	// project billed for requests, for accounts with several of them
+	EnvOrganization = "OPENAI_ORG_ID"
+	EnvProject      = "OPENAI_PROJECT_ID"
+)
+
+// Prompt caching modes for Client.PromptCache
+const (
+	// CacheOpenAI sends a prompt_cache_key derived from the system prompt, so
+	// that OpenAI routes every run to the cache holding it
+	CacheOpenAI = "openai"
+	// CacheAnthropic marks the end of the system prompt with cache_control,
+	// for Claude behind OpenAI-compatible gateways such as OpenRouter
+	CacheAnthropic = "anthropic"
 )
 
 type Client struct {
 	BaseURL string
 	Client  *http.Client
-	APIKey  string
+	// Keys authenticate requests through Client's transport
+	Keys *transport.Keys
+	// Header is added to every request, e.g. a gateway's tenant ID
+	Header http.Header
+	// Socket is the Unix domain socket requests go to, set by a
+	// "unix://" endpoint; BaseURL then only supplies the path
+	Socket string
+	// Organization and Project are sent as the OpenAI-Organization and
+	// OpenAI-Project headers when set
+	Organization string
+	Project      string
+	// Reasoning sets the reasoning effort and thinking budget of
+	// reasoning models
+	Reasoning provider.Reasoning
+	// PromptCache is CacheOpenAI or CacheAnthropic to ask the endpoint to
+	// cache the system prompt; empty leaves caching to the endpoint
+	PromptCache string
+
+	// siliconFlow lists chat models only; its /models also returns
+	// embedding, reranking and image models
+	siliconFlow bool
 }
 
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role string `json:"role"`
+	// Content is a string, or []ContentPart to set a cache breakpoint
+	Content any `json:"content"`
+}
+
+type ContentPart struct {
+	Type         string        `json:"type"`
+	Text         string        `json:"text"`
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
+}
+
+type CacheControl struct {
+	Type string `json:"type"`
 }
 
 type ChatRequest struct {
 	Model    string        `json:"model"`
 	Messages []ChatMessage `json:"messages"`
 	Stream   bool          `json:"stream"`
+	// ReasoningEffort is OpenAI's effort for o-series and GPT-5 models
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+	// Thinking is the budget of Anthropic's OpenAI-compatible API
+	Thinking *Thinking `json:"thinking,omitempty"`
+	// EnableThinking and ThinkingBudget are SiliconFlow's budget for
+	// models such as Qwen3
+	EnableThinking *bool `json:"enable_thinking,omitempty"`
+	ThinkingBudget int   `json:"thinking_budget,omitempty"`
+	// PromptCacheKey groups requests sharing a prompt prefix for OpenAI's
+	// automatic prompt caching
+	PromptCacheKey string `json:"prompt_cache_key,omitempty"`
+}
+
+type Thinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
 type ChatResponse struct {
@@ -48,13 +112,21 @@ type ChatResponse struct {
 		Message struct {
 			Role    string `json:"role"`
 			Content string `json:"content"`
+			// ReasoningContent is the thinking of DeepSeek, Qwen3 and
+			// similar models; it is never part of the answer
+			ReasoningContent string `json:"reasoning_content"`
 		} `json:"message"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
 	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
+		PromptTokens        int `json:"prompt_tokens"`
+		CompletionTokens    int `json:"completion_tokens"`
+		TotalTokens         int `json:"total_tokens"`
+		PromptTokensDetails struct {
+			CachedTokens int `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
+		// PromptCacheHitTokens is DeepSeek's count of cached prompt tokens
+		PromptCacheHitTokens int `json:"prompt_cache_hit_tokens"`
 	} `json:"usage"`
 }
 
@@ -84,23 +156,39 @@ func NewClient(baseURL, apiKey string, isSiliconFlow bool) *Client {
 		}
 	}
 
-	return &Client{
+	socket := ""
+	if path, base, ok := transport.SplitUnixEndpoint(baseURL); ok {
+		socket, baseURL = path, base
+	}
+
+	keys := transport.NewKeys(apiKey)
+	header := http.Header{}
+	client := &Client{
 		BaseURL: baseURL,
-		Client: &http.Client{
-			Timeout: DefaultTimeout,
-		},
-		APIKey: strings.TrimSpace(apiKey),
+		Client:  transport.NewClient(DefaultTimeout, socket, keys, header),
+		Keys:    keys,
+		Header:  header,
+		Socket:  socket,
+	}
+	client.siliconFlow = isSiliconFlow
+	if !isSiliconFlow {
+		client.Organization = strings.TrimSpace(getEnv(EnvOrganization))
+		client.Project = strings.TrimSpace(getEnv(EnvProject))
 	}
+	return client
 }
 
 func (c *Client) ListModels() ([]provider.Model, error) {
 	url := fmt.Sprintf("%s/models", c.BaseURL)
+	if c.siliconFlow {
+		url += "?type=text&sub_type=chat"
+	}
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	c.attachAuth(req)
+	c.setAccount(req)
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
@@ -110,7 +198,7 @@ func (c *Client) ListModels() ([]provider.Model, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return nil, transport.StatusError(resp, body)
 	}
 
 	var modelsResp ModelsResponse
@@ -121,7 +209,9 @@ func (c *Client) ListModels() ([]provider.Model, error) {
 	models := make([]provider.Model, 0, len(modelsResp.Data))
 	for _, m := range modelsResp.Data {
 		models = append(models, provider.Model{
-			Name: m.ID,
+			Name:    m.ID,
+			OwnedBy: m.OwnedBy,
+			Created: m.Created,
 		})
 	}
 
@@ -129,6 +219,14 @@ func (c *Client) ListModels() ([]provider.Model, error) {
 }
 
 func (c *Client) GenerateCommitMessage(model string, systemPrompt, userPrompt string) (string, error) {
+	completion, err := c.Generate(model, systemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	return completion.Content, nil
+}
+
+func (c *Client) Generate(model string, systemPrompt, userPrompt string) (*provider.Completion, error) {
 	url := fmt.Sprintf("%s/chat/completions", c.BaseURL)
 
 	messages := []ChatMessage{
@@ -147,41 +245,58 @@ func (c *Client) GenerateCommitMessage(model string, systemPrompt, userPrompt st
 		Messages: messages,
 		Stream:   false,
 	}
+	c.setReasoning(&reqBody)
+	c.setPromptCache(&reqBody, systemPrompt)
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	c.attachAuth(req)
+	c.setAccount(req)
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return nil, transport.StatusError(resp, body)
 	}
 
 	var chatResp ChatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	if len(chatResp.Choices) == 0 || chatResp.Choices[0].Message.Content == "" {
-		return "", fmt.Errorf("empty response from model")
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("empty response from model")
+	}
+	message := chatResp.Choices[0].Message
+	content := provider.StripThinking(message.Content)
+	if content == "" {
+		if message.ReasoningContent != "" || message.Content != "" {
+			return nil, provider.ErrReasoningOnly
+		}
+		return nil, fmt.Errorf("empty response from model")
 	}
 
-	return chatResp.Choices[0].Message.Content, nil
+	return &provider.Completion{
+		Content: content,
+		Usage: provider.Usage{
+			PromptTokens:     chatResp.Usage.PromptTokens,
+			CompletionTokens: chatResp.Usage.CompletionTokens,
+			CachedTokens:     max(chatResp.Usage.PromptTokensDetails.CachedTokens, chatResp.Usage.PromptCacheHitTokens),
+		},
+	}, nil
 }
 
 func (c *Client) CheckConnection() error {
@@ -192,7 +307,7 @@ func (c *Client) CheckConnection() error {
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
-	c.attachAuth(req)
+	c.setAccount(req)
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
@@ -202,21 +317,55 @@ func (c *Client) CheckConnection() error {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return transport.StatusError(resp, body)
 	}
 
 	return nil
 }
 
-func (c *Client) attachAuth(req *http.Request) {
-	if c.APIKey == "" {
+// setReasoning adds the configured reasoning settings to req in the form the
+// endpoint understands
+func (c *Client) setReasoning(req *ChatRequest) {
+	req.ReasoningEffort = c.Reasoning.Effort
+	if c.Reasoning.BudgetTokens <= 0 {
+		return
+	}
+	if c.siliconFlow {
+		enable := true
+		req.EnableThinking = &enable
+		req.ThinkingBudget = c.Reasoning.BudgetTokens
 		return
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
+	req.Thinking = &Thinking{Type: "enabled", BudgetTokens: c.Reasoning.BudgetTokens}
+}
+
+// setPromptCache asks the endpoint to cache the system prompt, which is the
+// same for every run and comes first, ahead of the diff
+func (c *Client) setPromptCache(req *ChatRequest, systemPrompt string) {
+	switch c.PromptCache {
+	case CacheOpenAI:
+		sum := sha256.Sum256([]byte(systemPrompt))
+		req.PromptCacheKey = "auto-git-" + hex.EncodeToString(sum[:8])
+	case CacheAnthropic:
+		req.Messages[0].Content = []ContentPart{{
+			Type:         "text",
+			Text:         systemPrompt,
+			CacheControl: &CacheControl{Type: "ephemeral"},
+		}}
+	}
+}
+
+// setAccount selects the organization and project billed for req
+func (c *Client) setAccount(req *http.Request) {
+	if c.Organization != "" {
+		req.Header.Set("OpenAI-Organization", c.Organization)
+	}
+	if c.Project != "" {
+		req.Header.Set("OpenAI-Project", c.Project)
+	}
 }
 
 // getEnv gets environment variable value
 func getEnv(key string) string {
 	return os.Getenv(key)
 }
-
@@ -0,0 +1,226 @@
+// Package plugin runs third-party providers as external executables.
+//
+// A plugin is an executable named auto-git-provider-<name> in the plugins
+// directory. For every call auto-git starts the plugin, writes one JSON
+// Request to its stdin, and reads one JSON Response from its stdout. A
+// non-empty Response.Error (or a non-zero exit status) marks the call as failed.
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"auto-git/internal/logging"
+	"auto-git/internal/provider"
+)
+
+const (
+	// FilePrefix is the required prefix of plugin executable names
+	FilePrefix = "auto-git-provider-"
+	// DefaultTimeout bounds a single plugin invocation
+	DefaultTimeout = 120 * time.Second
+)
+
+// Methods understood by plugins
+const (
+	MethodGenerate        = "generate"
+	MethodListModels      = "list_models"
+	MethodCheckConnection = "check_connection"
+)
+
+// Request is written to the plugin's stdin
+type Request struct {
+	Method       string `json:"method"`
+	Endpoint     string `json:"endpoint,omitempty"`
+	APIKey       string `json:"api_key,omitempty"`
+	Model        string `json:"model,omitempty"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	UserPrompt   string `json:"user_prompt,omitempty"`
+}
+
+// Response is read from the plugin's stdout
+type Response struct {
+	Content string           `json:"content,omitempty"`
+	Usage   *ResponseUsage   `json:"usage,omitempty"`
+	Models  []provider.Model `json:"models,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+type ResponseUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Discover returns the plugins in dir, keyed by provider name
+func Discover(dir string) map[string]string {
+	plugins := make(map[string]string)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return plugins
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, FilePrefix) {
+			continue
+		}
+		if runtime.GOOS == "windows" {
+			name = strings.TrimSuffix(name, ".exe")
+		}
+		path := filepath.Join(dir, entry.Name())
+		if !isExecutable(path) {
+			logging.Debug("ignoring non-executable plugin", "path", path)
+			continue
+		}
+		plugins[strings.ToLower(strings.TrimPrefix(name, FilePrefix))] = path
+	}
+
+	return plugins
+}
+
+// Names returns the sorted provider names of the plugins in dir
+func Names(dir string) []string {
+	plugins := Discover(dir)
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(path), ".exe")
+	}
+	return info.Mode()&0111 != 0
+}
+
+// Client implements provider.Provider by invoking a plugin executable
+type Client struct {
+	Name     string
+	Path     string
+	Endpoint string
+	APIKey   string
+	Timeout  time.Duration
+}
+
+func NewClient(name, path, endpoint, apiKey string) *Client {
+	return &Client{
+		Name:     name,
+		Path:     path,
+		Endpoint: endpoint,
+		APIKey:   strings.TrimSpace(apiKey),
+		Timeout:  DefaultTimeout,
+	}
+}
+
+func (c *Client) GenerateCommitMessage(model string, systemPrompt, userPrompt string) (string, error) {
+	completion, err := c.Generate(model, systemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	return completion.Content, nil
+}
+
+func (c *Client) Generate(model string, systemPrompt, userPrompt string) (*provider.Completion, error) {
+	resp, err := c.call(Request{
+		Method:       MethodGenerate,
+		Model:        model,
+		SystemPrompt: systemPrompt,
+		UserPrompt:   userPrompt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Content == "" {
+		return nil, fmt.Errorf("empty response from model")
+	}
+
+	completion := &provider.Completion{Content: resp.Content}
+	if resp.Usage != nil {
+		completion.Usage = provider.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		}
+	}
+	return completion, nil
+}
+
+func (c *Client) ListModels() ([]provider.Model, error) {
+	resp, err := c.call(Request{Method: MethodListModels})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Models, nil
+}
+
+func (c *Client) CheckConnection() error {
+	_, err := c.call(Request{Method: MethodCheckConnection})
+	return err
+}
+
+func (c *Client) call(req Request) (*Response, error) {
+	req.Endpoint = c.Endpoint
+	req.APIKey = c.APIKey
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plugin request: %w", err)
+	}
+
+	cmd := exec.Command(c.Path)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", c.Name, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err = <-done:
+	case <-time.After(c.Timeout):
+		cmd.Process.Kill()
+		<-done
+		return nil, fmt.Errorf("plugin %s timed out after %s", c.Name, c.Timeout)
+	}
+
+	logging.Debug("plugin call", "plugin", c.Name, "method", req.Method, "duration", time.Since(start), "error", err)
+
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("plugin %s failed: %w: %s", c.Name, err, msg)
+		}
+		return nil, fmt.Errorf("plugin %s failed: %w", c.Name, err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode plugin response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", c.Name, resp.Error)
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,63 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"auto-git/pkg/conventional"
+)
+
+// FindBanned returns the first of words that appears in the description of
+// subject, ignoring case and the "type(scope): " prefix, or "". Words and
+// phrases only match whole, so "WIP" doesn't match "wipe".
+func FindBanned(subject string, words []string) string {
+	_, subject = conventional.SplitEmoji(subject)
+	if m, err := conventional.Parse(subject); err == nil {
+		subject = m.Description
+	}
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		pattern := regexp.MustCompile(`(?i)(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(word) + `($|[^\p{L}\p{N}])`)
+		if pattern.MatchString(subject) {
+			return word
+		}
+	}
+	return ""
+}
+
+// AddBannedWords asks the model to avoid words, ahead of the closing line of
+// a user prompt
+func AddBannedWords(userPrompt string, words []string) string {
+	var quoted []string
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, fmt.Sprintf("%q", word))
+		}
+	}
+	if len(quoted) == 0 {
+		return userPrompt
+	}
+	return beforeClosing(userPrompt, "- Never use these words or phrases in the message: "+strings.Join(quoted, ", ")+".")
+}
+
+// AddRejection quotes a rejected reply and the reason back to the model,
+// ahead of the closing line of a user prompt
+func AddRejection(userPrompt, reply string, reason error) string {
+	note := fmt.Sprintf("\n=== REJECTED REPLY ===\nYour previous reply was rejected (%v):\n%s\nDo not repeat the mistake.",
+		reason, strings.TrimSpace(reply))
+	return beforeClosing(userPrompt, note)
+}
+
+// beforeClosing inserts lines ahead of the blank line and closing line that
+// end a user prompt
+func beforeClosing(userPrompt, lines string) string {
+	i := strings.LastIndex(userPrompt, "\n\n")
+	if i < 0 {
+		return userPrompt + "\n\n" + lines
+	}
+	return userPrompt[:i] + "\n" + lines + userPrompt[i:]
+}
@@ -1,59 +1,154 @@
 package prompt
 
 import (
+	"slices"
 	"strings"
 
 	"auto-git/internal/git"
+	"auto-git/pkg/conventional"
 )
 
+// subjectOutputGuideline is the system prompt line describing the reply
+const subjectOutputGuideline = `- Output exactly one line containing only the commit message (no explanations, code fences, or prefixes such as "Commit message:")`
+
+// replyFormat holds the parts of the user prompt that depend on the kind of
+// reply expected
+type replyFormat struct {
+	// respond is the first requirement, describing the reply
+	respond string
+	// details are further requirements on the reply's parts
+	details []string
+	// extras says what the reply must not contain
+	extras string
+	// closing ends the prompt
+	closing string
+}
+
+// subjectReply asks for a single commit message line
+var subjectReply = replyFormat{
+	respond: "- Respond with exactly one line containing only the commit message.",
+	extras:  "- Do NOT include explanations, bullet lists, code fences, or backticks.",
+	closing: "Return only the commit message text:",
+}
+
 func BuildSystemPrompt() string {
 	return `You are an expert git commit message writer. Your task is to analyze git changes and generate concise, meaningful commit messages following the Conventional Commits specification.
 
 Guidelines:
-- Use conventional commit format: <type>(<scope>): <subject> or <emoji> <type>(<scope>): <subject>
+` + formatGuideline + `
 - Types (STRICT - use exactly these): feat (new feature), fix (bug fix), core (core functionality), edit (edits/modifications), del (deletions), chore (maintenance), docs (documentation), style (formatting), refactor (code restructuring), perf (performance), test (tests), ci (CI/CD)
-- Use emojis when appropriate (e.g., ✨ for feat, 🐛 for fix, 🗑️ for del, 📝 for docs, ♻️ for refactor, ⚡ for perf, 🎨 for style, 🔧 for chore)
+` + emojiGuideline + `
 - Keep messages compact but descriptive - prioritize clarity over strict length limits
 - Use imperative mood ("add feature" not "added feature")
 - Be specific and descriptive
 - If multiple types apply, choose the most significant one
-- Output exactly one line containing only the commit message (no explanations, code fences, or prefixes such as "Commit message:")
+` + subjectOutputGuideline + `
 - Type must be lowercase and match one of the valid types exactly
 `
 }
 
 func BuildUserPrompt(changes *git.Changes, diffContent string) string {
+	return buildUserPrompt(changes, diffContent, subjectReply)
+}
+
+func buildUserPrompt(changes *git.Changes, diffContent string, reply replyFormat) string {
 	var parts []string
 
 	parts = append(parts, "Analyze the following git changes and generate an appropriate commit message:")
 	parts = append(parts, "")
-	parts = append(parts, "=== CHANGE SUMMARY ===")
-	parts = append(parts, changes.Summary)
-	parts = append(parts, "")
-	parts = append(parts, "=== DIFF CONTENT ===")
-	parts = append(parts, diffContent)
+	parts = append(parts, manifestSection(ManifestMarker, changes)...)
+	if sections := fileSections(changes, diffContent); sections != "" {
+		parts = append(parts, filesMarker)
+		parts = append(parts, sections)
+	} else {
+		parts = append(parts, "=== DIFF CONTENT ===")
+		parts = append(parts, diffContent)
+	}
+	todos := todoSection(diffContent)
+	if todos != "" {
+		parts = append(parts, "")
+		parts = append(parts, todos)
+	}
 	parts = append(parts, "")
 	parts = append(parts, "Requirements:")
-	parts = append(parts, "- Respond with exactly one line containing only the commit message.")
-	parts = append(parts, "- Use the format <emoji> <type>(<optional scope>): <subject> or <type>(<scope>): <subject> (emojis are optional but encouraged).")
+	parts = append(parts, reply.respond)
+	parts = append(parts, reply.details...)
+	parts = append(parts, formatRequirement)
 	parts = append(parts, "- Type MUST be one of: feat, fix, core, edit, del, chore, docs, style, refactor, perf, test, ci (lowercase, exact match).")
 	parts = append(parts, "- Keep messages compact but descriptive - no strict length limit, prioritize clarity.")
 	parts = append(parts, "- Write in imperative mood.")
-	parts = append(parts, "- Do NOT include explanations, bullet lists, code fences, or backticks.")
+	parts = append(parts, reply.extras)
 	parts = append(parts, "- If unsure, default the type to chore.")
+	if hint := categoryHint(changes); hint != "" {
+		parts = append(parts, hint)
+	}
+	if anyChange(changes, func(f git.FileChange) bool { return f.WhitespaceOnly }) {
+		parts = append(parts, "- Files marked \"(formatting only)\" changed only whitespace. If every change is formatting only, use the style type.")
+	}
+	if anyChange(changes, func(f git.FileChange) bool { return f.ModeNote() != "" }) {
+		parts = append(parts, "- Notes such as \"made executable\" or \"symlink to ...\" describe file mode and symlink changes, which have no diff lines. Mention them when they are the point of the change.")
+	}
+	if anyChange(changes, func(f git.FileChange) bool { return len(f.Symbols) > 0 }) {
+		parts = append(parts, "- \"Symbols:\" lines list the functions and types each file adds, modifies or removes. Use them to tell refactors, such as renames, moves and signature changes, from new features and fixes, and name the key symbol when it clarifies the subject.")
+	}
+	if todos != "" {
+		parts = append(parts, "- The TODO/FIXME list shows tagged comments the change adds or resolves. Mention them when they are the point of the change, e.g. a fix that resolves a FIXME, not in passing.")
+	}
+	if anyChange(changes, func(f git.FileChange) bool { return f.Vendored }) {
+		parts = append(parts, "- Diffs of vendored dependencies are omitted. Describe them as a dependency update rather than guessing at their contents; if nothing else changed, use the chore type.")
+	}
 	parts = append(parts, "")
-	parts = append(parts, "Return only the commit message text:")
+	parts = append(parts, reply.closing)
 
 	return strings.Join(parts, "\n")
 }
 
+// anyChange reports whether match holds for any changed file
+func anyChange(changes *git.Changes, match func(git.FileChange) bool) bool {
+	for _, files := range [][]git.FileChange{changes.Staged, changes.Unstaged} {
+		for _, f := range files {
+			if match(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddStyleGuide inserts repository-specific style instructions into a user
+// prompt, ahead of the generic requirements
+func AddStyleGuide(userPrompt, guide string) string {
+	if strings.TrimSpace(guide) == "" {
+		return userPrompt
+	}
+	section := "=== REPOSITORY STYLE ===\n" + guide + "\n\n"
+	if i := strings.LastIndex(userPrompt, "Requirements:"); i >= 0 {
+		return userPrompt[:i] + section + userPrompt[i:]
+	}
+	return userPrompt + "\n\n" + section
+}
+
 func BuildFullPrompt(changes *git.Changes, diffContent string) (string, string) {
 	systemPrompt := BuildSystemPrompt()
 	userPrompt := BuildUserPrompt(changes, diffContent)
 	return systemPrompt, userPrompt
 }
 
+// ExtractCommitMessage takes the commit message line from a model reply and
+// normalizes its type
 func ExtractCommitMessage(response string) string {
+	return NormalizeCommitType(ExtractMessageLine(response))
+}
+
+// NormalizeCommitType fixes the type of a commit message subject: a known
+// type is lowercased, and a subject without one gets "chore: "
+func NormalizeCommitType(subject string) string {
+	return conventional.Normalize(subject, commitTypes, "chore")
+}
+
+// ExtractMessageLine takes the commit message line from a model reply,
+// dropping code fences and a "Commit message:" prefix, without normalizing it
+func ExtractMessageLine(response string) string {
 	response = strings.TrimSpace(response)
 	
 	lines := strings.Split(response, "\n")
@@ -86,105 +181,20 @@ func ExtractCommitMessage(response string) string {
 		firstLine = strings.TrimSpace(firstLine)
 	}
 
-	// Validate and normalize commit type
-	firstLine = validateAndNormalizeCommitType(firstLine)
-
 	return firstLine
 }
 
-// Valid commit types (must be lowercase)
-var validCommitTypes = map[string]bool{
-	"feat":     true,
-	"fix":      true,
-	"core":     true,
-	"edit":     true,
-	"del":      true,
-	"chore":    true,
-	"docs":     true,
-	"style":    true,
-	"refactor": true,
-	"perf":     true,
-	"test":     true,
-	"ci":       true,
+// IsCommitType reports whether name is one of the commit types the prompt allows
+func IsCommitType(name string) bool {
+	return slices.Contains(commitTypes, name)
 }
 
-func validateAndNormalizeCommitType(message string) string {
-	// Pattern: [emoji] type(scope): subject or type(scope): subject or type: subject
-	// Extract the type part
-	parts := strings.Fields(message)
-	if len(parts) == 0 {
-		return message
-	}
-
-	// Find the type - it's either the first part (if no emoji) or second part (if emoji present)
-	typeIndex := 0
-	// Check if first part is likely an emoji (contains non-ASCII or is a single character)
-	if len(parts) > 1 && (len([]rune(parts[0])) == 1 || !isASCII(parts[0])) {
-		typeIndex = 1
-	}
-
-	if typeIndex >= len(parts) {
-		return message
-	}
-
-	typePart := parts[typeIndex]
-	
-	// Extract type from "type(scope):" or "type:"
-	typeName := ""
-	if strings.Contains(typePart, "(") {
-		// Format: type(scope):
-		idx := strings.Index(typePart, "(")
-		typeName = strings.ToLower(typePart[:idx])
-	} else if strings.Contains(typePart, ":") {
-		// Format: type:
-		idx := strings.Index(typePart, ":")
-		typeName = strings.ToLower(typePart[:idx])
-	} else {
-		// No colon found, might be just the type word
-		typeName = strings.ToLower(typePart)
-	}
-
-	// Validate type
-	if !validCommitTypes[typeName] {
-		// If type is invalid, try to fix common issues or default to chore
-		// Check if it's a known type with wrong case
-		for validType := range validCommitTypes {
-			if strings.EqualFold(typeName, validType) {
-				// Replace with correct lowercase type
-				if typeIndex == 0 {
-					parts[0] = strings.Replace(parts[0], typePart, validType, 1)
-				} else {
-					parts[typeIndex] = strings.Replace(parts[typeIndex], typePart, validType, 1)
-				}
-				return strings.Join(parts, " ")
-			}
-		}
-		// If still not found, prepend "chore: " if message doesn't start with a valid type
-		if !strings.HasPrefix(strings.ToLower(message), "chore") &&
-			!strings.HasPrefix(strings.ToLower(message), "feat") &&
-			!strings.HasPrefix(strings.ToLower(message), "fix") {
-			return "chore: " + message
-		}
-	} else {
-		// Type is valid, ensure it's lowercase in the message
-		if typeName != typePart {
-			correctedPart := strings.Replace(parts[typeIndex], typePart, typeName, 1)
-			parts[typeIndex] = correctedPart
-			return strings.Join(parts, " ")
-		}
-	}
-
-	return message
+// CommitTypes returns the commit types the prompt allows, all lowercase
+func CommitTypes() []string {
+	return slices.Clone(commitTypes)
 }
 
-func isASCII(s string) bool {
-	for _, r := range s {
-		if r > 127 {
-			return false
-		}
-	}
-	return true
-}
+var commitTypes = []string{"feat", "fix", "core", "edit", "del", "chore", "docs", "style", "refactor", "perf", "test", "ci"}
 
 func AnalyzeChangeTypes(changes *git.Changes) []string {
 	typeCount := make(map[string]int)
@@ -236,7 +246,7 @@ func SuggestCommitType(changes *git.Changes) string {
 		return "feat"
 	}
 	if hasModifications {
-		return "fix"
+		return "edit"
 	}
 	return "chore"
 }
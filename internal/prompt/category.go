@@ -0,0 +1,144 @@
+package prompt
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"auto-git/internal/git"
+)
+
+// FileCategory is the kind of file a path holds, judged by its name
+type FileCategory string
+
+const (
+	CategoryDocs   FileCategory = "docs"
+	CategoryTest   FileCategory = "test"
+	CategoryCI     FileCategory = "ci"
+	CategoryBuild  FileCategory = "build"
+	CategorySource FileCategory = "source"
+)
+
+// categoryTypes is the commit type suggested when every changed file is in
+// the category; source files need the diff to be classified
+var categoryTypes = map[FileCategory]string{
+	CategoryDocs:  "docs",
+	CategoryTest:  "test",
+	CategoryCI:    "ci",
+	CategoryBuild: "chore",
+}
+
+var (
+	ciPaths    = []string{".github/workflows/", ".gitlab-ci", ".circleci/", ".buildkite/", ".travis.yml", "jenkinsfile", "azure-pipelines", "bitbucket-pipelines.yml", ".drone.yml"}
+	buildFiles = map[string]bool{
+		"makefile": true, "dockerfile": true, "go.mod": true, "go.sum": true, "package.json": true,
+		"package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true, "cargo.toml": true, "cargo.lock": true,
+		"pom.xml": true, "build.gradle": true, "build.gradle.kts": true, "pyproject.toml": true, "setup.py": true,
+		"setup.cfg": true, "requirements.txt": true, "gemfile": true, "gemfile.lock": true, "cmakelists.txt": true,
+	}
+	docExts     = map[string]bool{".md": true, ".markdown": true, ".rst": true, ".adoc": true, ".txt": true}
+	docPrefixes = []string{"readme", "changelog", "license", "contributing", "authors", "notice"}
+)
+
+// Categorize guesses the category of a file from its path
+func Categorize(p string) FileCategory {
+	lower := strings.ToLower(p)
+	base := path.Base(lower)
+	ext := path.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	for _, ci := range ciPaths {
+		if strings.HasPrefix(lower, ci) || base == ci {
+			return CategoryCI
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(name, "_test"), strings.HasPrefix(name, "test_"),
+		strings.HasSuffix(name, ".test"), strings.HasSuffix(name, ".spec"), strings.HasSuffix(name, "_spec"),
+		strings.HasSuffix(base, "test.java"), strings.HasSuffix(base, "tests.cs"):
+		return CategoryTest
+	}
+	for _, dir := range []string{"test/", "tests/", "__tests__/", "testdata/", "spec/"} {
+		if strings.HasPrefix(lower, dir) || strings.Contains(lower, "/"+dir) {
+			return CategoryTest
+		}
+	}
+
+	if buildFiles[base] || strings.HasPrefix(base, "dockerfile") || strings.HasPrefix(base, "requirements") && ext == ".txt" {
+		return CategoryBuild
+	}
+
+	if docExts[ext] || strings.HasPrefix(lower, "docs/") || strings.HasPrefix(lower, "doc/") {
+		return CategoryDocs
+	}
+	for _, prefix := range docPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return CategoryDocs
+		}
+	}
+	return CategorySource
+}
+
+// categoryCounts counts the changed files of each category
+func categoryCounts(changes *git.Changes) map[FileCategory]int {
+	counts := map[FileCategory]int{}
+	for _, files := range [][]git.FileChange{changes.Staged, changes.Unstaged} {
+		for _, f := range files {
+			if f.Vendored {
+				// Vendored code is a dependency, whatever its extension
+				counts[CategoryBuild]++
+				continue
+			}
+			counts[Categorize(f.Path)]++
+		}
+	}
+	return counts
+}
+
+// CategoryType returns the commit type implied by the changed files when all
+// of them are docs, tests, CI or build files
+func CategoryType(changes *git.Changes) (string, bool) {
+	counts := categoryCounts(changes)
+	if len(counts) != 1 {
+		return "", false
+	}
+	for category := range counts {
+		commitType, ok := categoryTypes[category]
+		return commitType, ok
+	}
+	return "", false
+}
+
+// categoryHint returns a prompt requirement about the kinds of changed files,
+// or "" when there are no files
+func categoryHint(changes *git.Changes) string {
+	counts := categoryCounts(changes)
+	if len(counts) == 0 {
+		return ""
+	}
+	if commitType, ok := CategoryType(changes); ok {
+		for category := range counts {
+			return fmt.Sprintf("- Every changed file is a %s file, so the type should be %s.", category, commitType)
+		}
+	}
+
+	categories := make([]string, 0, len(counts))
+	for category := range counts {
+		categories = append(categories, string(category))
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return counts[FileCategory(categories[i])] > counts[FileCategory(categories[j])] ||
+			counts[FileCategory(categories[i])] == counts[FileCategory(categories[j])] && categories[i] < categories[j]
+	})
+	described := make([]string, len(categories))
+	for i, category := range categories {
+		described[i] = fmt.Sprintf("%d %s", counts[FileCategory(category)], category)
+	}
+	hint := "- Changed files: " + strings.Join(described, ", ") + "."
+	if counts[CategorySource] > 0 {
+		hint += " Choose the type from the source changes; tests and docs that accompany them don't change it."
+	}
+	return hint
+}
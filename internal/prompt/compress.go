@@ -0,0 +1,178 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"auto-git/internal/git"
+)
+
+// Diff compression levels
+const (
+	// CompressOff sends the diff as git produced it
+	CompressOff = "off"
+	// CompressLight keeps one context line around each change and collapses
+	// repeated hunks and blocks
+	CompressLight = "light"
+	// CompressAggressive is CompressLight without any context lines
+	CompressAggressive = "aggressive"
+)
+
+// minRepeatedBlock is how many added or removed lines in a row it takes for
+// a repeat of them to be collapsed
+const minRepeatedBlock = 4
+
+// ParseCompression validates a diff compression level; empty means
+// CompressOff
+func ParseCompression(level string) (string, error) {
+	switch level = strings.ToLower(strings.TrimSpace(level)); level {
+	case "":
+		return CompressOff, nil
+	case CompressOff, CompressLight, CompressAggressive:
+		return level, nil
+	}
+	return "", fmt.Errorf("unknown diff compression %q (supported: %s, %s, %s)", level, CompressOff, CompressLight, CompressAggressive)
+}
+
+// CompressDiff shrinks a diff for the prompt without losing changed lines:
+// context lines beyond those next to a change are dropped, a hunk making the
+// same change as an earlier one is replaced by a note naming its file, and
+// so is a repeat of a block of added or removed lines, such as generated code
+// pasted into several files. Headers and "===" sections are kept.
+func CompressDiff(diff, level string) string {
+	keep := 1
+	switch level {
+	case CompressLight:
+	case CompressAggressive:
+		keep = 0
+	default:
+		return diff
+	}
+
+	c := compressor{keep: keep, hunks: map[string]string{}, blocks: map[string]string{}}
+	sections := splitDiffSections(diff)
+	for i, section := range sections {
+		if strings.HasPrefix(section, "diff --git ") {
+			sections[i] = c.section(section)
+		}
+	}
+	return strings.Join(sections, "")
+}
+
+// compressor remembers the hunks and blocks seen so far, keyed by their
+// changed lines, with the path of the file they were first seen in
+type compressor struct {
+	keep   int
+	hunks  map[string]string
+	blocks map[string]string
+}
+
+// section compresses the hunks of one file's diff
+func (c compressor) section(section string) string {
+	path := ""
+	if patches := git.SplitPatch(section); len(patches) > 0 {
+		path = patches[0].Path
+	}
+
+	lines := strings.SplitAfter(section, "\n")
+	i := 0
+	for i < len(lines) && !strings.HasPrefix(lines[i], "@@") {
+		i++
+	}
+	out := append([]string{}, lines[:i]...)
+	for i < len(lines) {
+		end := i + 1
+		for end < len(lines) && !strings.HasPrefix(lines[end], "@@") {
+			end++
+		}
+		out = append(out, c.hunk(lines[i], lines[i+1:end], path)...)
+		i = end
+	}
+	return strings.Join(out, "")
+}
+
+// hunk compresses the body of a hunk, keeping its header
+func (c compressor) hunk(header string, body []string, path string) []string {
+	var changed strings.Builder
+	for _, line := range body {
+		if isChangeLine(line) {
+			changed.WriteString(line)
+		}
+	}
+	if key := changed.String(); key != "" {
+		if first, ok := c.hunks[key]; ok {
+			return []string{header, fmt.Sprintf("... (same change as in %s)\n", first)}
+		}
+		c.hunks[key] = path
+	}
+	return append([]string{header}, c.collapseBlocks(trimContext(body, c.keep), path)...)
+}
+
+// collapseBlocks replaces runs of added or removed lines seen before with a
+// note
+func (c compressor) collapseBlocks(body []string, path string) []string {
+	var out []string
+	for i := 0; i < len(body); {
+		if !isChangeLine(body[i]) {
+			out = append(out, body[i])
+			i++
+			continue
+		}
+		sign := body[i][0]
+		end := i + 1
+		for end < len(body) && isChangeLine(body[end]) && body[end][0] == sign {
+			end++
+		}
+		if end-i >= minRepeatedBlock {
+			key := strings.Join(body[i:end], "")
+			if first, ok := c.blocks[key]; ok {
+				kind := "added"
+				if sign == '-' {
+					kind = "removed"
+				}
+				out = append(out, fmt.Sprintf("... (%d %s lines identical to a block in %s)\n", end-i, kind, first))
+				i = end
+				continue
+			}
+			c.blocks[key] = path
+		}
+		out = append(out, body[i:end]...)
+		i = end
+	}
+	return out
+}
+
+// trimContext keeps at most keep context lines before and after each change
+func trimContext(body []string, keep int) []string {
+	var out []string
+	for i := 0; i < len(body); {
+		if !strings.HasPrefix(body[i], " ") {
+			out = append(out, body[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(body) && strings.HasPrefix(body[end], " ") {
+			end++
+		}
+		head, tail := 0, 0
+		if i > 0 {
+			head = keep
+		}
+		if end < len(body) && body[end] != "" {
+			tail = keep
+		}
+		if head+tail >= end-i {
+			out = append(out, body[i:end]...)
+		} else {
+			out = append(out, body[i:i+head]...)
+			out = append(out, body[end-tail:end]...)
+		}
+		i = end
+	}
+	return out
+}
+
+func isChangeLine(line string) bool {
+	return strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")
+}
@@ -0,0 +1,153 @@
+package prompt
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"auto-git/internal/git"
+)
+
+// FallbackMessage builds a commit message from the change set alone, for when
+// no model is reachable. The result is rule-based and only meant to be passable,
+// e.g. "edit(config): update config.go and scanner.go".
+func FallbackMessage(changes *git.Changes) string {
+	files := append(append([]git.FileChange{}, changes.Staged...), changes.Unstaged...)
+	if len(files) == 0 {
+		if purpose, ok := emptyCommitPurpose(changes); ok {
+			return "ci: " + purpose
+		}
+		return "chore: update files"
+	}
+
+	if vendoredOnly(files) {
+		return "chore(deps): update vendored dependencies"
+	}
+	if modesOnly(files) {
+		if allNotes(files, "made executable") {
+			return "chore: make " + describeFiles(files) + " executable"
+		}
+		return "chore: change the mode of " + describeFiles(files)
+	}
+
+	commitType := SuggestCommitType(changes)
+	verb := fallbackVerb(commitType)
+	if formattingOnly(files) {
+		commitType, verb = "style", "format"
+	} else if categoryType, ok := CategoryType(changes); ok {
+		// Keep the verb: "docs: add README.md" rather than "docs: update README.md"
+		commitType = categoryType
+	}
+
+	// Scope the message to the directory of the most-changed file
+	main := files[0]
+	for _, f := range files[1:] {
+		if f.Additions+f.Deletions > main.Additions+main.Deletions {
+			main = f
+		}
+	}
+	scope := path.Base(path.Dir(main.Path))
+	if scope == "." || scope == "/" {
+		scope = ""
+	}
+
+	header := commitType
+	if scope != "" {
+		header = fmt.Sprintf("%s(%s)", commitType, scope)
+	}
+	return fmt.Sprintf("%s: %s %s", header, verb, describeFiles(files))
+}
+
+func fallbackVerb(commitType string) string {
+	switch commitType {
+	case "feat":
+		return "add"
+	case "del":
+		return "remove"
+	default:
+		return "update"
+	}
+}
+
+// formattingOnly reports whether every file changed only in whitespace or
+// line endings
+func formattingOnly(files []git.FileChange) bool {
+	for _, f := range files {
+		if !f.WhitespaceOnly && !f.LineEndingsOnly {
+			return false
+		}
+	}
+	return true
+}
+
+// modesOnly reports whether every file only changed its mode, which adds
+// and removes no lines
+func modesOnly(files []git.FileChange) bool {
+	for _, f := range files {
+		if f.ModeNote() == "" || f.LinkTarget != "" || f.Additions+f.Deletions > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// allNotes reports whether every file has the mode note note
+func allNotes(files []git.FileChange, note string) bool {
+	for _, f := range files {
+		if f.ModeNote() != note {
+			return false
+		}
+	}
+	return true
+}
+
+// vendoredOnly reports whether every file is vendored third-party code
+func vendoredOnly(files []git.FileChange) bool {
+	for _, f := range files {
+		if !f.Vendored {
+			return false
+		}
+	}
+	return true
+}
+
+// describeFiles lists up to two file names and counts the rest
+func describeFiles(files []git.FileChange) string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, f := range files {
+		name := path.Base(f.Path)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	switch len(names) {
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " and " + names[1]
+	case 3:
+		return strings.Join(names[:2], ", ") + " and 1 other file"
+	default:
+		return fmt.Sprintf("%s and %d other files", strings.Join(names[:2], ", "), len(names)-2)
+	}
+}
+
+// emptyCommitSummary starts the summary of an empty commit, before its purpose
+const emptyCommitSummary = "No files changed. This is an empty commit whose purpose is: "
+
+// EmptyCommitChanges describes an empty commit so the model can write a
+// message for its purpose, e.g. "trigger deploy"
+func EmptyCommitChanges(purpose string) *git.Changes {
+	return &git.Changes{
+		Context: emptyCommitSummary + purpose + "\nUse the ci type if the commit triggers automation, otherwise chore.",
+	}
+}
+
+func emptyCommitPurpose(changes *git.Changes) (string, bool) {
+	first, _, _ := strings.Cut(changes.Context, "\n")
+	purpose, ok := strings.CutPrefix(first, emptyCommitSummary)
+	return purpose, ok && purpose != ""
+}
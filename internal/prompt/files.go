@@ -0,0 +1,138 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"auto-git/internal/git"
+	"auto-git/internal/symbols"
+)
+
+const (
+	// filesMarker precedes the per-file sections in the user prompt
+	filesMarker = "=== FILES ==="
+	// maxSymbols is the number of changed symbols named per file
+	maxSymbols = 15
+)
+
+// fileSections renders the diff as one section per file: a heading with the
+// path, kind of change and line counts, the functions or types whose hunks
+// changed, and the hunks themselves. It returns "" when the diff contains no
+// file patches.
+func fileSections(changes *git.Changes, diffContent string) string {
+	patches := git.SplitPatch(diffContent)
+	sections := make([]string, 0, len(patches))
+	for _, p := range patches {
+		sections = append(sections, fileSection(p, findChange(changes, p)))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+func fileSection(p git.FilePatch, change *git.FileChange) string {
+	details := []string{patchKind(p.Patch)}
+	if change != nil {
+		details = append(details, fmt.Sprintf("+%d -%d", change.Additions, change.Deletions))
+		if note := change.ModeNote(); note != "" {
+			details = append(details, note)
+		}
+		switch {
+		case change.LineEndingsOnly:
+			details = append(details, "line endings only")
+		case change.WhitespaceOnly:
+			details = append(details, "formatting only")
+		case change.Generated:
+			details = append(details, "generated")
+		}
+	}
+	switch {
+	case p.Staged:
+		details = append(details, "staged")
+	case p.Unstaged:
+		details = append(details, "unstaged")
+	}
+
+	heading := "### " + p.Path
+	if len(details) > 0 {
+		heading += " (" + strings.Join(details, ", ") + ")"
+	}
+	lines := []string{heading}
+
+	var body []string
+	var contexts []string
+	seen := map[string]bool{}
+	for _, line := range strings.SplitAfter(p.Patch, "\n") {
+		// The path is in the heading; the remaining header lines add nothing
+		if line == "" || strings.HasPrefix(line, "diff --git ") || strings.HasPrefix(line, "index ") ||
+			strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") {
+			continue
+		}
+		if ctx := hunkContext(line); ctx != "" && !seen[ctx] {
+			seen[ctx] = true
+			contexts = append(contexts, ctx)
+		}
+		body = append(body, strings.TrimSuffix(line, "\n"))
+	}
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+	if change != nil && len(change.Symbols) > 0 {
+		lines = append(lines, "Symbols: "+symbols.Summary(change.Symbols, maxSymbols))
+	}
+	if len(contexts) > 0 {
+		lines = append(lines, "Changed in: "+strings.Join(contexts, "; "))
+	}
+	return strings.Join(append(lines, body...), "\n")
+}
+
+// patchKind tells from the extended header lines of a patch whether the file
+// was created, deleted, renamed or modified
+func patchKind(patch string) string {
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			return "modified"
+		case strings.HasPrefix(line, "new file mode"):
+			return "new file"
+		case strings.HasPrefix(line, "deleted file mode"):
+			return "deleted file"
+		case strings.HasPrefix(line, "rename from"):
+			return "renamed"
+		}
+	}
+	return "modified"
+}
+
+// hunkContext returns the function or type git shows after a hunk header,
+// e.g. "func readDiff(gitRoot string)" for "@@ -1,3 +1,4 @@ func readDiff(gitRoot string)"
+func hunkContext(line string) string {
+	if !strings.HasPrefix(line, "@@") {
+		return ""
+	}
+	rest := line[2:]
+	end := strings.Index(rest, "@@")
+	if end < 0 {
+		return ""
+	}
+	return strings.TrimSpace(rest[end+2:])
+}
+
+// findChange returns the summary entry of the file a patch changes
+func findChange(changes *git.Changes, p git.FilePatch) *git.FileChange {
+	var groups [][]git.FileChange
+	switch {
+	case p.Staged:
+		groups = [][]git.FileChange{changes.Staged}
+	case p.Unstaged:
+		groups = [][]git.FileChange{changes.Unstaged}
+	default:
+		groups = [][]git.FileChange{changes.Staged, changes.Unstaged}
+	}
+	for _, files := range groups {
+		for i := range files {
+			if files[i].Path == p.Path {
+				return &files[i]
+			}
+		}
+	}
+	return nil
+}
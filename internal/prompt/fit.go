@@ -0,0 +1,96 @@
+package prompt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FitDiff shortens a diff to at most budget tokens as measured by count. The
+// budget is shared fairly between files: small files are kept whole and the
+// largest are cut, keeping their headers and first hunks. It returns the diff
+// unchanged if it already fits.
+func FitDiff(diff string, budget int, count func(string) int) string {
+	if count(diff) <= budget {
+		return diff
+	}
+
+	sections := splitDiffSections(diff)
+	cost := make([]int, len(sections))
+	var files []int
+	for i, section := range sections {
+		cost[i] = count(section)
+		if strings.HasPrefix(section, "=== ") {
+			// Section headers such as "=== STAGED CHANGES ===" are always kept
+			budget -= cost[i]
+		} else {
+			files = append(files, i)
+		}
+	}
+	sort.SliceStable(files, func(a, b int) bool { return cost[files[a]] < cost[files[b]] })
+
+	for n, i := range files {
+		share := max(budget, 0) / (len(files) - n)
+		if cost[i] > share {
+			sections[i] = truncateSection(sections[i], share, count)
+			cost[i] = count(sections[i])
+		}
+		budget -= cost[i]
+	}
+	return strings.Join(sections, "")
+}
+
+// splitDiffSections splits a diff before every "diff --git" line and around
+// "=== ... ===" headers, keeping line endings so the parts join back together
+func splitDiffSections(diff string) []string {
+	var sections []string
+	start := 0
+	for i := 0; i < len(diff); {
+		end := strings.IndexByte(diff[i:], '\n')
+		if end < 0 {
+			end = len(diff)
+		} else {
+			end += i + 1
+		}
+		line := diff[i:end]
+		if i > start && (strings.HasPrefix(line, "diff --git ") || strings.HasPrefix(line, "=== ")) {
+			sections = append(sections, diff[start:i])
+			start = i
+		}
+		if strings.HasPrefix(line, "=== ") {
+			sections = append(sections, diff[start:end])
+			start = end
+		}
+		i = end
+	}
+	if start < len(diff) {
+		sections = append(sections, diff[start:])
+	}
+	return sections
+}
+
+// truncateSection keeps the lines of a file's diff that fit in budget tokens,
+// always including the header up to the first hunk, and notes what was cut
+func truncateSection(section string, budget int, count func(string) int) string {
+	lines := strings.SplitAfter(section, "\n")
+	kept, used := 0, 0
+	inHeader := true
+	for ; kept < len(lines); kept++ {
+		if strings.HasPrefix(lines[kept], "@@") {
+			inHeader = false
+		}
+		cost := count(lines[kept])
+		if !inHeader && used+cost > budget {
+			break
+		}
+		used += cost
+	}
+	if kept == len(lines) {
+		return section
+	}
+	omitted := len(lines) - kept
+	if lines[len(lines)-1] == "" {
+		omitted--
+	}
+	return strings.Join(lines[:kept], "") + fmt.Sprintf("... (%d more lines omitted to fit the model's context window)\n", omitted)
+}
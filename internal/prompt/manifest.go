@@ -0,0 +1,201 @@
+package prompt
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"auto-git/internal/git"
+)
+
+// ManifestMarker precedes the JSON change manifest in the user prompt
+const ManifestMarker = "=== CHANGES (JSON) ==="
+
+// manifestEntry describes one changed file, or one vendor directory, in the
+// change manifest
+type manifestEntry struct {
+	Path      string   `json:"path"`
+	OldPath   string   `json:"old_path,omitempty"`
+	Type      string   `json:"type"`
+	Additions int      `json:"add"`
+	Deletions int      `json:"del"`
+	Language  string   `json:"lang,omitempty"`
+	Area      string   `json:"area"`
+	Files     int      `json:"files,omitempty"`
+	Notes     []string `json:"notes,omitempty"`
+}
+
+// vendoredType is the type of the manifest entry that stands for a whole
+// vendor directory
+const vendoredType = "vendored"
+
+// Manifest renders the changed files as a JSON array with one object per
+// line, e.g. {"path":"cmd/root.go","type":"edit","add":3,"del":1,"lang":"Go","area":"staged"}.
+// Vendored files are collapsed into one entry per vendor directory, as in
+// the terminal summary.
+func Manifest(changes *git.Changes) string {
+	var lines []string
+	for _, group := range []struct {
+		area  string
+		files []git.FileChange
+	}{{"staged", changes.Staged}, {"unstaged", changes.Unstaged}} {
+		vendored := map[string]int{}
+		var entries []*manifestEntry
+		for _, f := range group.files {
+			if f.Vendored {
+				root := git.VendorRoot(f.Path)
+				if root == "" {
+					root = f.Path
+				}
+				i, ok := vendored[root]
+				if !ok {
+					i = len(entries)
+					vendored[root] = i
+					entries = append(entries, &manifestEntry{Path: root, Type: vendoredType, Area: group.area})
+				}
+				entries[i].Additions += f.Additions
+				entries[i].Deletions += f.Deletions
+				entries[i].Files++
+				continue
+			}
+			entries = append(entries, &manifestEntry{
+				Path:      f.Path,
+				OldPath:   f.OldPath,
+				Type:      string(f.Type),
+				Additions: f.Additions,
+				Deletions: f.Deletions,
+				Language:  Language(f.Path),
+				Area:      group.area,
+				Notes:     fileNotes(f),
+			})
+		}
+		for _, e := range entries {
+			line, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			lines = append(lines, string(line))
+		}
+	}
+	if len(lines) == 0 {
+		return "[]"
+	}
+	return "[\n" + strings.Join(lines, ",\n") + "\n]"
+}
+
+// fileNotes lists what the line counts of a change do not tell, e.g. that
+// it only reformats the file
+func fileNotes(f git.FileChange) []string {
+	var notes []string
+	if note := f.ModeNote(); note != "" {
+		notes = append(notes, note)
+	}
+	switch {
+	case f.LineEndingsOnly:
+		notes = append(notes, "line endings only")
+	case f.WhitespaceOnly:
+		notes = append(notes, "formatting only")
+	case f.Generated:
+		notes = append(notes, "generated")
+	}
+	return notes
+}
+
+// manifestSection renders the manifest under heading, followed by the
+// context callers added to the changes, if any
+func manifestSection(heading string, changes *git.Changes) []string {
+	parts := []string{heading, Manifest(changes), ""}
+	if context := strings.TrimSpace(changes.Context); context != "" {
+		parts = append(parts, "=== CONTEXT ===", context, "")
+	}
+	return parts
+}
+
+// ParseManifest reads the change manifest of a user prompt back into file
+// changes. Vendor directories become one vendored change each. It returns
+// false when the prompt has no manifest.
+func ParseManifest(userPrompt string) ([]git.FileChange, bool) {
+	_, rest, ok := strings.Cut(userPrompt, ManifestMarker)
+	if !ok {
+		return nil, false
+	}
+	var entries []manifestEntry
+	if err := json.NewDecoder(strings.NewReader(rest)).Decode(&entries); err != nil {
+		return nil, false
+	}
+
+	files := make([]git.FileChange, 0, len(entries))
+	for _, e := range entries {
+		f := git.FileChange{
+			Path:      e.Path,
+			OldPath:   e.OldPath,
+			Type:      git.ChangeType(e.Type),
+			Additions: e.Additions,
+			Deletions: e.Deletions,
+		}
+		if e.Type == vendoredType {
+			f.Type, f.Vendored = git.ChangeTypeModified, true
+		}
+		files = append(files, f)
+	}
+	return files, true
+}
+
+// languagesByExt names the language of a file by its extension
+var languagesByExt = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".cjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".rs":    "Rust",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".cs":    "C#",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".swift": "Swift",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".zsh":   "Shell",
+	".sql":   "SQL",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "SCSS",
+	".vue":   "Vue",
+	".md":    "Markdown",
+	".rst":   "reStructuredText",
+	".json":  "JSON",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".toml":  "TOML",
+	".xml":   "XML",
+	".proto": "Protocol Buffers",
+}
+
+// languagesByName names the language of files known by their name alone
+var languagesByName = map[string]string{
+	"dockerfile":  "Dockerfile",
+	"makefile":    "Makefile",
+	"gnumakefile": "Makefile",
+	"go.mod":      "Go module",
+	"go.sum":      "Go module",
+}
+
+// Language names the language of a file from its name, or returns "" when
+// it is not known
+func Language(p string) string {
+	base := strings.ToLower(path.Base(p))
+	if lang, ok := languagesByName[base]; ok {
+		return lang
+	}
+	return languagesByExt[path.Ext(base)]
+}
@@ -0,0 +1,95 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"auto-git/internal/git"
+)
+
+// maxMergeCommits caps how many merged commit subjects are listed
+const maxMergeCommits = 50
+
+func BuildMergeSystemPrompt() string {
+	return `You are an expert git commit message writer. Your task is to summarize what a merge brings into the current branch, for the body of a merge commit.
+
+Guidelines:
+- Write 1-3 short sentences or a few "- " bullets describing the merged work as a whole
+- Base the summary on the merged commits and the diff; group related commits instead of listing them all
+- Use plain prose; do not use a Conventional Commits prefix
+- Do not repeat the merge subject line and do not mention conflicts; both are added separately
+- Output only the summary text (no explanations, code fences, or prefixes such as "Summary:")
+`
+}
+
+func BuildMergeUserPrompt(state *git.MergeState, changes *git.Changes, diffContent string) string {
+	var parts []string
+
+	parts = append(parts, "Summarize the following merge for the body of its commit message:")
+	parts = append(parts, "")
+	parts = append(parts, "=== MERGE ===")
+	parts = append(parts, state.Subject)
+	parts = append(parts, "")
+	parts = append(parts, "=== MERGED COMMITS ===")
+	parts = append(parts, limitLines(state.Commits, maxMergeCommits)...)
+	parts = append(parts, "")
+	parts = append(parts, manifestSection(ManifestMarker, changes)...)
+	parts = append(parts, "=== DIFF CONTENT ===")
+	parts = append(parts, diffContent)
+	parts = append(parts, "")
+	parts = append(parts, "Return only the summary text:")
+
+	return strings.Join(parts, "\n")
+}
+
+// CleanSummary strips code fences and labels from a model's free-form summary
+func CleanSummary(response string) string {
+	response = strings.TrimSpace(response)
+	response = strings.TrimPrefix(response, "```")
+	response = strings.TrimSuffix(response, "```")
+	response = strings.TrimSpace(response)
+	for _, label := range []string{"Summary:", "summary:"} {
+		response = strings.TrimSpace(strings.TrimPrefix(response, label))
+	}
+	return response
+}
+
+// FallbackMergeSummary lists the merged commits when no summary could be generated
+func FallbackMergeSummary(state *git.MergeState) string {
+	if len(state.Commits) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(state.Commits))
+	for _, subject := range limitLines(state.Commits, maxMergeCommits) {
+		lines = append(lines, "- "+subject)
+	}
+	return "Merged commits:\n" + strings.Join(lines, "\n")
+}
+
+// MergeMessage assembles a merge commit message from git's subject, a summary
+// of the merged work and the conflicts that were resolved, with the side
+// each one kept
+func MergeMessage(state *git.MergeState, summary string) string {
+	parts := []string{state.Subject}
+	if summary = strings.TrimSpace(summary); summary != "" {
+		parts = append(parts, summary)
+	}
+	if len(state.Conflicts) > 0 {
+		lines := []string{"Conflicts resolved:"}
+		for _, file := range state.Conflicts {
+			if resolution, ok := state.Resolutions[file]; ok {
+				file = fmt.Sprintf("%s (%s)", file, resolution)
+			}
+			lines = append(lines, "- "+file)
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func limitLines(lines []string, limit int) []string {
+	if len(lines) <= limit {
+		return lines
+	}
+	return append(lines[:limit:limit], fmt.Sprintf("... and %d more", len(lines)-limit))
+}
@@ -0,0 +1,81 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"auto-git/internal/git"
+)
+
+// PullRequest is a commit message and the pull request text generated with it
+type PullRequest struct {
+	Subject     string `json:"commit_subject"`
+	Body        string `json:"commit_body"`
+	Title       string `json:"pr_title"`
+	Description string `json:"pr_description"`
+}
+
+// CommitMessage joins the subject and body into a commit message
+func (p *PullRequest) CommitMessage() string {
+	if p.Body == "" {
+		return p.Subject
+	}
+	return p.Subject + "\n\n" + p.Body
+}
+
+// prReply asks for the commit message and pull request text as one JSON object
+var prReply = replyFormat{
+	respond: `- Respond with one JSON object: {"commit_subject": "...", "commit_body": "...", "pr_title": "...", "pr_description": "..."}.`,
+	details: []string{
+		"- commit_subject is the commit message on one line, in the format described next.",
+		"- commit_body explains what changed and why in a few lines wrapped at 72 characters; leave it empty for trivial changes.",
+		"- pr_title is a short plain-language title for the pull request, without a type prefix.",
+		"- pr_description is Markdown: a summary paragraph, then a bullet list of the notable changes. It must agree with the commit.",
+	},
+	extras:  "- Do NOT include explanations or code fences around the JSON object.",
+	closing: "Return only the JSON object:",
+}
+
+// BuildPRSystemPrompt is BuildSystemPrompt for a commit message and pull
+// request text generated together
+func BuildPRSystemPrompt() string {
+	return strings.Replace(BuildSystemPrompt(), subjectOutputGuideline,
+		"- Output a single JSON object holding the commit message and a pull request title and description that agree with it", 1)
+}
+
+// BuildPRUserPrompt is BuildUserPrompt asking for the reply ParsePullRequest reads
+func BuildPRUserPrompt(changes *git.Changes, diffContent string) string {
+	return buildUserPrompt(changes, diffContent, prReply)
+}
+
+// IsPRPrompt reports whether a user prompt was built by BuildPRUserPrompt
+func IsPRPrompt(userPrompt string) bool {
+	return strings.HasSuffix(userPrompt, prReply.closing)
+}
+
+// ParsePullRequest reads the JSON object of a reply to BuildPRUserPrompt.
+// Code fences and text around the object are ignored. The subject is cleaned
+// up like ExtractMessageLine does; its type is left for the caller to check.
+func ParsePullRequest(response string) (*PullRequest, error) {
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the reply contains no JSON object")
+	}
+
+	var pr PullRequest
+	if err := json.Unmarshal([]byte(response[start:end+1]), &pr); err != nil {
+		return nil, fmt.Errorf("the reply is not valid JSON: %w", err)
+	}
+	pr.Subject = ExtractMessageLine(pr.Subject)
+	pr.Body = strings.TrimSpace(pr.Body)
+	pr.Title = strings.TrimSpace(pr.Title)
+	pr.Description = strings.TrimSpace(pr.Description)
+	if pr.Subject == "" {
+		return nil, fmt.Errorf("the reply has no commit_subject")
+	}
+	if pr.Title == "" {
+		pr.Title = pr.Subject
+	}
+	return &pr, nil
+}
@@ -0,0 +1,59 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"auto-git/internal/git"
+)
+
+func BuildRevertSystemPrompt() string {
+	return `You are an expert git commit message writer. Your task is to explain a revert, for the body of the commit that reverts an earlier commit.
+
+Guidelines:
+- Write 1-3 short sentences describing what the reverted commit did and what behavior is removed or restored by undoing it
+- If a reason for the revert is given, state it; otherwise do not invent one
+- Use plain prose; do not use a Conventional Commits prefix
+- Do not repeat the subject line or the commit hash; both are added separately
+- Output only the explanation (no code fences or prefixes such as "Summary:")
+`
+}
+
+// BuildRevertUserPrompt describes the commit being reverted: its message and
+// its original diff
+func BuildRevertUserPrompt(originalMessage, reason string, changes *git.Changes, diffContent string) string {
+	var parts []string
+
+	parts = append(parts, "Explain the revert of the following commit:")
+	parts = append(parts, "")
+	parts = append(parts, "=== REVERTED COMMIT MESSAGE ===")
+	parts = append(parts, originalMessage)
+	parts = append(parts, "")
+	if reason = strings.TrimSpace(reason); reason != "" {
+		parts = append(parts, "=== REASON FOR THE REVERT ===")
+		parts = append(parts, reason)
+		parts = append(parts, "")
+	}
+	parts = append(parts, manifestSection("=== CHANGES OF THE REVERTED COMMIT (JSON) ===", changes)...)
+	parts = append(parts, "=== DIFF CONTENT OF THE REVERTED COMMIT ===")
+	parts = append(parts, diffContent)
+	parts = append(parts, "")
+	parts = append(parts, "Return only the explanation:")
+
+	return strings.Join(parts, "\n")
+}
+
+// RevertMessage assembles a revert commit message in git's format, with the
+// explanation between the subject and the "This reverts commit" line. Without
+// an explanation the reason, if any, is used.
+func RevertMessage(originalSubject, commit, explanation, reason string) string {
+	parts := []string{`Revert "` + originalSubject + `"`}
+	if explanation = strings.TrimSpace(explanation); explanation == "" {
+		explanation = strings.TrimSpace(reason)
+	}
+	if explanation != "" {
+		parts = append(parts, explanation)
+	}
+	parts = append(parts, fmt.Sprintf("This reverts commit %s.", commit))
+	return strings.Join(parts, "\n\n")
+}
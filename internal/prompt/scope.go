@@ -0,0 +1,31 @@
+package prompt
+
+import (
+	"strings"
+
+	"auto-git/pkg/conventional"
+)
+
+// WithScope sets the scope of a conventional commit message, replacing any
+// scope the model chose. A leading emoji such as "✨ " or ":sparkles: " is
+// kept. Messages without a type are returned unchanged.
+func WithScope(message, scope string) string {
+	if scope == "" {
+		return message
+	}
+	header, body, hasBody := strings.Cut(message, "\n")
+	emoji, rest := conventional.SplitEmoji(header)
+	m, err := conventional.Parse(rest)
+	if err != nil {
+		return message
+	}
+	m.Scope = scope
+	header = m.Header()
+	if emoji != "" {
+		header = emoji + " " + header
+	}
+	if hasBody {
+		return header + "\n" + body
+	}
+	return header
+}
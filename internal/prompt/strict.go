@@ -0,0 +1,25 @@
+package prompt
+
+import "strings"
+
+// The prompt lines describing the message format, which strict mode replaces
+const (
+	formatGuideline   = `- Use conventional commit format: <type>(<scope>): <subject> or <emoji> <type>(<scope>): <subject>`
+	emojiGuideline    = `- Use emojis when appropriate (e.g., ✨ for feat, 🐛 for fix, 🗑️ for del, 📝 for docs, ♻️ for refactor, ⚡ for perf, 🎨 for style, 🔧 for chore)`
+	formatRequirement = `- Use the format <emoji> <type>(<optional scope>): <subject> or <type>(<scope>): <subject> (emojis are optional but encouraged).`
+)
+
+const (
+	strictFormatGuideline   = `- Follow Conventional Commits 1.0.0 exactly: <type>(<scope>): <subject>, or <type>(<scope>)!: <subject> for breaking changes. Nothing, not even an emoji, may come before the type`
+	strictFormatRequirement = `- Use exactly the format <type>(<optional scope>): <subject>, with ": " after the type or scope and no emoji. Add "!" before the colon for breaking changes.`
+)
+
+// Strict adapts prompts built by this package to strict Conventional Commits
+// mode, which rejects emoji prefixes. Lines of a custom system prompt are
+// left alone.
+func Strict(systemPrompt, userPrompt string) (string, string) {
+	systemPrompt = strings.Replace(systemPrompt, formatGuideline, strictFormatGuideline, 1)
+	systemPrompt = strings.Replace(systemPrompt, emojiGuideline+"\n", "", 1)
+	userPrompt = strings.Replace(userPrompt, formatRequirement, strictFormatRequirement, 1)
+	return systemPrompt, userPrompt
+}
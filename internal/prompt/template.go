@@ -0,0 +1,133 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"auto-git/internal/git"
+)
+
+// DefaultTemplate renders types without a template of their own
+const DefaultTemplate = "{{type}}{{if scope}}({{scope}}){{end}}{{if breaking}}!{{end}}: {{subject}}"
+
+// Fields are the parts of a commit message, returned by the model when the
+// message is rendered from a template
+type Fields struct {
+	Type     string `json:"type"`
+	Scope    string `json:"scope"`
+	Subject  string `json:"subject"`
+	Issue    string `json:"issue"`
+	Breaking bool   `json:"breaking"`
+}
+
+// fieldsReply asks for the message parts as one JSON object
+var fieldsReply = replyFormat{
+	respond: `- Respond with one JSON object: {"type": "...", "scope": "...", "subject": "...", "issue": "...", "breaking": false}.`,
+	details: []string{
+		"- type is the commit type and scope the optional scope, as described next; subject is the description alone, without type, scope or emoji.",
+		"- issue is the number or key of the issue the change resolves, without \"#\", if the branch or the changes name one; otherwise leave it empty.",
+		"- breaking is true only if the change breaks existing users.",
+	},
+	extras:  "- Do NOT include explanations or code fences around the JSON object.",
+	closing: "Return only the JSON object with the message parts:",
+}
+
+// BuildFieldsSystemPrompt is BuildSystemPrompt for replies holding the
+// message parts that a template is filled with
+func BuildFieldsSystemPrompt() string {
+	return strings.Replace(BuildSystemPrompt(), subjectOutputGuideline,
+		"- Output a single JSON object holding the parts of the commit message: type, scope, subject, issue and whether it is breaking", 1)
+}
+
+// BuildFieldsUserPrompt is BuildUserPrompt asking for the reply ParseFields
+// reads. A non-empty branch is named so the model can find an issue in it.
+func BuildFieldsUserPrompt(changes *git.Changes, diffContent, branch string) string {
+	reply := fieldsReply
+	if branch != "" {
+		reply.details = append(append([]string{}, reply.details...), fmt.Sprintf("- The current branch is %q.", branch))
+	}
+	return buildUserPrompt(changes, diffContent, reply)
+}
+
+// IsFieldsPrompt reports whether a user prompt was built by BuildFieldsUserPrompt
+func IsFieldsPrompt(userPrompt string) bool {
+	return strings.HasSuffix(userPrompt, fieldsReply.closing)
+}
+
+// ParseFields reads the JSON object of a reply to BuildFieldsUserPrompt. Code
+// fences and text around the object are ignored.
+func ParseFields(response string) (*Fields, error) {
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the reply contains no JSON object")
+	}
+
+	var f Fields
+	if err := json.Unmarshal([]byte(response[start:end+1]), &f); err != nil {
+		return nil, fmt.Errorf("the reply is not valid JSON: %w", err)
+	}
+	f.Type = strings.ToLower(strings.TrimSpace(f.Type))
+	f.Scope = strings.TrimSpace(f.Scope)
+	f.Subject = ExtractMessageLine(f.Subject)
+	f.Issue = strings.TrimPrefix(strings.TrimSpace(f.Issue), "#")
+	if f.Type == "" || f.Subject == "" {
+		return nil, fmt.Errorf("the reply has no type or subject")
+	}
+	return &f, nil
+}
+
+// Templates render commit messages from Fields, with one template per type
+type Templates map[string]*template.Template
+
+// ParseTemplates parses the templates of each type. Templates use
+// text/template syntax with the fields as functions, e.g.
+// "fix({{scope}}): {{subject}}{{if issue}} (closes #{{issue}}){{end}}".
+func ParseTemplates(texts map[string]string) (Templates, error) {
+	templates := Templates{}
+	for typ, text := range texts {
+		tmpl, err := parseTemplate(typ, text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for %s: %w", typ, err)
+		}
+		templates[strings.ToLower(typ)] = tmpl
+	}
+	return templates, nil
+}
+
+// Render fills the template for the type of f, or DefaultTemplate
+func (t Templates) Render(f *Fields) (string, error) {
+	tmpl, ok := t[f.Type]
+	if !ok {
+		tmpl = template.Must(parseTemplate("default", DefaultTemplate))
+	}
+
+	// The functions are bound to f, so each template is cloned first
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := clone.Funcs(fieldFuncs(f)).Execute(&buf, nil); err != nil {
+		return "", fmt.Errorf("template for %s: %w", f.Type, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// parseTemplate parses text with placeholder functions, which Render replaces
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(fieldFuncs(&Fields{})).Parse(text)
+}
+
+// fieldFuncs returns the functions templates use to read f
+func fieldFuncs(f *Fields) template.FuncMap {
+	return template.FuncMap{
+		"type":     func() string { return f.Type },
+		"scope":    func() string { return f.Scope },
+		"subject":  func() string { return f.Subject },
+		"issue":    func() string { return f.Issue },
+		"breaking": func() bool { return f.Breaking },
+	}
+}
@@ -0,0 +1,39 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"auto-git/internal/todo"
+)
+
+// maxTodos is the number of added or resolved tagged comments listed
+const maxTodos = 10
+
+// todoSection lists the TODO/FIXME comments the diff adds and resolves, or
+// returns "" when there are none
+func todoSection(diffContent string) string {
+	delta := todo.Scan(diffContent)
+	if delta.Empty() {
+		return ""
+	}
+
+	lines := []string{"=== TODO/FIXME CHANGES ==="}
+	for _, list := range []struct {
+		label string
+		items []todo.Item
+	}{{"Added", delta.Added}, {"Resolved", delta.Removed}} {
+		if len(list.items) == 0 {
+			continue
+		}
+		lines = append(lines, list.label+":")
+		for i, item := range list.items {
+			if i == maxTodos {
+				lines = append(lines, fmt.Sprintf("- ... and %d more", len(list.items)-maxTodos))
+				break
+			}
+			lines = append(lines, "- "+item.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"regexp"
+
+	"auto-git/internal/git"
+)
+
+// FilterModels wraps p so that ListModels only returns the models whose name
+// matches pattern, e.g. to hide embedding and image models from selection
+func FilterModels(p Provider, pattern *regexp.Regexp) Provider {
+	return &filteredProvider{Provider: p, pattern: pattern}
+}
+
+type filteredProvider struct {
+	Provider
+	pattern *regexp.Regexp
+}
+
+func (p *filteredProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+func (p *filteredProvider) GenerateChanges(changes *git.Changes, model string, systemPrompt, userPrompt string) (*Completion, error) {
+	return GenerateChanges(p.Provider, changes, model, systemPrompt, userPrompt)
+}
+
+func (p *filteredProvider) ListModels() ([]Model, error) {
+	models, err := p.Provider.ListModels()
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]Model, 0, len(models))
+	for _, m := range models {
+		if p.pattern.MatchString(m.Name) {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered, nil
+}
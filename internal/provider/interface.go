@@ -1,14 +1,51 @@
 package provider
 
+import (
+	"errors"
+
+	"auto-git/internal/git"
+)
+
 // Model represents a language model available from a provider
 type Model struct {
 	Name       string `json:"name"`
 	ModifiedAt string `json:"modified_at,omitempty"`
 	Size       int64  `json:"size,omitempty"`
+
+	// Details reported by Ollama
+	Family        string `json:"family,omitempty"`
+	ParameterSize string `json:"parameter_size,omitempty"`
+	Quantization  string `json:"quantization_level,omitempty"`
+
+	// Details reported by OpenAI-compatible APIs
+	OwnedBy string `json:"owned_by,omitempty"`
+	Created int64  `json:"created,omitempty"`
+}
+
+// Usage holds the token counts reported for a single generation
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	// CachedTokens are the prompt tokens the provider read from its prompt
+	// cache, when it reports them
+	CachedTokens int `json:"cached_tokens,omitempty"`
+}
+
+// ErrContextExceeded is returned when the provider rejects a prompt as longer
+// than the model's context
+var ErrContextExceeded = errors.New("the prompt exceeds the model's context length")
+
+// Completion is the raw result of a generation request
+type Completion struct {
+	Content string
+	Usage   Usage
 }
 
 // Provider defines the interface that all LLM providers must implement
 type Provider interface {
+	// Generate sends the prompts to the model and returns its reply with token usage
+	Generate(model string, systemPrompt, userPrompt string) (*Completion, error)
+
 	// GenerateCommitMessage generates a commit message using the specified model and prompts
 	GenerateCommitMessage(model string, systemPrompt, userPrompt string) (string, error)
 
@@ -18,3 +55,37 @@ type Provider interface {
 	// CheckConnection verifies that the provider is accessible
 	CheckConnection() error
 }
+
+// ChangesGenerator is implemented by providers that describe the changes
+// from their structured form rather than from the prompt, such as the
+// offline mock provider. Wrappers implement it by forwarding with
+// GenerateChanges, so that it reaches the innermost provider through them.
+type ChangesGenerator interface {
+	GenerateChanges(changes *git.Changes, model string, systemPrompt, userPrompt string) (*Completion, error)
+}
+
+// GenerateChanges asks p to describe changes: through GenerateChanges when p
+// implements ChangesGenerator, and from the prompts with Generate otherwise
+func GenerateChanges(p Provider, changes *git.Changes, model string, systemPrompt, userPrompt string) (*Completion, error) {
+	if generator, ok := p.(ChangesGenerator); ok {
+		return generator.GenerateChanges(changes, model, systemPrompt, userPrompt)
+	}
+	return p.Generate(model, systemPrompt, userPrompt)
+}
+
+// Wrapper is implemented by providers that decorate another provider, so
+// callers can inspect the underlying client
+type Wrapper interface {
+	Unwrap() Provider
+}
+
+// Unwrap returns the innermost provider behind any wrappers
+func Unwrap(p Provider) Provider {
+	for {
+		w, ok := p.(Wrapper)
+		if !ok {
+			return p
+		}
+		p = w.Unwrap()
+	}
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Reasoning efforts accepted by Reasoning.Effort
+const (
+	EffortMinimal = "minimal"
+	EffortLow     = "low"
+	EffortMedium  = "medium"
+	EffortHigh    = "high"
+)
+
+// ErrReasoningOnly is returned when a reasoning model used up its reply on
+// thinking and gave no answer
+var ErrReasoningOnly = errors.New("model replied with reasoning only and no message; lower the reasoning effort or thinking budget")
+
+// Reasoning controls how long reasoning models think before answering. The
+// zero value leaves it to the model.
+type Reasoning struct {
+	// Effort is EffortMinimal, EffortLow, EffortMedium or EffortHigh
+	Effort string
+	// BudgetTokens caps the thinking tokens of models that take a budget,
+	// such as Claude and Qwen3
+	BudgetTokens int
+}
+
+// ParseReasoning validates a reasoning effort and thinking budget
+func ParseReasoning(effort string, budgetTokens int) (Reasoning, error) {
+	effort = strings.ToLower(strings.TrimSpace(effort))
+	switch effort {
+	case "", EffortMinimal, EffortLow, EffortMedium, EffortHigh:
+	default:
+		return Reasoning{}, fmt.Errorf("unknown reasoning effort %q (supported: %s, %s, %s, %s)", effort, EffortMinimal, EffortLow, EffortMedium, EffortHigh)
+	}
+	if budgetTokens < 0 {
+		return Reasoning{}, fmt.Errorf("thinking budget must not be negative, got %d", budgetTokens)
+	}
+	return Reasoning{Effort: effort, BudgetTokens: budgetTokens}, nil
+}
+
+// thinkBlock matches the reasoning some models put in their reply itself
+var thinkBlock = regexp.MustCompile(`(?is)<(think|thinking|reasoning)>.*?</(think|thinking|reasoning)>`)
+
+// openThinkBlock matches reasoning that was cut off before its closing tag
+var openThinkBlock = regexp.MustCompile(`(?is)<(think|thinking|reasoning)>.*$`)
+
+// StripThinking removes <think> blocks from a reply, including one cut off
+// before its closing tag and the rest of one whose opening tag is missing, so
+// that reasoning is never taken for the answer
+func StripThinking(content string) string {
+	content = thinkBlock.ReplaceAllString(content, "")
+	content = openThinkBlock.ReplaceAllString(content, "")
+	lower := strings.ToLower(content)
+	for _, tag := range []string{"</think>", "</thinking>", "</reasoning>"} {
+		if i := strings.LastIndex(lower, tag); i >= 0 {
+			content = content[i+len(tag):]
+			lower = lower[i+len(tag):]
+		}
+	}
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,75 @@
+// Package redact masks personal and environment-specific data in diffs before
+// they are sent to a provider. Only prompts are redacted, never the commit.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Placeholder replaces every redacted value
+const Placeholder = "[REDACTED]"
+
+// Rules selects what to redact
+type Rules struct {
+	Emails bool
+	IPs    bool
+	// EnvValues masks the values of .env-style lines such as DATABASE_URL=...
+	EnvValues bool
+	// Patterns are extra regular expressions whose matches are masked
+	Patterns []string
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	ipv4Pattern  = regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)
+	ipv6Pattern  = regexp.MustCompile(`\b(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}\b`)
+	// envPattern matches KEY=value lines, including diff-prefixed and exported ones
+	envPattern = regexp.MustCompile(`(?m)^([+\- ]?[ \t]*(?:export[ \t]+)?[A-Z][A-Z0-9_]*=)(\S.*)$`)
+)
+
+// Redactor applies a compiled set of rules
+type Redactor struct {
+	rules    Rules
+	patterns []*regexp.Regexp
+}
+
+// New compiles rules, failing on an invalid custom pattern
+func New(rules Rules) (*Redactor, error) {
+	r := &Redactor{rules: rules}
+	for _, p := range rules.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Enabled reports whether any rule is active
+func (r *Redactor) Enabled() bool {
+	return r != nil && (r.rules.Emails || r.rules.IPs || r.rules.EnvValues || len(r.patterns) > 0)
+}
+
+// Apply returns text with every rule applied
+func (r *Redactor) Apply(text string) string {
+	if !r.Enabled() {
+		return text
+	}
+
+	if r.rules.EnvValues {
+		text = envPattern.ReplaceAllString(text, "${1}"+Placeholder)
+	}
+	if r.rules.Emails {
+		text = emailPattern.ReplaceAllString(text, Placeholder)
+	}
+	if r.rules.IPs {
+		text = ipv6Pattern.ReplaceAllString(text, Placeholder)
+		text = ipv4Pattern.ReplaceAllString(text, Placeholder)
+	}
+	for _, re := range r.patterns {
+		text = re.ReplaceAllString(text, Placeholder)
+	}
+	return text
+}
@@ -0,0 +1,346 @@
+// Package server exposes auto-git over a local HTTP API so GUI clients and
+// editor extensions can reuse the provider and git logic.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"auto-git/internal/git"
+	"auto-git/internal/logging"
+	"auto-git/internal/verify"
+	"auto-git/pkg/autogit"
+)
+
+// maxBodySize limits request bodies; diffs larger than this are rejected
+const maxBodySize = 20 << 20
+
+// Options configures a Server
+type Options struct {
+	Provider     autogit.Provider
+	ProviderName string
+	Model        string
+	// NewEngine sets up the generation pipeline for model, with the prompt
+	// and message settings of the command line
+	NewEngine func(model string) (*autogit.Engine, error)
+	// Paths limits the changes that are described and committed
+	Paths git.PathFilter
+	// Commit stages the changes matching Paths and commits them with
+	// message, as a run on the command line does
+	Commit func(message string) error
+	// VerifyCommand must succeed before a commit is pushed
+	VerifyCommand string
+	// Web serves the review page at /
+	Web bool
+}
+
+// Server handles the HTTP API
+type Server struct {
+	opts Options
+	// gitMu serializes operations that modify the repository
+	gitMu sync.Mutex
+}
+
+func New(opts Options) *Server {
+	return &Server{opts: opts}
+}
+
+// GenerateRequest is the body of POST /generate. Without a diff the changes in
+// the server's repository are used.
+type GenerateRequest struct {
+	Diff  string `json:"diff,omitempty"`
+	Model string `json:"model,omitempty"`
+}
+
+type GenerateResponse struct {
+	Message  string        `json:"message"`
+	Provider string        `json:"provider"`
+	Model    string        `json:"model"`
+	Usage    autogit.Usage `json:"usage"`
+}
+
+// CommitRequest is the body of POST /commit. Without a message one is generated.
+type CommitRequest struct {
+	Message string `json:"message,omitempty"`
+	Model   string `json:"model,omitempty"`
+	Push    bool   `json:"push"`
+}
+
+type CommitResponse struct {
+	Message   string `json:"message"`
+	Committed bool   `json:"committed"`
+	Pushed    bool   `json:"pushed"`
+}
+
+type ModelsResponse struct {
+	Provider string          `json:"provider"`
+	Default  string          `json:"default"`
+	Models   []autogit.Model `json:"models"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler returns the API routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /models", s.handleModels)
+	mux.HandleFunc("POST /generate", s.handleGenerate)
+	mux.HandleFunc("POST /commit", s.handleCommit)
+	mux.HandleFunc("GET /changes", s.handleChanges)
+	if s.opts.Web {
+		mux.Handle("GET /", webHandler())
+	}
+	return localOnly(mux)
+}
+
+// ListenAndServe serves the API on addr, which must be a loopback address
+func (s *Server) ListenAndServe(addr string) error {
+	if err := CheckLoopback(addr); err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return srv.ListenAndServe()
+}
+
+// CheckLoopback rejects listen addresses that are reachable from other machines
+func CheckLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("refusing to listen on %q: only loopback addresses are allowed", addr)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	models, err := s.opts.Provider.ListModels()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ModelsResponse{
+		Provider: s.opts.ProviderName,
+		Default:  s.opts.Model,
+		Models:   models,
+	})
+}
+
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	var req GenerateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	resp, status, err := s.generate(req.Diff, req.Model)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleCommit(w http.ResponseWriter, r *http.Request) {
+	var req CommitRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	s.gitMu.Lock()
+	defer s.gitMu.Unlock()
+
+	// Keeps a run on the command line from committing at the same time
+	lock, err := git.AcquireLock()
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, git.ErrLocked) {
+			status = http.StatusConflict
+		}
+		writeError(w, status, err)
+		return
+	}
+	defer lock.Release()
+
+	message := strings.TrimSpace(req.Message)
+	if message == "" {
+		generated, status, err := s.generate("", req.Model)
+		if err != nil {
+			writeError(w, status, err)
+			return
+		}
+		message = generated.Message
+	}
+
+	if err := s.opts.Commit(message); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	resp := CommitResponse{Message: message, Committed: true}
+	if !req.Push {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if output, err := s.verify(); err != nil {
+		writeJSON(w, http.StatusConflict, struct {
+			CommitResponse
+			Error  string `json:"error"`
+			Output string `json:"output,omitempty"`
+		}{resp, err.Error(), output})
+		return
+	}
+
+	pushed, err := git.PushIfRemoteExists()
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, struct {
+			CommitResponse
+			Error string `json:"error"`
+		}{resp, err.Error()})
+		return
+	}
+	resp.Pushed = pushed
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// verify runs the configured verification command before a push
+func (s *Server) verify() (string, error) {
+	if s.opts.VerifyCommand == "" {
+		return "", nil
+	}
+	if hasOrigin, err := git.HasOrigin(); err != nil || !hasOrigin {
+		return "", nil
+	}
+	root, err := git.Root()
+	if err != nil {
+		return "", err
+	}
+	return verify.Run(root, s.opts.VerifyCommand)
+}
+
+// generate produces a message for diff, or for the repository changes when
+// diff is empty, returning the HTTP status to use on failure
+func (s *Server) generate(diff, model string) (*GenerateResponse, int, error) {
+	if model == "" {
+		model = s.opts.Model
+	}
+
+	engine, err := s.opts.NewEngine(model)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	var changes *autogit.Changes
+	if strings.TrimSpace(diff) != "" {
+		changes, err = engine.ParsePatch(diff)
+	} else {
+		changes, diff, err = git.CollectFiltered(".", s.opts.Paths)
+	}
+	if err != nil {
+		if errors.Is(err, autogit.ErrNoChanges) {
+			return nil, http.StatusConflict, err
+		}
+		return nil, http.StatusBadRequest, err
+	}
+
+	start := time.Now()
+	message, err := engine.Generate(changes, diff)
+	logging.Debug("serve: generation finished", "model", engine.Model(), "duration", time.Since(start), "error", err)
+	if err != nil {
+		// Replies that strict_conventional or banned_words rejected every time
+		if errors.Is(err, autogit.ErrNotConventional) || errors.Is(err, autogit.ErrBannedWord) {
+			return nil, http.StatusUnprocessableEntity, err
+		}
+		return nil, http.StatusBadGateway, err
+	}
+
+	return &GenerateResponse{
+		Message:  message,
+		Provider: s.opts.ProviderName,
+		Model:    engine.Model(),
+		Usage:    engine.Usage(),
+	}, http.StatusOK, nil
+}
+
+// localOnly rejects cross-site browser requests: the API can commit and push,
+// so a web page must not be able to drive it. The Host header is checked as
+// well, since same-origin requests carry no Origin: after DNS rebinding, a
+// page from another site reaches the server under its own host name.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !loopbackHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("requests must address a loopback host"))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || CheckLoopback(net.JoinHostPort(u.Hostname(), "0")) != nil {
+				writeError(w, http.StatusForbidden, fmt.Errorf("cross-origin requests are not allowed"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// loopbackHost reports whether a Host header names a loopback IP address or
+// localhost, with or without a port
+func loopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	} else {
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("Content-Type must be application/json"))
+		return false
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
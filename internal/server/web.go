@@ -0,0 +1,77 @@
+package server
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"net/http"
+
+	"auto-git/internal/git"
+)
+
+//go:embed web
+var webFiles embed.FS
+
+// ChangedFile is one entry of GET /changes
+type ChangedFile struct {
+	Path      string `json:"path"`
+	OldPath   string `json:"old_path,omitempty"`
+	Type      string `json:"type"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Staged    bool   `json:"staged"`
+}
+
+type ChangesResponse struct {
+	Files []ChangedFile `json:"files"`
+	Diff  string        `json:"diff"`
+}
+
+// handleChanges lists the repository changes and their diff; a clean
+// repository has none
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	// The changes a commit would record
+	changes, diff, err := git.CollectFiltered(".", s.opts.Paths)
+	if errors.Is(err, git.ErrNoChanges) {
+		writeJSON(w, http.StatusOK, ChangesResponse{Files: []ChangedFile{}})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := ChangesResponse{Files: []ChangedFile{}, Diff: diff}
+	for _, group := range []struct {
+		staged bool
+		files  []git.FileChange
+	}{{true, changes.Staged}, {false, changes.Unstaged}} {
+		for _, f := range group.files {
+			resp.Files = append(resp.Files, ChangedFile{
+				Path:      f.Path,
+				OldPath:   f.OldPath,
+				Type:      string(f.Type),
+				Additions: f.Additions,
+				Deletions: f.Deletions,
+				Staged:    group.staged,
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// webHandler serves the review page. It may not be framed, and its script
+// may only talk to this server.
+func webHandler() http.Handler {
+	root, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err)
+	}
+	files := http.FileServerFS(root)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,177 @@
+"use strict";
+
+// The review page drives the same API as other clients: GET /changes,
+// POST /generate and POST /commit.
+
+const subjectLimit = 72;
+const $ = (id) => document.getElementById(id);
+let patches = [];
+
+async function call(method, path, body) {
+  const options = { method, headers: {} };
+  if (body !== undefined) {
+    options.headers["Content-Type"] = "application/json";
+    options.body = JSON.stringify(body);
+  }
+  const resp = await fetch(path, options);
+  const data = await resp.json();
+  if (!resp.ok) {
+    const err = new Error(data.error || resp.statusText);
+    err.status = resp.status;
+    throw err;
+  }
+  return data;
+}
+
+function setStatus(text) {
+  $("status").textContent = text;
+}
+
+function setBusy(busy) {
+  for (const id of ["regenerate", "commit", "reload"]) {
+    $(id).disabled = busy;
+  }
+}
+
+// splitPatches cuts a diff into one patch per file, keyed by its new path
+function splitPatches(diff) {
+  const result = [];
+  for (const line of diff.split("\n")) {
+    const m = line.match(/^diff --git a\/.* b\/(.*)$/);
+    if (m) {
+      result.push({ path: m[1], lines: [] });
+    }
+    if (result.length > 0 && !line.startsWith("=== ")) {
+      result[result.length - 1].lines.push(line);
+    }
+  }
+  return result;
+}
+
+function showDiff(path) {
+  const pre = $("diff");
+  pre.replaceChildren();
+  const shown = path ? patches.filter((p) => p.path === path) : patches;
+  $("diff-title").textContent = path || "Diff";
+  for (const patch of shown) {
+    for (const line of patch.lines) {
+      const span = document.createElement("span");
+      if (line.startsWith("+++") || line.startsWith("---") || line.startsWith("diff ") || line.startsWith("index ")) {
+        span.className = "meta";
+      } else if (line.startsWith("@@")) {
+        span.className = "hunk";
+      } else if (line.startsWith("+")) {
+        span.className = "add";
+      } else if (line.startsWith("-")) {
+        span.className = "del";
+      }
+      span.textContent = line + "\n";
+      pre.appendChild(span);
+    }
+  }
+  for (const li of $("files").children) {
+    li.classList.toggle("selected", li.dataset.path === path);
+  }
+}
+
+function showFiles(files) {
+  const list = $("files");
+  list.replaceChildren();
+  for (const f of files) {
+    const li = document.createElement("li");
+    li.dataset.path = f.path;
+    li.title = f.staged ? "staged" : "unstaged";
+    if (!f.staged) {
+      li.className = "unstaged";
+    }
+    const counts = document.createElement("span");
+    counts.className = "counts";
+    const add = document.createElement("span");
+    add.className = "add";
+    add.textContent = "+" + f.additions;
+    const del = document.createElement("span");
+    del.className = "del";
+    del.textContent = " -" + f.deletions;
+    counts.append(add, del);
+    li.append(counts, f.old_path ? f.old_path + " → " + f.path : f.path);
+    li.addEventListener("click", () => showDiff(li.classList.contains("selected") ? "" : f.path));
+    list.appendChild(li);
+  }
+}
+
+function updateCounter() {
+  const subject = $("message").value.split("\n")[0];
+  const counter = $("counter");
+  counter.textContent = `subject ${[...subject].length}/${subjectLimit}`;
+  counter.classList.toggle("over", [...subject].length > subjectLimit);
+}
+
+async function regenerate() {
+  setBusy(true);
+  setStatus("Generating...");
+  try {
+    const data = await call("POST", "/generate", {});
+    $("message").value = data.message;
+    updateCounter();
+    setStatus(`Generated with ${data.model}`);
+  } catch (err) {
+    if (err.status === 422) {
+      // Every reply broke strict_conventional or banned_words
+      $("message").value = "";
+      updateCounter();
+      setStatus("No acceptable message was generated: " + err.message);
+    } else {
+      setStatus("Generation failed: " + err.message);
+    }
+  } finally {
+    setBusy(false);
+  }
+}
+
+async function load() {
+  setBusy(true);
+  setStatus("Reading changes...");
+  try {
+    const data = await call("GET", "/changes");
+    patches = splitPatches(data.diff);
+    showFiles(data.files);
+    showDiff("");
+    if (data.files.length === 0) {
+      $("message").value = "";
+      setStatus("Nothing to commit");
+      return;
+    }
+  } catch (err) {
+    setStatus("Could not read changes: " + err.message);
+    return;
+  } finally {
+    setBusy(false);
+  }
+  await regenerate();
+}
+
+async function commit() {
+  const message = $("message").value.trim();
+  if (message === "") {
+    setStatus("The commit message is empty");
+    return;
+  }
+  setBusy(true);
+  setStatus("Committing...");
+  try {
+    const data = await call("POST", "/commit", { message, push: $("push").checked });
+    setStatus(data.pushed ? "Committed and pushed" : "Committed");
+  } catch (err) {
+    setStatus("Commit failed: " + err.message);
+    setBusy(false);
+    return;
+  }
+  setBusy(false);
+  await load();
+}
+
+$("regenerate").addEventListener("click", regenerate);
+$("commit").addEventListener("click", commit);
+$("reload").addEventListener("click", load);
+$("message").addEventListener("input", updateCounter);
+load();
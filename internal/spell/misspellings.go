@@ -0,0 +1,111 @@
+package spell
+
+// misspellings maps common English misspellings, many of them frequent in
+// commit messages, to their correct spelling
+var misspellings = map[string]string{
+	"accomodate":       "accommodate",
+	"acheive":          "achieve",
+	"accross":          "across",
+	"adress":           "address",
+	"agressive":        "aggressive",
+	"allready":         "already",
+	"alot":             "a lot",
+	"analize":          "analyze",
+	"appearence":       "appearance",
+	"arguement":        "argument",
+	"assesment":        "assessment",
+	"asynchonous":      "asynchronous",
+	"atleast":          "at least",
+	"authentification": "authentication",
+	"availible":        "available",
+	"begining":         "beginning",
+	"beleive":          "believe",
+	"buisness":         "business",
+	"calender":         "calendar",
+	"cancelation":      "cancellation",
+	"catagory":         "category",
+	"changable":        "changeable",
+	"commited":         "committed",
+	"commiting":        "committing",
+	"compatability":    "compatibility",
+	"compatable":       "compatible",
+	"completly":        "completely",
+	"concurent":        "concurrent",
+	"configuraton":     "configuration",
+	"connecton":        "connection",
+	"consistant":       "consistent",
+	"correclty":        "correctly",
+	"currenly":         "currently",
+	"defualt":          "default",
+	"definately":       "definitely",
+	"dependancy":       "dependency",
+	"dependancies":     "dependencies",
+	"deprecatd":        "deprecated",
+	"desciption":       "description",
+	"diffrent":         "different",
+	"embarass":         "embarrass",
+	"enviroment":       "environment",
+	"environmnet":      "environment",
+	"exeption":         "exception",
+	"existance":        "existence",
+	"existant":         "existent",
+	"explicitely":      "explicitly",
+	"funtion":          "function",
+	"funciton":         "function",
+	"garantee":         "guarantee",
+	"handeling":        "handling",
+	"happend":          "happened",
+	"implemention":     "implementation",
+	"implmentation":    "implementation",
+	"independant":      "independent",
+	"initalize":        "initialize",
+	"initialise":       "initialize",
+	"intial":           "initial",
+	"lenght":           "length",
+	"mesage":           "message",
+	"messsage":         "message",
+	"neccessary":       "necessary",
+	"necesary":         "necessary",
+	"occured":          "occurred",
+	"occurence":        "occurrence",
+	"occurrance":       "occurrence",
+	"paramater":        "parameter",
+	"paramters":        "parameters",
+	"perfomance":       "performance",
+	"persistant":       "persistent",
+	"posible":          "possible",
+	"prefered":         "preferred",
+	"previosly":        "previously",
+	"proccess":         "process",
+	"recieve":          "receive",
+	"recieved":         "received",
+	"recomend":         "recommend",
+	"redundent":        "redundant",
+	"refered":          "referred",
+	"refrence":         "reference",
+	"relevent":         "relevant",
+	"remvoe":           "remove",
+	"repostiory":       "repository",
+	"repsonse":         "response",
+	"responce":         "response",
+	"retreive":         "retrieve",
+	"seperate":         "separate",
+	"seperator":        "separator",
+	"succesful":        "successful",
+	"successfull":      "successful",
+	"suport":           "support",
+	"suppport":         "support",
+	"synchonize":       "synchronize",
+	"teh":              "the",
+	"threshhold":       "threshold",
+	"truely":           "truly",
+	"unecessary":       "unnecessary",
+	"untill":           "until",
+	"updaet":           "update",
+	"usefull":          "useful",
+	"varaible":         "variable",
+	"verison":          "version",
+	"wich":             "which",
+	"wierd":            "weird",
+	"writting":         "writing",
+}
@@ -0,0 +1,150 @@
+// Package spell finds typos in commit subjects: identifiers that are close
+// to, but not quite, one in the diff, and common English misspellings.
+// Identifiers in the diff are never flagged.
+package spell
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Correction is a misspelled word of the subject and its likely spelling
+type Correction struct {
+	Word       string
+	Suggestion string
+}
+
+// word matches identifiers, including dotted ones such as "config.go" and
+// "git.Changes"
+var word = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*`)
+
+// Identifiers collects the words of a diff, including each part of the
+// dotted ones, as the allowlist for Check
+func Identifiers(diff string) map[string]bool {
+	known := map[string]bool{}
+	for _, w := range word.FindAllString(diff, -1) {
+		known[w] = true
+		if strings.Contains(w, ".") {
+			for _, part := range strings.Split(w, ".") {
+				known[part] = true
+			}
+		}
+	}
+	return known
+}
+
+// Check returns corrections for the words of subject, in order. A word is
+// corrected when it is a common misspelling, or when it looks like an
+// identifier and differs from one in known by a typo or two.
+func Check(subject string, known map[string]bool) []Correction {
+	var corrections []Correction
+	seen := map[string]bool{}
+	for _, w := range word.FindAllString(subject, -1) {
+		if seen[w] || known[w] || len(w) < 4 {
+			continue
+		}
+		seen[w] = true
+		if fix, ok := misspellings[strings.ToLower(w)]; ok {
+			corrections = append(corrections, Correction{Word: w, Suggestion: matchCase(fix, w)})
+			continue
+		}
+		if !identifierLike(w) {
+			continue
+		}
+		if fix := closest(w, known); fix != "" {
+			corrections = append(corrections, Correction{Word: w, Suggestion: fix})
+		}
+	}
+	return corrections
+}
+
+// Apply replaces the corrected words in the first line of message
+func Apply(message string, corrections []Correction) string {
+	subject, body, hasBody := strings.Cut(message, "\n")
+	for _, c := range corrections {
+		pattern := regexp.MustCompile(`(^|[^A-Za-z0-9_.])` + regexp.QuoteMeta(c.Word) + `($|[^A-Za-z0-9_.]|\.$|\.[^A-Za-z_])`)
+		subject = pattern.ReplaceAllString(subject, "${1}"+strings.ReplaceAll(c.Suggestion, "$", "$$")+"${2}")
+	}
+	if hasBody {
+		return subject + "\n" + body
+	}
+	return subject
+}
+
+// identifierLike reports whether w is written like code rather than prose:
+// with an inner capital, an underscore, a digit or a dot
+func identifierLike(w string) bool {
+	for i, r := range w {
+		if r == '_' || r == '.' || unicode.IsDigit(r) || (i > 0 && unicode.IsUpper(r)) {
+			return true
+		}
+	}
+	return false
+}
+
+// closest returns the known identifier nearest to w, ignoring case, if it
+// is within one edit for short words and two for longer ones
+func closest(w string, known map[string]bool) string {
+	limit := 1
+	if len(w) > 6 {
+		limit = 2
+	}
+	best, bestDistance := "", limit+1
+	lower := strings.ToLower(w)
+	for k := range known {
+		if !identifierLike(k) || abs(len(k)-len(w)) > limit {
+			continue
+		}
+		d := distance(lower, strings.ToLower(k))
+		if d < bestDistance || (d == bestDistance && k < best) {
+			best, bestDistance = k, d
+		}
+	}
+	return best
+}
+
+// distance is the optimal string alignment distance of a and b: the number
+// of insertions, deletions, substitutions and transpositions of adjacent
+// characters that turn one into the other
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// matchCase capitalizes fix like w
+func matchCase(fix, w string) string {
+	if w != "" && unicode.IsUpper([]rune(w)[0]) {
+		r := []rune(fix)
+		r[0] = unicode.ToUpper(r[0])
+		return string(r)
+	}
+	return fix
+}
@@ -0,0 +1,101 @@
+// Package state keeps what auto-git learns about a repository between runs,
+// such as the model last used and the message last generated, in the
+// repository's state directory inside .git, so the worktree stays clean.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the state's file inside the repository state directory, next
+// to the style profile
+const FileName = "state.json"
+
+// State is the data auto-git keeps per repository
+type State struct {
+	// LastProvider and LastModel generated the last message
+	LastProvider string `json:"last_provider,omitempty"`
+	LastModel    string `json:"last_model,omitempty"`
+	// LastMessage is the last generated message, before any edits
+	LastMessage   string    `json:"last_message,omitempty"`
+	LastMessageAt time.Time `json:"last_message_at,omitempty"`
+	// Models are the model names last listed by each provider from this
+	// repository, whose endpoint may differ from the global configuration
+	Models map[string]ModelList `json:"models,omitempty"`
+}
+
+// ModelList holds the model names a provider reported
+type ModelList struct {
+	Names     []string  `json:"names"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Load reads the state stored in dir, returning an empty state if there is
+// none yet
+func Load(dir string) (*State, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse repository state: %w", err)
+	}
+	return &s, nil
+}
+
+// Save writes the state to dir, creating it if needed
+func (s *State) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, FileName), append(data, '\n'), 0644)
+}
+
+// Update loads the state in dir, applies change and saves it. A corrupt
+// state is started afresh.
+func Update(dir string, change func(*State)) error {
+	s, err := Load(dir)
+	if err != nil {
+		s = &State{}
+	}
+	change(s)
+	return s.Save(dir)
+}
+
+// RecordMessage remembers a generated message and the model that wrote it
+func (s *State) RecordMessage(providerName, model, message string) {
+	s.LastProvider = providerName
+	s.LastModel = model
+	s.LastMessage = message
+	s.LastMessageAt = time.Now()
+}
+
+// RecordModels remembers the models providerName listed
+func (s *State) RecordModels(providerName string, names []string) {
+	if s.Models == nil {
+		s.Models = map[string]ModelList{}
+	}
+	s.Models[providerName] = ModelList{Names: names, UpdatedAt: time.Now()}
+}
+
+// FreshModels returns the models providerName listed within ttl
+func (s *State) FreshModels(providerName string, ttl time.Duration) ([]string, bool) {
+	list, ok := s.Models[providerName]
+	if !ok || time.Since(list.UpdatedAt) > ttl {
+		return nil, false
+	}
+	return list.Names, true
+}
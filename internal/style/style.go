@@ -0,0 +1,259 @@
+// Package style learns how a repository writes commit messages from its
+// history, so generated messages blend in with the existing log.
+package style
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// FileName is the profile's file inside the repository state directory
+	FileName = "style.json"
+	// SampleSize is the number of recent commits analyzed
+	SampleSize = 200
+	// MaxAge is how long a profile is used before the history is analyzed again
+	MaxAge = 7 * 24 * time.Hour
+	// minCommits is the least history worth learning from
+	minCommits = 5
+	// maxScopes bounds the scopes remembered and offered to the model
+	maxScopes = 30
+)
+
+// Profile summarizes the commit messages of a repository
+type Profile struct {
+	Commits int `json:"commits"`
+	// Ratios of commits that start with an emoji, follow the conventional
+	// "type(scope): subject" form, and carry a scope
+	EmojiRatio        float64 `json:"emoji_ratio"`
+	ConventionalRatio float64 `json:"conventional_ratio"`
+	ScopeRatio        float64 `json:"scope_ratio"`
+	// LowercaseRatio is the share of subjects whose first word is lowercase
+	LowercaseRatio float64  `json:"lowercase_ratio"`
+	Types          []string `json:"types,omitempty"`
+	Scopes         []string `json:"scopes,omitempty"`
+	AverageLength  int      `json:"average_length"`
+	// Language is the dominant script of the subjects, e.g. "English" or "Chinese"
+	Language  string    `json:"language"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// conventionalPattern matches "[emoji] type(scope)!: subject"
+var conventionalPattern = regexp.MustCompile(`^(?:(\S+)\s+)?([A-Za-z]+)(?:\(([^)]*)\))?!?:\s*(.+)$`)
+
+// ticketPrefix matches leading bracketed references like "[PROJ-12] "
+var ticketPrefix = regexp.MustCompile(`^(?:\[[^\]]*\]\s*)+`)
+
+// Analyze builds a profile from commit subjects
+func Analyze(subjects []string) *Profile {
+	p := &Profile{Commits: len(subjects), UpdatedAt: time.Now()}
+	if len(subjects) == 0 {
+		return p
+	}
+
+	types := make(map[string]int)
+	scopes := make(map[string]int)
+	scripts := make(map[string]int)
+	var emoji, conventional, scoped, lowercase, totalLength int
+
+	for _, subject := range subjects {
+		totalLength += len([]rune(subject))
+		if startsWithEmoji(subject) {
+			emoji++
+		}
+
+		text := subject
+		if m := conventionalPattern.FindStringSubmatch(subject); m != nil && (m[1] == "" || startsWithEmoji(m[1])) {
+			conventional++
+			types[strings.ToLower(m[2])]++
+			if m[3] != "" {
+				scoped++
+				scopes[m[3]]++
+			}
+			text = m[4]
+		} else if startsWithEmoji(subject) {
+			_, text, _ = strings.Cut(subject, " ")
+		}
+
+		// Ticket references such as "[PROJ-12]" don't say anything about casing
+		text = ticketPrefix.ReplaceAllString(text, "")
+		if r := firstLetter(text); r != 0 && unicode.IsLower(r) {
+			lowercase++
+		}
+		scripts[scriptOf(subject)]++
+	}
+
+	n := float64(len(subjects))
+	p.EmojiRatio = float64(emoji) / n
+	p.ConventionalRatio = float64(conventional) / n
+	p.ScopeRatio = float64(scoped) / n
+	p.LowercaseRatio = float64(lowercase) / n
+	p.AverageLength = totalLength / len(subjects)
+	p.Types = mostCommon(types, 5)
+	p.Scopes = mostCommon(scopes, maxScopes)
+	p.Language = mostCommon(scripts, 1)[0]
+	return p
+}
+
+// KnownScopes returns the scopes used in past commits, most common first
+func (p *Profile) KnownScopes() []string {
+	if p == nil {
+		return nil
+	}
+	return p.Scopes
+}
+
+// Guide renders the profile as prompt instructions, or "" when the history is
+// too short to be meaningful
+func (p *Profile) Guide() string {
+	if p == nil || p.Commits < minCommits {
+		return ""
+	}
+
+	lines := []string{fmt.Sprintf("Match the style of this repository's last %d commits:", p.Commits)}
+	switch {
+	case p.EmojiRatio >= 0.5:
+		lines = append(lines, "- Start the message with an emoji.")
+	case p.EmojiRatio < 0.1:
+		lines = append(lines, "- Do not use emojis.")
+	}
+	switch {
+	case p.ScopeRatio >= 0.5:
+		lines = append(lines, "- Include a scope.")
+	case p.ScopeRatio < 0.1:
+		lines = append(lines, "- Omit the scope.")
+	}
+	if p.ScopeRatio >= 0.1 && len(p.Scopes) > 0 {
+		lines = append(lines, fmt.Sprintf("- Use a scope this repository already uses, most common first: %s. Only introduce a new scope if none fits.", strings.Join(p.Scopes, ", ")))
+	}
+	if p.ConventionalRatio >= 0.5 && len(p.Types) > 0 {
+		lines = append(lines, fmt.Sprintf("- Prefer the types used most here: %s.", strings.Join(p.Types, ", ")))
+	}
+	switch {
+	case p.LowercaseRatio >= 0.8:
+		lines = append(lines, "- Start the subject in lowercase.")
+	case p.LowercaseRatio <= 0.2:
+		lines = append(lines, "- Start the subject with a capital letter.")
+	}
+	if p.AverageLength > 0 {
+		lines = append(lines, fmt.Sprintf("- Aim for about %d characters.", p.AverageLength))
+	}
+	if p.Language != "" && p.Language != "English" {
+		lines = append(lines, fmt.Sprintf("- Write the subject in %s.", p.Language))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Load reads the profile stored in dir
+func Load(dir string) (*Profile, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		return nil, err
+	}
+	var p Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse style profile: %w", err)
+	}
+	return &p, nil
+}
+
+// Save writes the profile to dir, creating it if needed
+func (p *Profile) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, FileName), append(data, '\n'), 0644)
+}
+
+// Stale reports whether the profile should be rebuilt from the history
+func (p *Profile) Stale() bool {
+	return time.Since(p.UpdatedAt) > MaxAge
+}
+
+func startsWithEmoji(s string) bool {
+	if strings.HasPrefix(s, ":") {
+		// GitHub shortcodes such as :sparkles:
+		if end := strings.Index(s[1:], ":"); end > 0 && !strings.ContainsAny(s[1:end+1], " ") {
+			return true
+		}
+	}
+	for _, r := range s {
+		return unicode.Is(unicode.So, r) || (r >= 0x1F000 && r <= 0x1FAFF) || (r >= 0x2600 && r <= 0x27BF)
+	}
+	return false
+}
+
+func firstLetter(s string) rune {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return r
+		}
+	}
+	return 0
+}
+
+// scriptOf names the language implied by the dominant script of s
+func scriptOf(s string) string {
+	counts := make(map[string]int)
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			// Japanese mixes kana with Han, so kana decides
+			counts["Japanese"] += 2
+		case unicode.Is(unicode.Han, r):
+			counts["Chinese"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["Korean"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["Russian"]++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			counts["English"]++
+		}
+	}
+	if counts["Japanese"] > 0 {
+		return "Japanese"
+	}
+	delete(counts, "Japanese")
+
+	best, bestCount := "English", 0
+	for name, n := range counts {
+		// Identifiers and types are ASCII even in non-English messages, so a
+		// few non-Latin characters outweigh many Latin ones
+		if name != "English" {
+			n *= 3
+		}
+		if n > bestCount || (n == bestCount && name < best) {
+			best, bestCount = name, n
+		}
+	}
+	return best
+}
+
+// mostCommon returns up to limit keys ordered by descending count
+func mostCommon(counts map[string]int, limit int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
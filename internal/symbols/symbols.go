@@ -0,0 +1,153 @@
+// Package symbols compares two versions of a source file and reports which
+// functions, methods and types were added, removed or modified, so the
+// prompt can name them instead of leaving the model to infer them from hunks.
+package symbols
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Action is what happened to a symbol
+type Action string
+
+const (
+	Added    Action = "added"
+	Removed  Action = "removed"
+	Modified Action = "modified"
+)
+
+// Change describes one changed symbol
+type Change struct {
+	Action Action
+	// Kind is "func", "method" or "type"
+	Kind string
+	// Name is the symbol name; methods include their receiver, e.g. "(*Engine).Generate"
+	Name string
+	// SignatureChanged is set for modified functions and methods whose
+	// parameters or results changed, as opposed to only their body
+	SignatureChanged bool
+}
+
+// Supported reports whether changes to the file at p can be compared
+func Supported(p string) bool {
+	return path.Ext(p) == ".go"
+}
+
+// Compare lists the symbols that differ between the old and new content of
+// the file at p, sorted by action and name. Either version may be empty for
+// added or deleted files. It returns nil for unsupported files and for
+// sources that don't parse.
+func Compare(p string, oldSrc, newSrc []byte) []Change {
+	if !Supported(p) {
+		return nil
+	}
+	oldDecls, ok := goDecls(oldSrc)
+	if !ok {
+		return nil
+	}
+	newDecls, ok := goDecls(newSrc)
+	if !ok {
+		return nil
+	}
+
+	var changes []Change
+	for name, n := range newDecls {
+		o, existed := oldDecls[name]
+		switch {
+		case !existed:
+			changes = append(changes, Change{Action: Added, Kind: n.kind, Name: name})
+		case o.text != n.text:
+			changes = append(changes, Change{Action: Modified, Kind: n.kind, Name: name, SignatureChanged: o.signature != n.signature})
+		}
+	}
+	for name, o := range oldDecls {
+		if _, ok := newDecls[name]; !ok {
+			changes = append(changes, Change{Action: Removed, Kind: o.kind, Name: name})
+		}
+	}
+
+	order := map[Action]int{Added: 0, Modified: 1, Removed: 2}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Action != changes[j].Action {
+			return order[changes[i].Action] < order[changes[j].Action]
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// decl is a top-level declaration with the source text it is compared by
+type decl struct {
+	kind      string
+	text      string
+	signature string
+}
+
+// goDecls collects the functions, methods and types of a Go file. An empty
+// source has none.
+func goDecls(src []byte) (map[string]decl, bool) {
+	decls := map[string]decl{}
+	if len(bytes.TrimSpace(src)) == 0 {
+		return decls, true
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+	source := func(n ast.Node) string {
+		return string(src[fset.Position(n.Pos()).Offset:fset.Position(n.End()).Offset])
+	}
+	// Declarations are compared without whitespace, so reformatting alone
+	// doesn't make a symbol modified
+	text := func(n ast.Node) string {
+		return strings.Join(strings.Fields(source(n)), "")
+	}
+
+	for _, d := range file.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			name, kind := d.Name.Name, "func"
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name, kind = "("+strings.Join(strings.Fields(source(d.Recv.List[0].Type)), " ")+")."+name, "method"
+			}
+			decls[name] = decl{kind: kind, text: text(d), signature: text(d.Type)}
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				decls[ts.Name.Name] = decl{kind: "type", text: text(ts)}
+			}
+		}
+	}
+	return decls, true
+}
+
+// Summary renders changes on one line, e.g. "added func NewClient; modified
+// method (*Client).Send (signature changed); removed type options". At most
+// limit symbols are named; the rest are counted.
+func Summary(changes []Change, limit int) string {
+	var parts []string
+	for i, c := range changes {
+		if i == limit {
+			parts = append(parts, fmt.Sprintf("%d more", len(changes)-limit))
+			break
+		}
+		part := string(c.Action) + " " + c.Kind + " " + c.Name
+		if c.SignatureChanged {
+			part += " (signature changed)"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
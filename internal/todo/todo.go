@@ -0,0 +1,89 @@
+// Package todo finds the TODO, FIXME, HACK and XXX comments a diff adds or
+// removes, as a lightweight record of technical debt.
+package todo
+
+import (
+	"regexp"
+	"strings"
+
+	"auto-git/internal/git"
+)
+
+// commentTag matches a tag at the start of a comment, such as
+// "// TODO(alice): retry on timeout" or "# FIXME handle EOF"
+var commentTag = regexp.MustCompile(`(?://+|#+|/\*+|^\s*\*+|--|;+|<!--)\s*(TODO|FIXME|HACK|XXX)\b(?:\([^)]*\))?:?\s*(.*?)\s*(?:\*/|-->)?\s*$`)
+
+// Item is one tagged comment
+type Item struct {
+	Path string
+	Tag  string
+	Text string
+}
+
+// Delta lists the tagged comments a diff adds and removes. Comments that were
+// only moved or reindented appear in neither list.
+type Delta struct {
+	Added   []Item
+	Removed []Item
+}
+
+// Empty reports whether the diff neither adds nor removes tagged comments
+func (d Delta) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// Scan finds the tagged comments on the added and removed lines of diff
+func Scan(diff string) Delta {
+	var added, removed []Item
+	for _, p := range git.SplitPatch(diff) {
+		for _, line := range strings.Split(p.Patch, "\n") {
+			if strings.HasPrefix(line, "+++ ") || strings.HasPrefix(line, "--- ") {
+				continue
+			}
+			var list *[]Item
+			switch {
+			case strings.HasPrefix(line, "+"):
+				list = &added
+			case strings.HasPrefix(line, "-"):
+				list = &removed
+			default:
+				continue
+			}
+			if m := commentTag.FindStringSubmatch(strings.TrimSuffix(line[1:], "\r")); m != nil {
+				*list = append(*list, Item{Path: p.Path, Tag: m[1], Text: m[2]})
+			}
+		}
+	}
+
+	// A comment removed in one place and added in another was moved
+	moved := map[[2]string]int{}
+	for _, item := range removed {
+		moved[[2]string{item.Tag, item.Text}]++
+	}
+	var delta Delta
+	for _, item := range added {
+		key := [2]string{item.Tag, item.Text}
+		if moved[key] > 0 {
+			moved[key]--
+			continue
+		}
+		delta.Added = append(delta.Added, item)
+	}
+	for _, item := range removed {
+		key := [2]string{item.Tag, item.Text}
+		if moved[key] > 0 {
+			moved[key]--
+			delta.Removed = append(delta.Removed, item)
+		}
+	}
+	return delta
+}
+
+// String renders the item as "TODO path: text"
+func (i Item) String() string {
+	s := i.Tag + " " + i.Path
+	if i.Text != "" {
+		s += ": " + i.Text
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package tokenizer
+
+import "strings"
+
+// ReplyReserve is the number of tokens kept free for the model's reply when
+// fitting a prompt into the context window
+const ReplyReserve = 512
+
+// contextWindows lists the context window of model families, most specific
+// prefix first
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-5", 400000},
+	{"gpt-4o", 128000},
+	{"chatgpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1-mini", 128000},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"llama3.1", 131072},
+	{"llama3.2", 131072},
+	{"llama3.3", 131072},
+	{"llama-3.1", 131072},
+	{"llama-3.2", 131072},
+	{"llama-3.3", 131072},
+	{"meta-llama-3.1", 131072},
+	{"llama3", 8192},
+	{"llama-3", 8192},
+	{"meta-llama-3", 8192},
+	{"llama2", 4096},
+	{"codellama", 16384},
+	{"qwen2.5-coder", 32768},
+	{"qwen2.5", 32768},
+	{"qwen2", 32768},
+	{"qwen3", 32768},
+	{"qwq", 32768},
+	{"deepseek-r1", 65536},
+	{"deepseek-v3", 65536},
+	{"deepseek-v2.5", 32768},
+	{"deepseek-coder", 16384},
+	{"mistral-nemo", 131072},
+	{"mistral", 32768},
+	{"mixtral", 32768},
+	{"gemma3", 131072},
+	{"gemma2", 8192},
+	{"gemma", 8192},
+	{"phi4", 16384},
+	{"phi3", 4096},
+	{"glm-4", 131072},
+}
+
+// ContextWindow returns the number of tokens the model accepts, or 0 when the
+// model is not known
+func ContextWindow(model string) int {
+	name := normalizeModel(model)
+	for _, w := range contextWindows {
+		if strings.HasPrefix(name, w.prefix) {
+			return w.tokens
+		}
+	}
+	return 0
+}
@@ -0,0 +1,132 @@
+// Package tokenizer estimates how many tokens a prompt uses with a given
+// model, and how many tokens the model accepts.
+//
+// Exact counts would need each model's vocabulary. Instead, text is split into
+// pieces with the pre-tokenization pattern tiktoken uses for the model's
+// encoding, and each piece is estimated from its length, which is close for
+// English and source code. Models with an unknown tokenizer use a byte-based
+// heuristic.
+package tokenizer
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tokenizer estimates the number of tokens in a text
+type Tokenizer interface {
+	// Name identifies the encoding, e.g. "cl100k_base"
+	Name() string
+	Count(text string) int
+}
+
+// bpe estimates tiktoken encodings: text is split like the real encoder
+// splits it, then each piece is priced by length
+type bpe struct {
+	name    string
+	pattern *regexp.Regexp
+	// wordLen is the length up to which a word is usually a single token
+	wordLen int
+	// charsPerToken prices longer words, punctuation runs and non-ASCII text
+	charsPerToken float64
+}
+
+// The tiktoken patterns use lookaheads, which Go's regexp lacks; trailing
+// whitespace before a word is split off separately instead, which yields the
+// same number of pieces.
+var (
+	CL100K Tokenizer = &bpe{
+		name:          "cl100k_base",
+		pattern:       regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`),
+		wordLen:       8,
+		charsPerToken: 4,
+	}
+	O200K Tokenizer = &bpe{
+		name:          "o200k_base",
+		pattern:       regexp.MustCompile(`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+`),
+		wordLen:       9,
+		charsPerToken: 4.5,
+	}
+	// Heuristic is used for models whose tokenizer is not known
+	Heuristic Tokenizer = heuristic{}
+)
+
+func (b *bpe) Name() string { return b.name }
+
+func (b *bpe) Count(text string) int {
+	tokens := 0
+	for _, piece := range b.pattern.FindAllString(text, -1) {
+		tokens += b.countPiece(piece)
+	}
+	return tokens
+}
+
+func (b *bpe) countPiece(piece string) int {
+	if strings.TrimSpace(piece) == "" {
+		// Runs of spaces and newlines are merged into one token
+		return 1
+	}
+	if n := utf8.RuneCountInString(piece); n != len(piece) {
+		// Non-ASCII text is split into far smaller tokens than English
+		return ceilDiv(float64(len(piece)), b.charsPerToken/1.5)
+	}
+	if len(strings.TrimSpace(piece)) <= b.wordLen {
+		return 1
+	}
+	return ceilDiv(float64(len(piece)), b.charsPerToken)
+}
+
+type heuristic struct{}
+
+func (heuristic) Name() string { return "heuristic" }
+
+// Count assumes four bytes per token for ASCII and a token per other character
+func (heuristic) Count(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+func ceilDiv(n, d float64) int {
+	tokens := int(n / d)
+	if float64(tokens)*d < n {
+		tokens++
+	}
+	return max(tokens, 1)
+}
+
+// ForModel returns the tokenizer estimate for a model name, such as
+// "gpt-4o-mini" or "Qwen/Qwen2.5-7B-Instruct"
+func ForModel(model string) Tokenizer {
+	name := normalizeModel(model)
+	for _, prefix := range []string{"gpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "o1", "o3", "o4", "chatgpt-4o"} {
+		if strings.HasPrefix(name, prefix) {
+			return O200K
+		}
+	}
+	// Llama 3, Qwen 2 and DeepSeek split text with the cl100k pattern
+	for _, prefix := range []string{"gpt-4", "gpt-3.5", "text-embedding", "llama3", "llama-3", "meta-llama-3", "qwen2", "qwen3", "qwq", "deepseek"} {
+		if strings.HasPrefix(name, prefix) {
+			return CL100K
+		}
+	}
+	return Heuristic
+}
+
+// normalizeModel lowercases a model name and strips the organization of
+// SiliconFlow/Hugging Face names and the tag of Ollama names
+func normalizeModel(model string) string {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name, _, _ = strings.Cut(name, ":")
+	return name
+}
@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"auto-git/internal/logging"
+)
+
+// How Keys picks the key for a request
+const (
+	// RotateOnError keeps using a key until it is rate limited (429) or
+	// rejected (401), then moves on to the next one
+	RotateOnError = "on_error"
+	// RotateRoundRobin uses the next key for every request, spreading the
+	// load over per-key rate limits, and also moves on after a 429 or 401
+	RotateRoundRobin = "round_robin"
+)
+
+// Keys are the API keys of a provider, sent as "Authorization: Bearer"
+type Keys struct {
+	mu       sync.Mutex
+	keys     []string
+	rotation string
+	current  int
+}
+
+// NewKeys reads a comma-separated list of API keys, as accepted in the API
+// key environment variables
+func NewKeys(apiKey string) *Keys {
+	return &Keys{keys: SplitKeys(apiKey)}
+}
+
+// SplitKeys splits a comma-separated list of API keys
+func SplitKeys(apiKey string) []string {
+	var keys []string
+	for _, key := range strings.Split(apiKey, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Len returns the number of keys
+func (k *Keys) Len() int {
+	if k == nil {
+		return 0
+	}
+	return len(k.keys)
+}
+
+// SetRotation selects RotateOnError or RotateRoundRobin; empty selects
+// RotateOnError
+func (k *Keys) SetRotation(mode string) error {
+	switch mode {
+	case "", RotateOnError, RotateRoundRobin:
+		k.mu.Lock()
+		k.rotation = mode
+		k.mu.Unlock()
+		return nil
+	default:
+		return fmt.Errorf("unknown key rotation %q (supported: %s, %s)", mode, RotateOnError, RotateRoundRobin)
+	}
+}
+
+// pick returns the key for the next request and its index
+func (k *Keys) pick() (int, string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	index := k.current % len(k.keys)
+	if k.rotation == RotateRoundRobin {
+		k.current = index + 1
+	}
+	return index, k.keys[index]
+}
+
+// skip moves past the key at index after it was rate limited or rejected
+func (k *Keys) skip(index int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.rotation != RotateRoundRobin {
+		k.current = index + 1
+	}
+}
+
+// Auth sets the Authorization header from keys, retrying with the next key
+// when one is rate limited or rejected. Requests pass unchanged without keys.
+func Auth(keys *Keys) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if keys.Len() == 0 {
+				return next.RoundTrip(req)
+			}
+			for n := 1; ; n++ {
+				r, err := attempt(req, n)
+				if err != nil {
+					return nil, err
+				}
+				index, key := keys.pick()
+				r.Header.Set("Authorization", "Bearer "+key)
+
+				resp, err := next.RoundTrip(r)
+				if err != nil || n >= keys.Len() || !canReplay(req) || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusUnauthorized) {
+					return resp, err
+				}
+				logging.Debug("API key rejected, trying the next one", "status", resp.StatusCode, "key", logging.MaskSecret(key))
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				keys.skip(index)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stats are the requests Metrics saw for one host
+type Stats struct {
+	Host     string
+	Requests int
+	// Failures are requests that got no response or an error status
+	Failures int
+	// Duration is the total time spent on the requests, including retries
+	Duration time.Duration
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = map[string]*Stats{}
+)
+
+// Metrics records the number, failures and duration of requests per host;
+// see Snapshot
+func Metrics() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			elapsed := time.Since(start)
+
+			statsMu.Lock()
+			defer statsMu.Unlock()
+			s, ok := stats[req.URL.Host]
+			if !ok {
+				s = &Stats{Host: req.URL.Host}
+				stats[req.URL.Host] = s
+			}
+			s.Requests++
+			s.Duration += elapsed
+			if err != nil || resp.StatusCode >= 400 {
+				s.Failures++
+			}
+			return resp, err
+		})
+	}
+}
+
+// Snapshot returns the stats recorded so far, sorted by host
+func Snapshot() []Stats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	out := make([]Stats, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"auto-git/internal/logging"
+	"auto-git/internal/provider"
+)
+
+// DefaultRetries is how often NewClient retries a request that was rate
+// limited or that the server was temporarily unable to handle
+const DefaultRetries = 2
+
+// MaxRetryAfter caps how long Retry waits for a Retry-After header; a server
+// asking for a longer wait gets its response returned at once
+const MaxRetryAfter = 30 * time.Second
+
+// retryBackoff is the wait before the first retry without a Retry-After
+// header; it doubles for each one
+var retryBackoff = 500 * time.Millisecond
+
+// OnWait, when set, is called before Retry waits, e.g. to show a countdown
+var OnWait func(status int, wait time.Duration)
+
+// Retry resends requests answered with 429, 502, 503 or 504 up to retries
+// times, waiting as long as a Retry-After header asks. Connection errors are
+// not retried, so that an unreachable provider is reported at once.
+func Retry(retries int) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			backoff := retryBackoff
+			for n := 1; ; n++ {
+				r, err := attempt(req, n)
+				if err != nil {
+					return nil, err
+				}
+				resp, err := next.RoundTrip(r)
+				if err != nil || n > retries || !canReplay(req) || !temporary(resp.StatusCode) {
+					return resp, err
+				}
+				wait, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+				if !ok {
+					wait = backoff
+					backoff *= 2
+				}
+				if wait > MaxRetryAfter {
+					logging.Debug("not retrying, Retry-After is too long", "url", req.URL.Redacted(), "status", resp.StatusCode, "retry_after", wait)
+					return resp, nil
+				}
+				logging.Debug("retrying request", "url", req.URL.Redacted(), "status", resp.StatusCode, "attempt", n, "wait", wait)
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+
+				if OnWait != nil {
+					OnWait(resp.StatusCode, wait)
+				}
+				select {
+				case <-time.After(wait):
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				}
+			}
+		})
+	}
+}
+
+// temporary reports whether a status means the request may succeed later
+func temporary(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as an HTTP date
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// contextExceeded matches the errors of OpenAI, Anthropic, vLLM, TGI and
+// similar servers rejecting a prompt that is too long for the model
+var contextExceeded = regexp.MustCompile(`(?i)context[ _]length|context window|maximum context|prompt is too long|too many (input )?tokens|tokens? exceeds?`)
+
+// StatusError describes a response that is not 200 OK. Rate limiting gets a
+// readable message with the wait the server asked for, and a prompt too long
+// for the model wraps provider.ErrContextExceeded.
+func StatusError(resp *http.Response, body []byte) error {
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		if contextExceeded.Match(body) {
+			return fmt.Errorf("%w (status code %d): %s", provider.ErrContextExceeded, resp.StatusCode, body)
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return fmt.Errorf("rate limited by the provider (status code 429), retry after %s: %s", wait.Round(time.Second), body)
+		}
+		return fmt.Errorf("rate limited by the provider (status code 429): %s", body)
+	}
+	return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+}
@@ -0,0 +1,94 @@
+// Package transport is the HTTP stack shared by the providers: a chain of
+// http.RoundTripper middleware adding authentication, retries, logging and
+// metrics to every request, so that each client only builds requests and
+// reads replies.
+package transport
+
+import (
+	"net/http"
+	"time"
+
+	"auto-git/internal/logging"
+)
+
+// Middleware wraps a RoundTripper with cross-cutting behavior
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain wraps base (or http.DefaultTransport when nil) with middleware; the
+// first one sees each request first
+func Chain(base http.RoundTripper, middleware ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		base = middleware[i](base)
+	}
+	return base
+}
+
+// Logging logs every request and response at debug level; see logging.Transport
+func Logging() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return logging.NewTransport(next)
+	}
+}
+
+// Headers sets header on every request, replacing values set by earlier
+// middleware such as Auth. Later changes to header apply to later requests.
+func Headers(header http.Header) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if len(header) == 0 {
+				return next.RoundTrip(req)
+			}
+			r := req.Clone(req.Context())
+			for name, values := range header {
+				r.Header[name] = values
+			}
+			return next.RoundTrip(r)
+		})
+	}
+}
+
+// NewClient returns an HTTP client sending requests through the standard
+// chain: metrics, retries, authentication with keys, the custom header, then
+// logging of each attempt as sent. With a socket path, requests go to that
+// Unix domain socket instead of over TCP.
+func NewClient(timeout time.Duration, socket string, keys *Keys, header http.Header) *http.Client {
+	var base http.RoundTripper
+	if socket != "" {
+		base = unixTransport(socket)
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: Chain(base, Metrics(), Retry(DefaultRetries), Auth(keys), Headers(header), Logging()),
+	}
+}
+
+// canReplay reports whether req can be sent again: it has no body, or one
+// that can be read afresh
+func canReplay(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// attempt returns the copy of req to send for attempt n, starting at 1, with
+// a fresh body after the first. Callers check canReplay before retrying.
+func attempt(req *http.Request, n int) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if n == 1 || req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone.Body = body
+	return clone, nil
+}
@@ -0,0 +1,36 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SplitUnixEndpoint splits an endpoint such as "unix:///var/run/ollama.sock"
+// into the socket path and the base URL of the requests sent over it. A base
+// path, e.g. for OpenAI-compatible servers, is given as "?path=/v1". ok is
+// false for other endpoints.
+func SplitUnixEndpoint(endpoint string) (socket, baseURL string, ok bool) {
+	if !strings.HasPrefix(endpoint, "unix://") {
+		return "", "", false
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", false
+	}
+	// The host doesn't matter; requests never leave the socket
+	return u.Host + u.Path, "http://localhost" + strings.TrimRight(u.Query().Get("path"), "/"), true
+}
+
+// unixTransport returns a copy of http.DefaultTransport connecting to socket
+func unixTransport(socket string) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", socket)
+	}
+	return t
+}
@@ -0,0 +1,415 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"auto-git/internal/i18n"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// AppFile is a changed file shown by RunApp. A file with both staged and
+// unstaged changes is listed twice.
+type AppFile struct {
+	Path      string
+	Staged    bool
+	Additions int
+	Deletions int
+	// Patch is the file's part of the diff, starting with "diff --git"
+	Patch string
+}
+
+// AppActions are the repository operations behind RunApp's keys. They run
+// outside the UI goroutine, one at a time.
+type AppActions struct {
+	// Load lists the changed files
+	Load func() ([]AppFile, error)
+	// Stage stages or, with staged false, unstages path
+	Stage func(path string, staged bool) error
+	// StageAll stages every change
+	StageAll func() error
+	// Generate returns a message for the staged changes, or for all changes
+	// when nothing is staged
+	Generate func() (string, error)
+	// Commit commits the staged changes, or all changes when nothing is staged
+	Commit func(message string) error
+	// Push pushes the current branch and describes the outcome
+	Push func() (string, error)
+}
+
+// appHelp lists the active keys of RunApp
+func appHelp() string {
+	return strings.Join([]string{
+		keyHelp("move", KeyUp, KeyDown),
+		keyHelp("stage/unstage", KeyStage),
+		keyHelp("stage all", KeyStageAll),
+		keyHelp("regenerate", KeyRegenerate),
+		keyHelp("edit message", KeyEdit),
+		keyHelp("commit", KeyCommit),
+		keyHelp("push", KeyPush),
+		keyHelp("quit", KeyQuit),
+	}, " • ")
+}
+
+var (
+	appPaneStyle   = lipgloss.NewStyle().Border(lipgloss.RoundedBorder())
+	appStagedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	appErrorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+// appMessageLines is the height of the message editor
+const appMessageLines = 6
+
+// appLoadedMsg carries the result of AppActions.Load
+type appLoadedMsg struct {
+	files []AppFile
+	err   error
+}
+
+// appGeneratedMsg carries the result of AppActions.Generate
+type appGeneratedMsg struct {
+	message string
+	err     error
+}
+
+// appDoneMsg reports a finished stage, commit or push
+type appDoneMsg struct {
+	status string
+	err    error
+	// reload is set when the list of changes may have changed
+	reload bool
+	// committed is set after a commit, whose message is then cleared
+	committed bool
+}
+
+type appModel struct {
+	actions AppActions
+	files   []AppFile
+	cursor  int
+	offset  int
+	diff    viewport.Model
+	message textarea.Model
+	editing bool
+	busy    string
+	status  string
+	failed  bool
+	width   int
+	height  int
+	ready   bool
+	// loaded is set once the changes were listed for the first time
+	loaded bool
+}
+
+func (m appModel) Init() tea.Cmd {
+	return m.load()
+}
+
+func (m appModel) load() tea.Cmd {
+	return func() tea.Msg {
+		files, err := m.actions.Load()
+		return appLoadedMsg{files: files, err: err}
+	}
+}
+
+// run starts an action unless another one is still running
+func (m *appModel) run(label string, action func() tea.Msg) tea.Cmd {
+	if m.busy != "" {
+		return nil
+	}
+	m.busy = label
+	return action
+}
+
+func (m *appModel) generate() tea.Cmd {
+	return m.run(i18n.T("Generating message..."), func() tea.Msg {
+		message, err := m.actions.Generate()
+		return appGeneratedMsg{message: message, err: err}
+	})
+}
+
+func (m *appModel) setStatus(status string, err error) {
+	m.status, m.failed = status, err != nil
+	if err != nil {
+		// git's output spans several lines; the status line has one
+		m.status = strings.Join(strings.Fields(err.Error()), " ")
+	}
+}
+
+func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.layout()
+		return m, nil
+
+	case appLoadedMsg:
+		m.busy = ""
+		if msg.err != nil {
+			m.setStatus("", msg.err)
+			return m, nil
+		}
+		m.files = msg.files
+		m.cursor = min(m.cursor, max(len(m.files)-1, 0))
+		m.showDiff()
+		// Propose a message as soon as there is something to commit
+		if !m.loaded && len(m.files) > 0 && strings.TrimSpace(m.message.Value()) == "" {
+			m.loaded = true
+			return m, m.generate()
+		}
+		m.loaded = true
+		return m, nil
+
+	case appGeneratedMsg:
+		m.busy = ""
+		if msg.err != nil {
+			m.setStatus("", msg.err)
+			return m, nil
+		}
+		m.message.SetValue(msg.message)
+		m.setStatus(i18n.T("Message generated"), nil)
+		return m, nil
+
+	case appDoneMsg:
+		m.busy = ""
+		m.setStatus(msg.status, msg.err)
+		if msg.committed && msg.err == nil {
+			m.message.Reset()
+		}
+		if msg.reload {
+			m.busy = i18n.T("Loading changes...")
+			return m, m.load()
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.editing {
+			switch msg.String() {
+			case "esc", "tab":
+				m.editing = false
+				m.message.Blur()
+				return m, nil
+			case "ctrl+c":
+				return m, tea.Quit
+			}
+			var cmd tea.Cmd
+			m.message, cmd = m.message.Update(msg)
+			return m, cmd
+		}
+		return m.handleKey(msg)
+	}
+
+	var cmd tea.Cmd
+	m.diff, cmd = m.diff.Update(msg)
+	return m, cmd
+}
+
+func (m appModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case keyIs(msg, KeyQuit), msg.String() == "ctrl+c":
+		return m, tea.Quit
+
+	case keyIs(msg, KeyUp):
+		if m.cursor > 0 {
+			m.cursor--
+			m.showDiff()
+		}
+	case keyIs(msg, KeyDown):
+		if m.cursor < len(m.files)-1 {
+			m.cursor++
+			m.showDiff()
+		}
+
+	case keyIs(msg, KeyStage):
+		if len(m.files) == 0 {
+			return m, nil
+		}
+		f := m.files[m.cursor]
+		return m, m.run(i18n.T("Staging..."), func() tea.Msg {
+			err := m.actions.Stage(f.Path, !f.Staged)
+			status := i18n.Sprintf("Staged %s", f.Path)
+			if f.Staged {
+				status = i18n.Sprintf("Unstaged %s", f.Path)
+			}
+			return appDoneMsg{status: status, err: err, reload: true}
+		})
+	case keyIs(msg, KeyStageAll):
+		return m, m.run(i18n.T("Staging..."), func() tea.Msg {
+			return appDoneMsg{status: i18n.T("Staged all changes"), err: m.actions.StageAll(), reload: true}
+		})
+
+	case keyIs(msg, KeyRegenerate):
+		if len(m.files) == 0 {
+			m.setStatus("", errors.New(i18n.T("no changes to describe")))
+			return m, nil
+		}
+		return m, m.generate()
+
+	case keyIs(msg, KeyEdit):
+		m.editing = true
+		return m, m.message.Focus()
+
+	case keyIs(msg, KeyCommit):
+		message := strings.TrimSpace(m.message.Value())
+		if message == "" {
+			m.setStatus("", errors.New(i18n.T("commit message cannot be empty")))
+			return m, nil
+		}
+		if len(m.files) == 0 {
+			m.setStatus("", errors.New(i18n.T("no changes to commit")))
+			return m, nil
+		}
+		return m, m.run(i18n.T("Committing..."), func() tea.Msg {
+			err := m.actions.Commit(message)
+			return appDoneMsg{status: i18n.T("Committed"), err: err, reload: true, committed: true}
+		})
+
+	case keyIs(msg, KeyPush):
+		return m, m.run(i18n.T("Pushing..."), func() tea.Msg {
+			status, err := m.actions.Push()
+			return appDoneMsg{status: status, err: err}
+		})
+
+	default:
+		// pgup/pgdn and the like scroll the diff
+		var cmd tea.Cmd
+		m.diff, cmd = m.diff.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// listWidth is the width of the file list pane, borders included
+func (m appModel) listWidth() int {
+	return min(max(m.width/3, 24), 50)
+}
+
+// paneHeight is the inner height of the file list and diff panes
+func (m appModel) paneHeight() int {
+	// Two borders each for the panes and the message, plus the status and
+	// help lines
+	return max(m.height-appMessageLines-6, 1)
+}
+
+func (m *appModel) layout() {
+	diffWidth := max(m.width-m.listWidth()-2, 1)
+	if !m.ready {
+		m.diff = viewport.New(diffWidth, m.paneHeight())
+		m.ready = true
+	} else {
+		m.diff.Width, m.diff.Height = diffWidth, m.paneHeight()
+	}
+	m.message.SetWidth(max(m.width-2, 1))
+	m.message.SetHeight(appMessageLines)
+	m.showDiff()
+}
+
+// showDiff shows the patch of the file under the cursor
+func (m *appModel) showDiff() {
+	if !m.ready {
+		return
+	}
+	if len(m.files) == 0 {
+		m.diff.SetContent(i18n.T("No changes"))
+		return
+	}
+	lines, _ := renderDiff(m.files[m.cursor].Patch)
+	m.diff.SetContent(strings.Join(lines, "\n"))
+	m.diff.GotoTop()
+
+	// Keep the cursor within the visible part of the list
+	height := m.paneHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+}
+
+func (m appModel) fileList() string {
+	width := m.listWidth() - 2
+	var lines []string
+	for i := m.offset; i < len(m.files) && i < m.offset+m.paneHeight(); i++ {
+		f := m.files[i]
+		mark := "○"
+		if f.Staged {
+			mark = appStagedStyle.Render("●")
+		}
+		stats := fmt.Sprintf(" +%d -%d", f.Additions, f.Deletions)
+		path := f.Path
+		if room := width - 4 - len(stats); len([]rune(path)) > room && room > 1 {
+			path = "…" + string([]rune(path)[len([]rune(path))-room+1:])
+		}
+		line := fmt.Sprintf("%s %s%s", mark, path, counterStyle.Render(stats))
+		if i == m.cursor {
+			line = selectedItemStyle.UnsetPaddingLeft().Render("> ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	if len(m.files) == 0 {
+		lines = append(lines, counterStyle.Render(i18n.T("nothing to commit")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m appModel) View() string {
+	if !m.ready {
+		return "\n  " + i18n.T("Loading changes...")
+	}
+	list := appPaneStyle.Width(m.listWidth() - 2).Height(m.paneHeight()).Render(m.fileList())
+	diff := appPaneStyle.Render(m.diff.View())
+	message := appPaneStyle.Render(m.message.View())
+
+	status := m.status
+	switch {
+	case m.busy != "":
+		status = m.busy
+	case m.failed:
+		status = appErrorStyle.Render(status)
+	}
+	help := appHelp()
+	if m.editing {
+		help = "esc/tab " + i18n.T("done editing") + " • ctrl+c " + i18n.T("quit")
+	}
+	// Long errors and help are cut rather than wrapped, to keep the layout
+	line := lipgloss.NewStyle().MaxWidth(m.width)
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Top, list, diff),
+		message,
+		line.Render(status),
+		line.Render(reviewFooterStyle.Render(help)),
+	)
+}
+
+// RunApp runs the full-screen application: the changed files, the diff of
+// the selected one and the commit message stay on screen while the user
+// stages files, regenerates and edits the message, commits and pushes, until
+// they quit.
+func RunApp(actions AppActions) error {
+	if !canUseTUI() {
+		return fmt.Errorf("the full-screen interface needs a terminal: %w", ErrNonInteractive)
+	}
+
+	ta := textarea.New()
+	ta.Placeholder = i18n.T("Commit message")
+	ta.ShowLineNumbers = false
+	ta.Prompt = ""
+	ta.CharLimit = 0
+
+	m := appModel{
+		actions: actions,
+		message: ta,
+		busy:    i18n.T("Loading changes..."),
+	}
+	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+		return fmt.Errorf("failed to run UI: %w", err)
+	}
+	return nil
+}
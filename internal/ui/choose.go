@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"auto-git/internal/i18n"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Candidate is one of several generated messages to choose from, with a
+// label saying where it came from, such as the model and its latency
+type Candidate struct {
+	Label   string
+	Message string
+}
+
+// SelectMessage lets the user pick one of candidates by its subject and
+// returns its index. Without a user to ask it returns 0.
+func SelectMessage(candidates []Candidate) (int, error) {
+	if len(candidates) == 0 || !IsInteractive() {
+		return 0, nil
+	}
+	if !canUseTUI() {
+		return selectMessagePlain(candidates)
+	}
+
+	items := make([]list.Item, len(candidates))
+	for i, c := range candidates {
+		items[i] = item{title: subjectOf(c.Message), desc: c.Label}
+	}
+	l := list.New(items, itemDelegate{}, 80, len(items)+6)
+	l.Title = i18n.T("Select a commit message")
+	l.SetShowStatusBar(false)
+	// Indexes must stay those of candidates
+	l.SetFilteringEnabled(false)
+	l.Styles.Title = titleStyle
+	l.Styles.PaginationStyle = lipgloss.NewStyle()
+	l.Styles.HelpStyle = helpStyle
+	setListKeys(&l)
+
+	finalModel, err := tea.NewProgram(modelSelectionModel{list: l}, tea.WithAltScreen()).Run()
+	if err != nil {
+		return 0, fmt.Errorf("failed to run UI: %w", err)
+	}
+	m, ok := finalModel.(modelSelectionModel)
+	if ok && m.cancelled {
+		return 0, fmt.Errorf("message selection %w", ErrCancelled)
+	}
+	if !ok || m.choice == "" {
+		return 0, nil
+	}
+	return m.list.Index(), nil
+}
+
+// selectMessagePlain is the line-based fallback for SelectMessage
+func selectMessagePlain(candidates []Candidate) (int, error) {
+	for i, c := range candidates {
+		fmt.Fprintf(os.Stderr, "%d. %s  (%s)\n", i+1, subjectOf(c.Message), c.Label)
+	}
+	for {
+		answer, err := readLine(i18n.Sprintf("Select a commit message by number [%d]: ", 1))
+		if err != nil {
+			return 0, err
+		}
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			return 0, nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(candidates) {
+			return n - 1, nil
+		}
+	}
+}
+
+// subjectOf returns the first line of a message
+func subjectOf(message string) string {
+	subject, _, _ := strings.Cut(message, "\n")
+	return subject
+}
@@ -0,0 +1,15 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/muesli/termenv"
+)
+
+// DisableColor turns off ANSI styling for both fatih/color output (change
+// summary, spinner) and lipgloss styles (TUIs)
+func DisableColor() {
+	color.NoColor = true
+	lipgloss.SetColorProfile(termenv.Ascii)
+	stderrRenderer.SetColorProfile(termenv.Ascii)
+}
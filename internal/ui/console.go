@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"os"
+
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
+)
+
+// legacyConsole is set when a Windows console can't process ANSI escape
+// sequences, as in older cmd.exe; output is then plain ASCII without colors
+var legacyConsole bool
+
+// PrepareConsole enables ANSI escape sequences on Windows consoles; elsewhere
+// it does nothing. Consoles that don't support them fall back to plain output.
+func PrepareConsole() {
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		// Cygwin and MSYS terminals such as mintty understand ANSI natively
+		if !isatty.IsTerminal(f.Fd()) {
+			continue
+		}
+		if _, err := termenv.EnableVirtualTerminalProcessing(termenv.NewOutput(f)); err != nil {
+			legacyConsole = true
+		}
+	}
+	if legacyConsole {
+		DisableColor()
+	}
+}
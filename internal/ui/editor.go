@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const editorInstructions = `Please enter the commit message for your changes. Lines starting
+with '#' will be ignored, and an empty message aborts the commit.`
+
+// EditInEditor writes message and commented-out context lines to path, opens it
+// in editor, and returns the edited message with comment lines removed
+func EditInEditor(editor, path, message string, context []string) (string, error) {
+	if !IsInteractive() {
+		return "", fmt.Errorf("cannot open editor: %w", ErrNonInteractive)
+	}
+
+	var b strings.Builder
+	b.WriteString(message)
+	b.WriteString("\n\n")
+	for _, line := range strings.Split(editorInstructions, "\n") {
+		b.WriteString("# " + line + "\n")
+	}
+	if len(context) > 0 {
+		b.WriteString("#\n")
+		for _, line := range context {
+			b.WriteString("# " + line + "\n")
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write message file: %w", err)
+	}
+
+	cmd := editorCommand(editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read message file: %w", err)
+	}
+
+	edited := stripComments(string(data))
+	if edited == "" {
+		return "", fmt.Errorf("empty message, %w", ErrCancelled)
+	}
+	return edited, nil
+}
+
+// editorCommand runs editor through the shell, as git does, so values such as
+// "code --wait" work
+func editorCommand(editor, path string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", editor+` "`+path+`"`)
+	}
+	return exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+}
+
+// stripComments drops '#' lines and surrounding blank lines from an edited message
+func stripComments(text string) string {
+	var kept []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	diffFileStyle    = lipgloss.NewStyle().Bold(true)
+	diffHunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	diffMetaStyle    = lipgloss.NewStyle().Faint(true)
+	keywordStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("5")).Bold(true)
+	stringStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	commentStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Italic(true)
+)
+
+// language describes just enough of a programming language to highlight
+// keywords, string literals, and line comments
+type language struct {
+	keywords     map[string]bool
+	lineComment  string
+	stringQuotes string
+}
+
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+var (
+	langGo = &language{
+		keywords:     words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var nil true false"),
+		lineComment:  "//",
+		stringQuotes: "\"'`",
+	}
+	langPython = &language{
+		keywords:     words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield None True False self"),
+		lineComment:  "#",
+		stringQuotes: "\"'",
+	}
+	langJS = &language{
+		keywords:     words("async await break case catch class const continue default delete do else export extends finally for from function if import in instanceof interface let new null of return static super switch this throw try type typeof undefined var void while yield true false"),
+		lineComment:  "//",
+		stringQuotes: "\"'`",
+	}
+	langRust = &language{
+		keywords:     words("as async await break const continue crate else enum extern false fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while"),
+		lineComment:  "//",
+		stringQuotes: "\"",
+	}
+	langC = &language{
+		keywords:     words("auto break case catch char class const continue default delete do double else enum extern final float for if int long namespace new private protected public return short static struct switch template this throw try typedef union unsigned virtual void volatile while boolean import package extends implements null true false"),
+		lineComment:  "//",
+		stringQuotes: "\"'",
+	}
+	langShell = &language{
+		keywords:     words("if then else elif fi for in do done while until case esac function return local export"),
+		lineComment:  "#",
+		stringQuotes: "\"'",
+	}
+)
+
+var languagesByExt = map[string]*language{
+	".go":   langGo,
+	".py":   langPython,
+	".js":   langJS,
+	".jsx":  langJS,
+	".ts":   langJS,
+	".tsx":  langJS,
+	".mjs":  langJS,
+	".rs":   langRust,
+	".c":    langC,
+	".h":    langC,
+	".cc":   langC,
+	".cpp":  langC,
+	".hpp":  langC,
+	".java": langC,
+	".kt":   langC,
+	".cs":   langC,
+	".sh":   langShell,
+	".bash": langShell,
+	".zsh":  langShell,
+}
+
+func languageFor(path string) *language {
+	return languagesByExt[strings.ToLower(filepath.Ext(path))]
+}
+
+// renderDiff colors a unified diff for display and returns the rendered lines
+// together with the index of the first line of each file section
+func renderDiff(diff string) ([]string, []int) {
+	var lines []string
+	var fileStarts []int
+	var lang *language
+
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			fileStarts = append(fileStarts, len(lines))
+			lang = languageFor(pathFromDiffLine(line))
+			lines = append(lines, diffFileStyle.Render(line))
+		case strings.HasPrefix(line, "=== "):
+			lines = append(lines, diffFileStyle.Render(line))
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "),
+			strings.HasPrefix(line, "index "), strings.HasPrefix(line, "new file"),
+			strings.HasPrefix(line, "deleted file"), strings.HasPrefix(line, "similarity"),
+			strings.HasPrefix(line, "rename "), strings.HasPrefix(line, "old mode"),
+			strings.HasPrefix(line, "new mode"):
+			lines = append(lines, diffMetaStyle.Render(line))
+		case strings.HasPrefix(line, "@@"):
+			lines = append(lines, diffHunkStyle.Render(line))
+		case strings.HasPrefix(line, "+"):
+			lines = append(lines, diffAddedStyle.Render("+")+highlightCode(line[1:], lang, diffAddedStyle))
+		case strings.HasPrefix(line, "-"):
+			lines = append(lines, diffRemovedStyle.Render("-")+highlightCode(line[1:], lang, diffRemovedStyle))
+		default:
+			lines = append(lines, highlightCode(line, lang, lipgloss.NewStyle()))
+		}
+	}
+
+	return lines, fileStarts
+}
+
+func pathFromDiffLine(line string) string {
+	if idx := strings.LastIndex(line, " b/"); idx >= 0 {
+		return line[idx+len(" b/"):]
+	}
+	return ""
+}
+
+// highlightCode applies keyword, string, and comment styles to a single line of
+// code; everything else is rendered with base
+func highlightCode(code string, lang *language, base lipgloss.Style) string {
+	if lang == nil || code == "" {
+		return base.Render(code)
+	}
+
+	var b strings.Builder
+	runes := []rune(code)
+	plainStart := 0
+
+	flushPlain := func(end int) {
+		if end > plainStart {
+			b.WriteString(base.Render(string(runes[plainStart:end])))
+		}
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case lang.lineComment != "" && strings.HasPrefix(string(runes[i:]), lang.lineComment):
+			flushPlain(i)
+			b.WriteString(commentStyle.Render(string(runes[i:])))
+			return b.String()
+		case strings.ContainsRune(lang.stringQuotes, r):
+			flushPlain(i)
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(runes) {
+				end = len(runes) - 1
+			}
+			b.WriteString(stringStyle.Render(string(runes[i : end+1])))
+			i = end + 1
+			plainStart = i
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			if lang.keywords[string(runes[i:end])] {
+				flushPlain(i)
+				b.WriteString(keywordStyle.Render(string(runes[i:end])))
+				plainStart = end
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	flushPlain(len(runes))
+
+	return b.String()
+}
@@ -1,25 +1,31 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
+	"auto-git/internal/i18n"
 	"auto-git/internal/provider"
 
 	"github.com/charmbracelet/bubbles/list"
-	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 var (
-	titleStyle        = lipgloss.NewStyle().MarginLeft(2)
+	titleStyle        lipgloss.Style
 	itemStyle         = lipgloss.NewStyle().PaddingLeft(4)
-	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
+	selectedItemStyle lipgloss.Style
 	helpStyle         = lipgloss.NewStyle().MarginTop(2).MarginLeft(4)
 )
 
+// ErrCancelled is returned when the user dismisses an interactive prompt
+var ErrCancelled = errors.New("cancelled by user")
+
 type Model struct {
 	list     list.Model
 	choice   string
@@ -33,8 +39,9 @@ type item struct {
 func (i item) FilterValue() string { return i.title }
 
 type modelSelectionModel struct {
-	list   list.Model
-	choice string
+	list      list.Model
+	choice    string
+	cancelled bool
 }
 
 func (m modelSelectionModel) Init() tea.Cmd {
@@ -48,11 +55,17 @@ func (m modelSelectionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		switch keypress := msg.String(); keypress {
-		case "ctrl+c", "q":
+		// While the filter input is focused, keys belong to it
+		if m.list.FilterState() == list.Filtering && msg.String() != "ctrl+c" {
+			break
+		}
+
+		switch {
+		case msg.String() == "ctrl+c", keyIs(msg, KeyQuit):
+			m.cancelled = true
 			return m, tea.Quit
 
-		case "enter":
+		case keyIs(msg, KeyAccept):
 			i, ok := m.list.SelectedItem().(item)
 			if ok {
 				m.choice = i.title
@@ -74,29 +87,36 @@ func (m modelSelectionModel) View() string {
 }
 
 func SelectModel(models []provider.Model, defaultModel string) (string, error) {
+	if len(models) == 0 {
+		return defaultModel, nil
+	}
+
 	items := make([]list.Item, len(models))
 	selectedIndex := 0
 
 	for i, m := range models {
-		desc := ""
-		if m.Size > 0 {
-			desc = fmt.Sprintf("Size: %d bytes", m.Size)
-		} else if m.ModifiedAt != "" {
-			desc = fmt.Sprintf("Modified: %s", m.ModifiedAt)
-		}
-		items[i] = item{title: m.Name, desc: desc}
+		items[i] = item{title: m.Name, desc: describeModel(m)}
 		if m.Name == defaultModel {
 			selectedIndex = i
 		}
 	}
 
+	if !IsInteractive() {
+		return models[selectedIndex].Name, nil
+	}
+	if !canUseTUI() {
+		return selectModelPlain(models, selectedIndex)
+	}
+
 	l := list.New(items, itemDelegate{}, 80, 20)
 	l.Title = "Select Model"
 	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false)
+	// Type / to fuzzy-search; matches are ranked by score
+	l.SetFilteringEnabled(true)
 	l.Styles.Title = titleStyle
 	l.Styles.PaginationStyle = lipgloss.NewStyle()
 	l.Styles.HelpStyle = helpStyle
+	setListKeys(&l)
 	l.Select(selectedIndex)
 
 	m := modelSelectionModel{list: l}
@@ -109,6 +129,9 @@ func SelectModel(models []provider.Model, defaultModel string) (string, error) {
 	}
 
 	if m, ok := finalModel.(modelSelectionModel); ok {
+		if m.cancelled {
+			return "", fmt.Errorf("model selection %w", ErrCancelled)
+		}
 		if m.choice != "" {
 			return m.choice, nil
 		}
@@ -121,6 +144,48 @@ func SelectModel(models []provider.Model, defaultModel string) (string, error) {
 	return defaultModel, nil
 }
 
+// describeModel summarizes the metadata a provider reported for a model
+func describeModel(m provider.Model) string {
+	var parts []string
+	if m.ParameterSize != "" {
+		parts = append(parts, m.ParameterSize)
+	}
+	if m.Quantization != "" {
+		parts = append(parts, m.Quantization)
+	}
+	if m.Family != "" {
+		parts = append(parts, m.Family)
+	}
+	if m.Size > 0 {
+		parts = append(parts, formatBytes(m.Size))
+	}
+	if m.OwnedBy != "" {
+		parts = append(parts, "owned by "+m.OwnedBy)
+	}
+	if m.Created > 0 {
+		parts = append(parts, "created "+time.Unix(m.Created, 0).Format("2006-01-02"))
+	}
+	if len(parts) == 0 && m.ModifiedAt != "" {
+		parts = append(parts, "modified "+m.ModifiedAt)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+var itemDescStyle = lipgloss.NewStyle().Faint(true)
+
 type itemDelegate struct{}
 
 func (d itemDelegate) Height() int                             { return 1 }
@@ -133,6 +198,9 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	}
 
 	str := fmt.Sprintf("%d. %s", index+1, i.title)
+	if i.desc != "" {
+		str += "  " + itemDescStyle.Render(i.desc)
+	}
 
 	fn := itemStyle.Render
 	if index == m.Index() {
@@ -144,14 +212,25 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	fmt.Fprint(w, fn(str))
 }
 
+// SubjectLimit is the conventional maximum length of a commit subject line;
+// the editor also soft-wraps the body at this width
+const SubjectLimit = 72
+
+var (
+	counterStyle     = lipgloss.NewStyle().Faint(true)
+	counterOverStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
 type messageEditModel struct {
-	textInput textinput.Model
-	message   string
-	done      bool
+	textArea textarea.Model
+	message  string
+	done     bool
+	// scopes are offered for tab completion inside "type(" on the subject line
+	scopes []string
 }
 
 func (m messageEditModel) Init() tea.Cmd {
-	return textinput.Blink
+	return textarea.Blink
 }
 
 func (m messageEditModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -161,39 +240,104 @@ func (m messageEditModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "esc":
-			m.done = true
 			m.message = ""
 			return m, tea.Quit
 
-		case "enter":
+		case "ctrl+s", "ctrl+d":
 			m.done = true
-			m.message = m.textInput.Value()
+			m.message = strings.TrimSpace(m.textArea.Value())
 			return m, tea.Quit
+
+		case "tab":
+			if partial, ok := m.scopeAtCursor(); ok {
+				if insert := completeScope(matchScopes(m.scopes, partial), partial); insert != "" {
+					m.textArea.InsertString(insert)
+				}
+				return m, nil
+			}
 		}
 	}
 
-	m.textInput, cmd = m.textInput.Update(msg)
+	m.textArea, cmd = m.textArea.Update(msg)
 	return m, cmd
 }
 
+// counter reports the subject length against SubjectLimit and the total size
+func (m messageEditModel) counter() string {
+	value := m.textArea.Value()
+	subject, _, _ := strings.Cut(value, "\n")
+	subjectLen := len([]rune(subject))
+
+	style := counterStyle
+	if subjectLen > SubjectLimit {
+		style = counterOverStyle
+	}
+	return style.Render(fmt.Sprintf("subject %d/%d", subjectLen, SubjectLimit)) +
+		counterStyle.Render(fmt.Sprintf(" • %d chars, %d lines", len([]rune(value)), m.textArea.LineCount()))
+}
+
+// scopeAtCursor returns the partial scope when the cursor is inside an unclosed
+// scope on the subject line
+func (m messageEditModel) scopeAtCursor() (string, bool) {
+	if len(m.scopes) == 0 || m.textArea.Line() != 0 {
+		return "", false
+	}
+	subject, _, _ := strings.Cut(m.textArea.Value(), "\n")
+	runes := []rune(subject)
+	info := m.textArea.LineInfo()
+	col := min(info.StartColumn+info.CharOffset, len(runes))
+	return openScope(string(runes[:col]))
+}
+
+// scopeHint lists the scopes matching what is being typed, or all of them
+func (m messageEditModel) scopeHint() string {
+	if len(m.scopes) == 0 {
+		return ""
+	}
+	scopes := m.scopes
+	if partial, ok := m.scopeAtCursor(); ok {
+		scopes = matchScopes(scopes, partial)
+	}
+	const shown = 8
+	if len(scopes) > shown {
+		scopes = append(scopes[:shown:shown], "…")
+	}
+	return counterStyle.Render("scopes (tab to complete): "+strings.Join(scopes, ", ")) + "\n"
+}
+
 func (m messageEditModel) View() string {
 	return fmt.Sprintf(
-		"\nEdit commit message:\n\n%s\n\n%s",
-		m.textInput.View(),
-		"(enter to confirm, esc to cancel)",
+		"\nEdit commit message (first line is the subject, then a blank line and the body):\n\n%s\n\n%s\n%s%s",
+		m.textArea.View(),
+		m.counter(),
+		m.scopeHint(),
+		"(ctrl+s to confirm, esc to cancel)",
 	) + "\n"
 }
 
-func EditCommitMessage(initialMessage string) (string, error) {
-	ti := textinput.New()
-	ti.Placeholder = "Enter commit message..."
-	ti.SetValue(initialMessage)
-	ti.Focus()
-	ti.CharLimit = 200
-	ti.Width = 80
+// EditCommitMessage lets the user edit message in a text area. Known scopes
+// are listed and can be completed with tab while typing "type(".
+func EditCommitMessage(initialMessage string, scopes []string) (string, error) {
+	if !IsInteractive() {
+		return "", fmt.Errorf("cannot edit commit message: %w", ErrNonInteractive)
+	}
+	if !canUseTUI() {
+		return editMessagePlain(initialMessage, scopes)
+	}
+
+	ta := textarea.New()
+	ta.Placeholder = i18n.T("Enter commit message...")
+	ta.ShowLineNumbers = false
+	ta.Prompt = ""
+	ta.CharLimit = 0
+	ta.SetWidth(SubjectLimit)
+	ta.SetHeight(10)
+	ta.SetValue(initialMessage)
+	ta.Focus()
 
 	m := messageEditModel{
-		textInput: ti,
+		textArea: ta,
+		scopes:   scopes,
 	}
 
 	p := tea.NewProgram(m, tea.WithAltScreen())
@@ -209,7 +353,7 @@ func EditCommitMessage(initialMessage string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("message editing cancelled")
+	return "", fmt.Errorf("message editing %w", ErrCancelled)
 }
 
 type progressModel struct {
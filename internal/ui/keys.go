@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"auto-git/internal/i18n"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Actions that can be bound to other keys with SetKeys
+const (
+	KeyAccept     = "accept"
+	KeyEdit       = "edit"
+	KeyEditor     = "editor"
+	KeyRegenerate = "regenerate"
+	KeyQuit       = "quit"
+	KeyUp         = "up"
+	KeyDown       = "down"
+	KeyNextFile   = "next_file"
+	KeyPrevFile   = "prev_file"
+	KeyStage      = "stage"
+	KeyStageAll   = "stage_all"
+	KeyCommit     = "commit"
+	KeyPush       = "push"
+)
+
+// defaultKeys are the keys of each action unless SetKeys changes them. Key
+// names are those of bubbletea, e.g. "enter", "ctrl+q" or "shift+tab".
+var defaultKeys = map[string][]string{
+	KeyAccept:     {"enter", "y"},
+	KeyEdit:       {"i"},
+	KeyEditor:     {"e"},
+	KeyRegenerate: {"r"},
+	KeyQuit:       {"q"},
+	KeyUp:         {"up", "k"},
+	KeyDown:       {"down", "j"},
+	KeyNextFile:   {"n", "tab"},
+	KeyPrevFile:   {"p", "shift+tab"},
+	KeyStage:      {" "},
+	KeyStageAll:   {"a"},
+	KeyCommit:     {"c"},
+	KeyPush:       {"p"},
+}
+
+// activeKeys are the keys in use, set by SetKeys
+var activeKeys = defaultKeys
+
+// KeyActions returns the names of the actions SetKeys accepts, sorted
+func KeyActions() []string {
+	actions := make([]string, 0, len(defaultKeys))
+	for action := range defaultKeys {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// SetKeys binds actions to other keys for subsequent TUIs. Each value lists
+// the keys of one action separated by spaces, e.g. "ctrl+q esc"; "space"
+// stands for the space bar. Actions left out keep their default keys. The
+// known actions are applied even when others are unknown, which returns an
+// error. ctrl+c always quits, whatever the bindings.
+func SetKeys(bindings map[string]string) error {
+	keys := make(map[string][]string, len(defaultKeys))
+	for action, k := range defaultKeys {
+		keys[action] = k
+	}
+
+	var unknown []string
+	for action, value := range bindings {
+		action = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(action)), "-", "_")
+		if _, ok := defaultKeys[action]; !ok {
+			unknown = append(unknown, action)
+			continue
+		}
+		var names []string
+		for _, name := range strings.Fields(value) {
+			if name == "space" {
+				name = " "
+			}
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			unknown = append(unknown, action+" (no keys given)")
+			continue
+		}
+		keys[action] = names
+	}
+	activeKeys = keys
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown key bindings %s (available: %s)", strings.Join(unknown, ", "), strings.Join(KeyActions(), ", "))
+	}
+	return nil
+}
+
+// keyIs reports whether msg is one of the keys of action
+func keyIs(msg tea.KeyMsg, action string) bool {
+	return slices.Contains(activeKeys[action], msg.String())
+}
+
+// keyBinding returns the keys of action for the key maps of bubbles
+// components, with desc as their help
+func keyBinding(action, desc string, extra ...string) key.Binding {
+	keys := append(slices.Clone(activeKeys[action]), extra...)
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(keyNames(activeKeys[action]), desc))
+}
+
+// setListKeys makes l move and quit with the active keys
+func setListKeys(l *list.Model) {
+	l.KeyMap.CursorUp = keyBinding(KeyUp, "up")
+	l.KeyMap.CursorDown = keyBinding(KeyDown, "down")
+	// esc quits lists as well, unless it clears a filter
+	l.KeyMap.Quit = keyBinding(KeyQuit, "quit", "esc")
+}
+
+// keyNames shows keys for the help, e.g. "↑/k"
+func keyNames(keys []string) string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		switch k {
+		case " ":
+			names[i] = "space"
+		case "up":
+			names[i] = "↑"
+		case "down":
+			names[i] = "↓"
+		default:
+			names[i] = k
+		}
+	}
+	return strings.Join(names, "/")
+}
+
+// keyHelp renders one entry of a help footer, e.g. "enter/y accept". With
+// several actions only the first key of each is shown, as in
+// "n/p next/prev file".
+func keyHelp(desc string, actions ...string) string {
+	if len(actions) == 1 {
+		return keyNames(activeKeys[actions[0]]) + " " + i18n.T(desc)
+	}
+	first := make([]string, 0, len(actions))
+	for _, action := range actions {
+		if keys := activeKeys[action]; len(keys) > 0 {
+			first = append(first, keys[0])
+		}
+	}
+	return keyNames(first) + " " + i18n.T(desc)
+}
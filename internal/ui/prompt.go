@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"auto-git/internal/i18n"
+	"auto-git/internal/provider"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// stdinReader is shared by the line prompts so buffered input is not lost
+// between consecutive prompts
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// errTimeout is returned by readLineTimeout when no line arrived in time
+var errTimeout = errors.New("no answer before the timeout")
+
+// lineResult is the outcome of reading a line from stdin
+type lineResult struct {
+	line string
+	err  error
+}
+
+// pendingLine is a read started by a prompt that timed out. The next prompt
+// takes its line instead of starting another read.
+var pendingLine chan lineResult
+
+// readLine prints label to stderr and reads one line from stdin
+func readLine(label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	return finishLine(<-startLine())
+}
+
+// readLineTimeout is readLine giving up with errTimeout after timeout
+func readLineTimeout(label string, timeout time.Duration) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	ch := startLine()
+	select {
+	case result := <-ch:
+		return finishLine(result)
+	case <-time.After(timeout):
+		pendingLine = ch
+		fmt.Fprintln(os.Stderr)
+		return "", errTimeout
+	}
+}
+
+// startLine reads a line in the background, or returns the pending read
+func startLine() chan lineResult {
+	if ch := pendingLine; ch != nil {
+		pendingLine = nil
+		return ch
+	}
+	ch := make(chan lineResult, 1)
+	go func() {
+		line, err := stdinReader.ReadString('\n')
+		ch <- lineResult{line, err}
+	}()
+	return ch
+}
+
+func finishLine(result lineResult) (string, error) {
+	line, err := result.line, result.err
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			return "", ErrCancelled
+		}
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Ask reads a line of free text, returning defaultValue for an empty answer.
+// It returns ErrNonInteractive when the user cannot be prompted.
+func Ask(question, defaultValue string) (string, error) {
+	if !IsInteractive() {
+		return "", ErrNonInteractive
+	}
+
+	label := question + ": "
+	if defaultValue != "" {
+		label = fmt.Sprintf("%s [%s]: ", question, defaultValue)
+	}
+	answer, err := readLine(label)
+	if err != nil {
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
+
+// Confirm asks a yes/no question on stderr. An empty answer selects defaultYes.
+// It returns ErrNonInteractive when the user cannot be prompted.
+func Confirm(question string, defaultYes bool) (bool, error) {
+	if !IsInteractive() {
+		return false, ErrNonInteractive
+	}
+
+	hint := "[y/N]"
+	if defaultYes {
+		hint = "[Y/n]"
+	}
+	for {
+		answer, err := readLine(fmt.Sprintf("%s %s: ", question, hint))
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "":
+			return defaultYes, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// selectModelPlain is the line-based fallback for SelectModel, used when
+// stdout is not a terminal
+func selectModelPlain(models []provider.Model, defaultIndex int) (string, error) {
+	for i, m := range models {
+		marker := " "
+		if i == defaultIndex {
+			marker = "*"
+		}
+		if desc := describeModel(m); desc != "" {
+			fmt.Fprintf(os.Stderr, "%s %d. %s (%s)\n", marker, i+1, m.Name, desc)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s %d. %s\n", marker, i+1, m.Name)
+		}
+	}
+
+	for {
+		answer, err := readLine(i18n.Sprintf("Select a model by number or name, or type to search [%d]: ", defaultIndex+1))
+		if err != nil {
+			return "", err
+		}
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			return models[defaultIndex].Name, nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(models) {
+			return models[n-1].Name, nil
+		}
+		for _, m := range models {
+			if m.Name == answer {
+				return m.Name, nil
+			}
+		}
+
+		// Anything else is a fuzzy search narrowing the list
+		matches := fuzzy.FindFrom(answer, modelNames(models))
+		switch len(matches) {
+		case 0:
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("No model matches %q", answer))
+		case 1:
+			return models[matches[0].Index].Name, nil
+		default:
+			narrowed := make([]provider.Model, 0, len(matches))
+			for _, match := range matches {
+				narrowed = append(narrowed, models[match.Index])
+			}
+			return selectModelPlain(narrowed, 0)
+		}
+	}
+}
+
+type modelNames []provider.Model
+
+func (n modelNames) String(i int) string { return n[i].Name }
+func (n modelNames) Len() int            { return len(n) }
+
+// editMessagePlain is the line-based fallback for EditCommitMessage
+func editMessagePlain(initialMessage string, scopes []string) (string, error) {
+	if len(scopes) > 0 {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Scopes used in this repository: %s", strings.Join(scopes, ", ")))
+	}
+	label := i18n.T("Commit message: ")
+	if initialMessage != "" {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Current message: %s", initialMessage))
+		label = i18n.T("Commit message (empty keeps current): ")
+	}
+
+	answer, err := readLine(label)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(answer) == "" {
+		return initialMessage, nil
+	}
+	return answer, nil
+}
@@ -0,0 +1,245 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"auto-git/internal/i18n"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ReviewAction is the user's decision on the review screen
+type ReviewAction int
+
+const (
+	ReviewAccept ReviewAction = iota
+	ReviewEdit
+	// ReviewEditExternal asks for the message to be edited in $EDITOR
+	ReviewEditExternal
+	ReviewCancel
+	// ReviewAutoAccept accepts the message after the review timeout passed
+	// without input
+	ReviewAutoAccept
+)
+
+var (
+	reviewHeaderStyle = lipgloss.NewStyle().Padding(0, 1).Border(lipgloss.RoundedBorder())
+	reviewFooterStyle = lipgloss.NewStyle().Faint(true)
+)
+
+// reviewHelp lists the active keys of the review screen
+func reviewHelp() string {
+	return strings.Join([]string{
+		keyHelp("accept", KeyAccept),
+		keyHelp("edit", KeyEdit),
+		keyHelp("$EDITOR", KeyEditor),
+		keyHelp("next/prev file", KeyNextFile, KeyPrevFile),
+		keyHelp("scroll", KeyUp, KeyDown),
+		keyHelp("cancel", KeyQuit),
+	}, " • ")
+}
+
+type reviewModel struct {
+	viewport   viewport.Model
+	message    string
+	trailers   []string
+	content    string
+	fileStarts []int
+	action     ReviewAction
+	ready      bool
+	// remaining counts down to auto-accepting; 0 once a key was pressed
+	remaining time.Duration
+}
+
+// countdownMsg ticks the auto-accept countdown
+type countdownMsg struct{}
+
+func countdown() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return countdownMsg{} })
+}
+
+func (m reviewModel) Init() tea.Cmd {
+	if m.remaining > 0 {
+		return countdown()
+	}
+	return nil
+}
+
+func (m reviewModel) header() string {
+	text := i18n.T("Commit message:") + "\n" + titleStyle.UnsetMarginLeft().Render(m.message)
+	if len(m.trailers) > 0 {
+		text += "\n\n" + reviewFooterStyle.Render(strings.Join(m.trailers, "\n"))
+	}
+	return reviewHeaderStyle.Render(text)
+}
+
+func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		headerHeight := lipgloss.Height(m.header())
+		footerHeight := 1
+		height := msg.Height - headerHeight - footerHeight
+		if height < 1 {
+			height = 1
+		}
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width, height)
+			m.viewport.KeyMap.Up = keyBinding(KeyUp, "up")
+			m.viewport.KeyMap.Down = keyBinding(KeyDown, "down")
+			m.viewport.SetContent(m.content)
+			m.ready = true
+		} else {
+			m.viewport.Width = msg.Width
+			m.viewport.Height = height
+		}
+		return m, nil
+
+	case countdownMsg:
+		if m.remaining <= 0 {
+			return m, nil
+		}
+		m.remaining -= time.Second
+		if m.remaining <= 0 {
+			m.action = ReviewAutoAccept
+			return m, tea.Quit
+		}
+		return m, countdown()
+
+	case tea.KeyMsg:
+		// Any key means the user is reviewing; stop the countdown
+		m.remaining = 0
+		switch {
+		case keyIs(msg, KeyAccept):
+			m.action = ReviewAccept
+			return m, tea.Quit
+		case keyIs(msg, KeyEdit):
+			m.action = ReviewEdit
+			return m, tea.Quit
+		case keyIs(msg, KeyEditor):
+			m.action = ReviewEditExternal
+			return m, tea.Quit
+		case keyIs(msg, KeyQuit), msg.String() == "esc", msg.String() == "ctrl+c":
+			m.action = ReviewCancel
+			return m, tea.Quit
+		case keyIs(msg, KeyNextFile):
+			for _, start := range m.fileStarts {
+				if start > m.viewport.YOffset {
+					m.viewport.SetYOffset(start)
+					break
+				}
+			}
+			return m, nil
+		case keyIs(msg, KeyPrevFile):
+			for i := len(m.fileStarts) - 1; i >= 0; i-- {
+				if m.fileStarts[i] < m.viewport.YOffset {
+					m.viewport.SetYOffset(m.fileStarts[i])
+					break
+				}
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m reviewModel) View() string {
+	if !m.ready {
+		return "\n  Loading diff..."
+	}
+	status := fmt.Sprintf("%3.f%% • %s", m.viewport.ScrollPercent()*100, reviewHelp())
+	if m.remaining > 0 {
+		status = i18n.Sprintf("accepting in %ds, press any key to stay", int(m.remaining.Seconds())) + " • " + status
+	}
+	footer := reviewFooterStyle.Render(status)
+	return lipgloss.JoinVertical(lipgloss.Left, m.header(), m.viewport.View(), footer)
+}
+
+// ReviewCommit shows the proposed commit message above a scrollable, colored
+// diff and returns whether the user accepts, wants to edit, or cancels.
+// Trailers that git will add, such as Signed-off-by, are shown below the message.
+// With a positive timeout, the message is accepted with ReviewAutoAccept if
+// the user doesn't press a key in time.
+func ReviewCommit(message, diff string, trailers []string, timeout time.Duration) (ReviewAction, error) {
+	if !IsInteractive() {
+		return ReviewAccept, nil
+	}
+	if !canUseTUI() {
+		return reviewCommitPlain(message, trailers, timeout)
+	}
+
+	lines, fileStarts := renderDiff(diff)
+	m := reviewModel{
+		message:    message,
+		trailers:   trailers,
+		content:    strings.Join(lines, "\n"),
+		fileStarts: fileStarts,
+		action:     ReviewCancel,
+		remaining:  timeout.Round(time.Second),
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return ReviewCancel, fmt.Errorf("failed to run UI: %w", err)
+	}
+
+	if m, ok := finalModel.(reviewModel); ok {
+		return m.action, nil
+	}
+	return ReviewCancel, nil
+}
+
+// reviewCommitPlain is the line-based fallback for ReviewCommit
+func reviewCommitPlain(message string, trailers []string, timeout time.Duration) (ReviewAction, error) {
+	fmt.Fprintf(os.Stderr, "%s\n  %s\n", i18n.T("Commit message:"), message)
+	for _, t := range trailers {
+		fmt.Fprintf(os.Stderr, "  %s\n", t)
+	}
+	label := i18n.T("[a]ccept, [e]dit in $EDITOR, [i]nline edit, [c]ancel? [a]: ")
+	if timeout > 0 {
+		// Only the first prompt counts down; answering it means the user is here
+		answer, err := readLineTimeout(i18n.Sprintf("(accepting in %ds) ", int(timeout.Round(time.Second).Seconds()))+label, timeout)
+		if errors.Is(err, errTimeout) {
+			return ReviewAutoAccept, nil
+		}
+		if err != nil {
+			return ReviewCancel, err
+		}
+		if action, ok := plainReviewAnswer(answer); ok {
+			return action, nil
+		}
+	}
+	for {
+		answer, err := readLine(label)
+		if err != nil {
+			return ReviewCancel, err
+		}
+		if action, ok := plainReviewAnswer(answer); ok {
+			return action, nil
+		}
+	}
+}
+
+// plainReviewAnswer reads an answer to the plain review prompt
+func plainReviewAnswer(answer string) (ReviewAction, bool) {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "a", "y", "yes":
+		return ReviewAccept, true
+	case "i", "inline":
+		return ReviewEdit, true
+	case "e", "edit", "o", "open":
+		return ReviewEditExternal, true
+	case "c", "n", "no", "q":
+		return ReviewCancel, true
+	}
+	return ReviewCancel, false
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"strings"
+)
+
+// openScope returns the partial scope being typed when the text before the
+// cursor ends inside an unclosed "type(" group, e.g. "feat(con" → "con"
+func openScope(beforeCursor string) (string, bool) {
+	open := strings.LastIndex(beforeCursor, "(")
+	if open < 0 || strings.Contains(beforeCursor[open:], ")") || strings.Contains(beforeCursor[:open], ":") {
+		return "", false
+	}
+	partial := beforeCursor[open+1:]
+	if strings.ContainsAny(partial, " :") {
+		return "", false
+	}
+	return partial, true
+}
+
+// matchScopes returns the scopes starting with partial, keeping their order
+func matchScopes(scopes []string, partial string) []string {
+	var matches []string
+	for _, s := range scopes {
+		if strings.HasPrefix(strings.ToLower(s), strings.ToLower(partial)) {
+			matches = append(matches, s)
+		}
+	}
+	return matches
+}
+
+// completeScope returns the text to insert after partial: the rest of the only
+// match followed by "): ", or the longest prefix shared by several matches
+func completeScope(matches []string, partial string) string {
+	switch len(matches) {
+	case 0:
+		return ""
+	case 1:
+		return matches[0][len(partial):] + "): "
+	}
+
+	prefix := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(strings.ToLower(m), strings.ToLower(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) < len(partial) {
+		return ""
+	}
+	return prefix[len(partial):]
+}
@@ -4,52 +4,145 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 	"time"
-
-	"github.com/fatih/color"
 )
 
 type Spinner struct {
+	mu      sync.Mutex
 	message string
-	ctx     context.Context
-	cancel  context.CancelFunc
-	done    chan bool
+	detail  string
+	start   time.Time
+	// countdown is a format shown with the seconds left until until
+	countdown string
+	until     time.Time
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan bool
 }
 
 var spinnerChars = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 
+// asciiSpinnerChars are used on consoles that can't render braille characters
+var asciiSpinnerChars = []string{"|", "/", "-", "\\"}
+
 func NewSpinner(message string) *Spinner {
 	ctx, cancel := context.WithCancel(context.Background())
 	sp := &Spinner{
 		message: message,
+		start:   time.Now(),
 		ctx:     ctx,
 		cancel:  cancel,
 		done:    make(chan bool, 1),
 	}
 
-	go sp.run()
+	if animate() {
+		go sp.run()
+	} else {
+		// No animation when stderr is a log file or pipe, just one line per phase
+		fmt.Fprintln(os.Stderr, message)
+		go func() {
+			<-ctx.Done()
+			sp.done <- true
+		}()
+	}
 	return sp
 }
 
+// SetPhase replaces the spinner message, e.g. when moving to the next step
+func (s *Spinner) SetPhase(message string) {
+	s.mu.Lock()
+	changed := s.message != message
+	s.message = message
+	s.mu.Unlock()
+
+	if changed && !animate() {
+		fmt.Fprintln(os.Stderr, message)
+	}
+}
+
+// SetDetail shows extra progress information after the elapsed time, such as
+// the number of tokens received so far
+func (s *Spinner) SetDetail(detail string) {
+	s.mu.Lock()
+	s.detail = detail
+	s.mu.Unlock()
+}
+
+// Countdown shows format, filled with the seconds left, in place of the
+// detail until wait has passed, e.g. while waiting to retry a request
+func (s *Spinner) Countdown(format string, wait time.Duration) {
+	s.mu.Lock()
+	s.countdown = format
+	s.until = time.Now().Add(wait)
+	s.mu.Unlock()
+
+	if !animate() {
+		fmt.Fprintln(os.Stderr, fmt.Sprintf(format, int(wait.Round(time.Second).Seconds())))
+	}
+}
+
+func (s *Spinner) line(char string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line := fmt.Sprintf("%s %s (%ds", spinnerStyle.Render(char), s.message, int(time.Since(s.start).Seconds()))
+	if left := time.Until(s.until); left > 0 {
+		line += ", " + fmt.Sprintf(s.countdown, int(left.Seconds())+1)
+	} else if s.detail != "" {
+		line += ", " + s.detail
+	}
+	return line + ")"
+}
+
 func (s *Spinner) run() {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
+	chars := spinnerChars
+	if legacyConsole {
+		chars = asciiSpinnerChars
+	}
+	width := 0
 	i := 0
 	for {
 		select {
 		case <-s.ctx.Done():
-			fmt.Fprint(os.Stderr, "\r\033[K")
+			clearLine(width)
 			s.done <- true
 			return
 		case <-ticker.C:
-			char := spinnerChars[i%len(spinnerChars)]
-			fmt.Fprintf(os.Stderr, "\r%s %s", color.CyanString(char), s.message)
+			line := s.line(chars[i%len(chars)])
+			clearLine(width)
+			fmt.Fprint(os.Stderr, line)
+			width = len([]rune(line))
 			i++
 		}
 	}
 }
 
+// DisableAnimation prints one line per spinner phase even on a terminal,
+// e.g. for CI logs that capture terminal output
+func DisableAnimation() {
+	noAnimation = true
+}
+
+var noAnimation bool
+
+func animate() bool {
+	return !noAnimation && isTerminal(os.Stderr)
+}
+
+// clearLine erases the current stderr line of the given width, with spaces
+// on consoles that don't support the erase-line sequence
+func clearLine(width int) {
+	if legacyConsole {
+		fmt.Fprintf(os.Stderr, "\r%*s\r", width, "")
+		return
+	}
+	fmt.Fprint(os.Stderr, "\r\033[K")
+}
+
 func (s *Spinner) Stop() {
 	s.cancel()
 	<-s.done
@@ -60,4 +153,3 @@ func ShowSpinner(message string, fn func() error) error {
 	defer sp.Stop()
 	return fn()
 }
-
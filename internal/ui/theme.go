@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"os"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme holds the colors used by the TUIs and the spinner. Values are lipgloss
+// colors: ANSI codes ("170") or hex ("#ff87d7"); empty means the terminal default.
+type Theme struct {
+	Selected string
+	Title    string
+	Spinner  string
+}
+
+// DefaultThemeName is used when no theme is configured
+const DefaultThemeName = "default"
+
+// Themes lists the built-in themes
+var Themes = map[string]Theme{
+	"default": {Selected: "170", Spinner: "6"},
+	"dark":    {Selected: "213", Title: "252", Spinner: "87"},
+	"light":   {Selected: "25", Title: "236", Spinner: "30"},
+	"mono":    {},
+}
+
+// ThemeNames returns the names of the built-in themes in sorted order
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// stderrRenderer styles output written to stderr (the spinner), whose color
+// support can differ from stdout's
+var stderrRenderer = lipgloss.NewRenderer(os.Stderr)
+
+var spinnerStyle = stderrRenderer.NewStyle()
+
+func init() {
+	ApplyTheme(Themes[DefaultThemeName])
+}
+
+// ApplyTheme updates the styles used by subsequent TUIs and spinners
+func ApplyTheme(t Theme) {
+	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2)
+	titleStyle = lipgloss.NewStyle().MarginLeft(2)
+	spinnerStyle = stderrRenderer.NewStyle()
+
+	if t.Selected != "" {
+		selectedItemStyle = selectedItemStyle.Foreground(lipgloss.Color(t.Selected))
+	}
+	if t.Title != "" {
+		titleStyle = titleStyle.Foreground(lipgloss.Color(t.Title)).Bold(true)
+	}
+	if t.Spinner != "" {
+		spinnerStyle = spinnerStyle.Foreground(lipgloss.Color(t.Spinner))
+	}
+}
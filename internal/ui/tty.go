@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"errors"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// ErrNonInteractive is returned when user input is required but prompting is
+// disabled or no terminal is attached
+var ErrNonInteractive = errors.New("input required but running non-interactively")
+
+var nonInteractive bool
+
+// plain is set by SetPlain
+var plain bool
+
+// SetPlain switches to output suited to screen readers and terminal logging:
+// no colors, no spinner animation and line prompts instead of full-screen TUIs
+func SetPlain() {
+	plain = true
+	DisableColor()
+	DisableAnimation()
+}
+
+// SetNonInteractive disables every prompt; callers fall back to defaults
+func SetNonInteractive(v bool) {
+	nonInteractive = v
+}
+
+// IsInteractive reports whether the user can be prompted at all
+func IsInteractive() bool {
+	return !nonInteractive && isTerminal(os.Stdin)
+}
+
+// canUseTUI reports whether full-screen bubbletea programs can run, which
+// requires both stdin and stdout to be terminals that understand ANSI sequences
+func canUseTUI() bool {
+	return IsInteractive() && isTerminal(os.Stdout) && !legacyConsole && !plain
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
@@ -0,0 +1,50 @@
+// Package verify runs the user's pre-push check (tests, linters, …) so a
+// failing commit stays local instead of being pushed.
+package verify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"auto-git/internal/logging"
+)
+
+// ErrFailed is wrapped by errors returned when the command exits unsuccessfully
+var ErrFailed = errors.New("verification failed")
+
+// Run executes command through the shell in dir and returns its combined output
+func Run(dir, command string) (string, error) {
+	start := time.Now()
+
+	cmd := shellCommand(command)
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+
+	logging.Debug("verify command", "command", command, "dir", dir, "duration", time.Since(start), "error", err)
+
+	output := strings.TrimRight(out.String(), "\n")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return output, fmt.Errorf("%w: %q exited with status %d", ErrFailed, command, exitErr.ExitCode())
+		}
+		return output, fmt.Errorf("failed to run %q: %w", command, err)
+	}
+	return output, nil
+}
+
+// shellCommand runs command through the platform shell so pipes and && work
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,211 @@
+// Package workspace finds the packages of a monorepo that changed files
+// belong to: Go modules, npm, yarn and pnpm workspaces, and directories
+// configured as package roots.
+package workspace
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is how a package was recognized
+type Kind string
+
+const (
+	KindGoModule     Kind = "go module"
+	KindNPMWorkspace Kind = "npm workspace"
+	KindConfigured   Kind = "package root"
+	// KindRoot holds the files outside every package
+	KindRoot Kind = "repository root"
+)
+
+// Package is a directory whose changes get a commit of their own
+type Package struct {
+	// Name is used as the commit scope; it is empty for the repository root
+	Name string
+	// Dir is relative to the repository root, with forward slashes; it is
+	// empty for the repository root
+	Dir  string
+	Kind Kind
+}
+
+// Group is a package and the changed paths inside it
+type Group struct {
+	Package
+	Paths []string
+}
+
+// Detector assigns paths to the innermost package containing them
+type Detector struct {
+	root       string
+	roots      []string // configured package root globs
+	workspaces []string // npm/pnpm workspace globs
+	cache      map[string]*Package
+}
+
+// NewDetector returns a detector for the repository at root. roots are globs
+// of extra package directories relative to root, such as "services/*".
+func NewDetector(root string, roots []string) *Detector {
+	d := &Detector{root: root, cache: map[string]*Package{}}
+	for _, r := range roots {
+		if r = strings.Trim(filepath.ToSlash(strings.TrimSpace(r)), "/"); r != "" {
+			d.roots = append(d.roots, r)
+		}
+	}
+	d.workspaces = workspaceGlobs(root)
+	return d
+}
+
+// PackageOf returns the innermost package containing the repository-relative
+// path p, or the repository root
+func (d *Detector) PackageOf(p string) Package {
+	for dir := path.Dir(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if pkg := d.packageAt(dir); pkg != nil {
+			return *pkg
+		}
+	}
+	return Package{Kind: KindRoot}
+}
+
+// packageAt returns the package whose directory is dir, or nil
+func (d *Detector) packageAt(dir string) *Package {
+	if pkg, ok := d.cache[dir]; ok {
+		return pkg
+	}
+
+	var pkg *Package
+	switch {
+	case MatchAny(d.roots, dir):
+		pkg = &Package{Name: path.Base(dir), Dir: dir, Kind: KindConfigured}
+	case fileExists(filepath.Join(d.root, filepath.FromSlash(dir), "go.mod")):
+		pkg = &Package{Name: path.Base(dir), Dir: dir, Kind: KindGoModule}
+	case MatchAny(d.workspaces, dir) && fileExists(filepath.Join(d.root, filepath.FromSlash(dir), "package.json")):
+		pkg = &Package{Name: npmName(filepath.Join(d.root, filepath.FromSlash(dir), "package.json"), dir), Dir: dir, Kind: KindNPMWorkspace}
+	}
+	d.cache[dir] = pkg
+	return pkg
+}
+
+// Group sorts paths into the packages containing them, ordered by directory
+// with the repository root first
+func (d *Detector) Group(paths []string) []Group {
+	byDir := map[string]*Group{}
+	for _, p := range paths {
+		pkg := d.PackageOf(p)
+		g := byDir[pkg.Dir]
+		if g == nil {
+			g = &Group{Package: pkg}
+			byDir[pkg.Dir] = g
+		}
+		g.Paths = append(g.Paths, p)
+	}
+
+	groups := make([]Group, 0, len(byDir))
+	for _, g := range byDir {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Dir < groups[j].Dir })
+	return groups
+}
+
+// workspaceGlobs reads the workspace patterns of the root package.json
+// ("workspaces", as npm and yarn use it) and of pnpm-workspace.yaml
+func workspaceGlobs(root string) []string {
+	var globs []string
+	if data, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil {
+		var manifest struct {
+			Workspaces json.RawMessage `json:"workspaces"`
+		}
+		if json.Unmarshal(data, &manifest) == nil && len(manifest.Workspaces) > 0 {
+			// Either a list of globs or, with yarn, {"packages": [...]}
+			var list []string
+			var object struct {
+				Packages []string `json:"packages"`
+			}
+			if json.Unmarshal(manifest.Workspaces, &list) == nil {
+				globs = append(globs, list...)
+			} else if json.Unmarshal(manifest.Workspaces, &object) == nil {
+				globs = append(globs, object.Packages...)
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "pnpm-workspace.yaml")); err == nil {
+		var manifest struct {
+			Packages []string `yaml:"packages"`
+		}
+		if yaml.Unmarshal(data, &manifest) == nil {
+			globs = append(globs, manifest.Packages...)
+		}
+	}
+
+	var cleaned []string
+	for _, g := range globs {
+		// Exclusions such as "!**/test/**" only narrow the list down
+		if g = strings.Trim(strings.TrimPrefix(g, "./"), "/"); g != "" && !strings.HasPrefix(g, "!") {
+			cleaned = append(cleaned, g)
+		}
+	}
+	return cleaned
+}
+
+// npmName returns the name in a package.json without its @org/ prefix, or the
+// base name of dir when it has none
+func npmName(file, dir string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return path.Base(dir)
+	}
+	defer f.Close()
+
+	var manifest struct {
+		Name string `json:"name"`
+	}
+	if json.NewDecoder(bufio.NewReader(f)).Decode(&manifest) != nil || manifest.Name == "" {
+		return path.Base(dir)
+	}
+	return path.Base(manifest.Name)
+}
+
+// MatchAny reports whether dir matches one of the globs, where "**" matches
+// any number of directories
+func MatchAny(globs []string, dir string) bool {
+	for _, g := range globs {
+		if matchGlob(strings.Split(g, "/"), strings.Split(dir, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlob(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchGlob(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], parts[0]); err != nil || !ok {
+		return false
+	}
+	return matchGlob(pattern[1:], parts[1:])
+}
+
+func fileExists(file string) bool {
+	info, err := os.Stat(file)
+	return err == nil && !info.IsDir()
+}
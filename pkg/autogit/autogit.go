@@ -0,0 +1,559 @@
+// Package autogit exposes the auto-git pipeline — scan the repository, build
+// the prompt, generate a message, and validate it — for use by other Go tools
+// without shelling out to the CLI.
+//
+//	prov, _ := autogit.NewProvider("ollama", "", "")
+//	engine, _ := autogit.New(autogit.WithProvider(prov), autogit.WithModel("llama3.2"))
+//	message, err := engine.Run()
+package autogit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"auto-git/internal/emoji"
+	"auto-git/internal/git"
+	"auto-git/internal/prompt"
+	"auto-git/internal/provider"
+	"auto-git/internal/tokenizer"
+	"auto-git/pkg/conventional"
+)
+
+// Changes summarizes the staged and unstaged changes of a repository
+type Changes = git.Changes
+
+// FileChange describes the change to a single file
+type FileChange = git.FileChange
+
+// PullRequest is a commit message generated together with a pull request
+// title and description
+type PullRequest = prompt.PullRequest
+
+// ErrNoChanges is returned by Scan when there is nothing to commit
+var ErrNoChanges = git.ErrNoChanges
+
+// ErrNoPullRequest is returned by GeneratePR, together with the commit
+// message, when the reply holds no pull request title or description
+var ErrNoPullRequest = errors.New("the reply contains no pull request text")
+
+// ErrEmptyMessage is returned when the model's reply contains no usable message
+var ErrEmptyMessage = errors.New("generated commit message is empty")
+
+// ErrNotConventional is returned in strict mode when none of the replies was
+// a valid Conventional Commits message
+var ErrNotConventional = errors.New("the model did not produce a valid Conventional Commits message")
+
+// ErrBannedWord is returned when the generated subject contains a banned
+// word, right away with BannedBlock and otherwise after MaxAttempts replies
+var ErrBannedWord = errors.New("the generated message contains a banned word")
+
+// ErrContextExceeded is returned when the provider rejected the prompt as too
+// long for the model, even after shortening the diff MaxAttempts times
+var ErrContextExceeded = provider.ErrContextExceeded
+
+// MaxAttempts is how many replies the engine asks for when it rejects them
+const MaxAttempts = 3
+
+// Actions for replies whose subject contains a banned word
+const (
+	BannedRegenerate = "regenerate"
+	BannedBlock      = "block"
+)
+
+// Engine runs the commit message pipeline against a provider
+type Engine struct {
+	provider     Provider
+	model        string
+	dir          string
+	systemPrompt string
+	redact       func(string) string
+	styleGuide   string
+	// contextWindow is the model's limit in tokens; 0 when unknown
+	contextWindow int
+	strict        bool
+	// templateTexts are parsed into templates, which render messages from
+	// structured replies when set
+	templateTexts map[string]string
+	templates     prompt.Templates
+	branch        string
+	emojiMode     string
+	emojiPosition string
+	emojiMap      map[string]string
+	emoji         emoji.Style
+	bannedWords   []string
+	bannedAction  string
+	// largeContextModel takes over when the model's context is exceeded
+	largeContextModel string
+	// compression is a prompt.Compress* level applied to the diff
+	compression string
+	// usage adds up the tokens of every request sent
+	usage Usage
+}
+
+// Option configures an Engine
+type Option func(*Engine)
+
+// WithProvider sets the provider used for generation (required)
+func WithProvider(p Provider) Option {
+	return func(e *Engine) { e.provider = p }
+}
+
+// WithModel sets the model name passed to the provider (required)
+func WithModel(model string) Option {
+	return func(e *Engine) { e.model = model }
+}
+
+// WithDir scans the repository containing dir instead of the working directory
+func WithDir(dir string) Option {
+	return func(e *Engine) { e.dir = dir }
+}
+
+// WithSystemPrompt replaces the built-in system prompt
+func WithSystemPrompt(systemPrompt string) Option {
+	return func(e *Engine) { e.systemPrompt = systemPrompt }
+}
+
+// WithRedactor filters the diff before it is put into the prompt, e.g. to mask
+// personal data. The commit itself is not affected.
+func WithRedactor(redact func(string) string) Option {
+	return func(e *Engine) { e.redact = redact }
+}
+
+// WithStyleGuide adds instructions describing the repository's commit style
+// to the user prompt
+func WithStyleGuide(guide string) Option {
+	return func(e *Engine) { e.styleGuide = guide }
+}
+
+// WithContextWindow sets the number of tokens the model accepts, overriding
+// the built-in table of known models. Diffs are shortened to fit; 0 keeps the
+// default, a negative value disables the limit.
+func WithContextWindow(tokens int) Option {
+	return func(e *Engine) { e.contextWindow = tokens }
+}
+
+// WithLargeContextModel sets a model with a larger context to switch to when
+// the provider rejects a prompt as too long for the configured model. Model
+// then returns it. Without one, the diff is shortened instead.
+func WithLargeContextModel(model string) Option {
+	return func(e *Engine) { e.largeContextModel = strings.TrimSpace(model) }
+}
+
+// WithDiffCompression shrinks the diff before it goes into the prompt:
+// "light" keeps one context line around each change and collapses repeated
+// hunks and blocks of lines, "aggressive" also drops the remaining context
+// lines, and "off" (the default) sends the diff as is
+func WithDiffCompression(level string) Option {
+	return func(e *Engine) { e.compression = level }
+}
+
+// WithStrictConventional checks generated messages with a Conventional
+// Commits 1.0.0 parser instead of normalizing them. Replies that don't parse,
+// or use a type the prompt doesn't allow, are rejected and the model is asked
+// again up to MaxAttempts times.
+func WithStrictConventional(strict bool) Option {
+	return func(e *Engine) { e.strict = strict }
+}
+
+// WithTemplates renders messages from per-type templates, keyed by commit
+// type, such as "fix({{scope}}): {{subject}} (closes #{{issue}})". The model
+// then replies with the message parts instead of a message. Types without a
+// template use prompt.DefaultTemplate.
+func WithTemplates(templates map[string]string) Option {
+	return func(e *Engine) { e.templateTexts = templates }
+}
+
+// WithBranch names the current branch in templated prompts, so the model can
+// take the issue from it
+func WithBranch(branch string) Option {
+	return func(e *Engine) { e.branch = branch }
+}
+
+// WithEmoji sets how generated messages use emoji: mode is "auto" (keep the
+// model's choice), "always" or "never", and position is "before_type",
+// "after_colon", or empty to keep the emoji where the model put it
+func WithEmoji(mode, position string) Option {
+	return func(e *Engine) { e.emojiMode, e.emojiPosition = mode, position }
+}
+
+// WithEmojiMap sets the emoji of commit types, e.g. {"perf": "🚀"}. Messages
+// of these types get the emoji instead of the model's, unless the emoji mode
+// is "never".
+func WithEmojiMap(mapping map[string]string) Option {
+	return func(e *Engine) { e.emojiMap = mapping }
+}
+
+// WithBannedWords rejects messages whose subject contains one of words, such
+// as "WIP" or "misc changes", matched whole and ignoring case. With
+// BannedRegenerate (or "") the model is asked again up to MaxAttempts times;
+// with BannedBlock generation fails right away. Either way it ends with
+// ErrBannedWord.
+func WithBannedWords(words []string, action string) Option {
+	return func(e *Engine) { e.bannedWords, e.bannedAction = words, action }
+}
+
+// New creates an Engine from opts
+func New(opts ...Option) (*Engine, error) {
+	e := &Engine{}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.provider == nil {
+		return nil, fmt.Errorf("autogit: a provider is required")
+	}
+	if strings.TrimSpace(e.model) == "" {
+		return nil, fmt.Errorf("autogit: a model is required")
+	}
+	if e.contextWindow == 0 {
+		e.contextWindow = tokenizer.ContextWindow(e.model)
+	}
+	style, err := emoji.Parse(e.emojiMode, e.emojiPosition, e.emojiMap)
+	if err != nil {
+		return nil, fmt.Errorf("autogit: %w", err)
+	}
+	if e.strict && style.Mode != emoji.Never {
+		// Conventional Commits only allows an emoji in the description
+		if style.Position == emoji.BeforeType {
+			return nil, fmt.Errorf("autogit: emoji position %s is not allowed in strict Conventional Commits mode", style.Position)
+		}
+		style.Position = emoji.AfterColon
+	}
+	e.emoji = style
+	switch e.bannedAction {
+	case "":
+		e.bannedAction = BannedRegenerate
+	case BannedRegenerate, BannedBlock:
+	default:
+		return nil, fmt.Errorf("autogit: unknown banned words action %q (supported: %s, %s)", e.bannedAction, BannedRegenerate, BannedBlock)
+	}
+	if e.compression, err = prompt.ParseCompression(e.compression); err != nil {
+		return nil, fmt.Errorf("autogit: %w", err)
+	}
+	if len(e.templateTexts) > 0 {
+		templates, err := prompt.ParseTemplates(e.templateTexts)
+		if err != nil {
+			return nil, fmt.Errorf("autogit: %w", err)
+		}
+		e.templates = templates
+	}
+	return e, nil
+}
+
+// Model returns the model the engine generates with
+func (e *Engine) Model() string {
+	return e.model
+}
+
+// Usage returns the tokens used by the requests sent so far, including the
+// ones whose reply was rejected
+func (e *Engine) Usage() Usage {
+	return e.usage
+}
+
+// Scan collects the uncommitted changes and their diff from the repository
+func (e *Engine) Scan() (*Changes, string, error) {
+	if e.dir != "" {
+		return git.CollectAt(e.dir)
+	}
+	return git.Collect()
+}
+
+// ParsePatch builds a change summary from a unified diff
+func (e *Engine) ParsePatch(patch string) (*Changes, error) {
+	return git.ParsePatch(patch)
+}
+
+// ContextWindow returns the number of tokens the model accepts, or 0 when it
+// is unknown or unlimited
+func (e *Engine) ContextWindow() int {
+	return max(e.contextWindow, 0)
+}
+
+// CountTokens estimates the tokens of text with the model's tokenizer
+func (e *Engine) CountTokens(text string) int {
+	return tokenizer.ForModel(e.model).Count(text)
+}
+
+// PromptTokens estimates the size of the full prompt for the given changes,
+// before any shortening to fit the context window
+func (e *Engine) PromptTokens(changes *Changes, diffContent string) int {
+	systemPrompt, userPrompt := e.buildPrompt(changes, e.prepareDiff(diffContent))
+	return e.CountTokens(systemPrompt) + e.CountTokens(userPrompt)
+}
+
+// BuildPrompt returns the system and user prompts for the given changes. If
+// they would not fit into the model's context window, leaving room for the
+// reply, the diff is shortened.
+func (e *Engine) BuildPrompt(changes *Changes, diffContent string) (string, string) {
+	return e.fitPrompt(changes, diffContent, e.buildPrompt)
+}
+
+// BuildPRPrompt is like BuildPrompt for GeneratePR
+func (e *Engine) BuildPRPrompt(changes *Changes, diffContent string) (string, string) {
+	return e.fitPrompt(changes, diffContent, e.buildPRPrompt)
+}
+
+// fitPrompt redacts and compresses the diff and builds the prompts with build, shortening
+// the diff if they don't fit into the model's context window
+func (e *Engine) fitPrompt(changes *Changes, diffContent string, build func(*Changes, string) (string, string)) (string, string) {
+	limit := 0
+	if e.ContextWindow() > 0 {
+		limit = e.ContextWindow() - tokenizer.ReplyReserve
+	}
+	return e.fitPromptWithin(changes, diffContent, build, limit)
+}
+
+// prepareDiff redacts and compresses the diff for the prompt
+func (e *Engine) prepareDiff(diffContent string) string {
+	if e.redact != nil {
+		diffContent = e.redact(diffContent)
+	}
+	return prompt.CompressDiff(diffContent, e.compression)
+}
+
+// fitPromptWithin is fitPrompt with a limit of prompt tokens; 0 is unlimited
+func (e *Engine) fitPromptWithin(changes *Changes, diffContent string, build func(*Changes, string) (string, string), limit int) (string, string) {
+	diffContent = e.prepareDiff(diffContent)
+	systemPrompt, userPrompt := build(changes, diffContent)
+	if limit <= 0 {
+		return systemPrompt, userPrompt
+	}
+
+	total := e.CountTokens(systemPrompt) + e.CountTokens(userPrompt)
+	if total <= limit {
+		return systemPrompt, userPrompt
+	}
+	overhead := total - e.CountTokens(diffContent)
+	return build(changes, prompt.FitDiff(diffContent, limit-overhead, e.CountTokens))
+}
+
+func (e *Engine) buildPrompt(changes *Changes, diffContent string) (string, string) {
+	if e.templates != nil {
+		return e.buildFieldsPrompt(changes, diffContent)
+	}
+	systemPrompt, userPrompt := prompt.BuildFullPrompt(changes, diffContent)
+	if e.systemPrompt != "" {
+		systemPrompt = e.systemPrompt
+	}
+	return e.adapt(systemPrompt, prompt.AddStyleGuide(userPrompt, e.styleGuide))
+}
+
+// buildPRPrompt ignores WithSystemPrompt, whose prompt asks for a single line
+func (e *Engine) buildPRPrompt(changes *Changes, diffContent string) (string, string) {
+	userPrompt := prompt.BuildPRUserPrompt(changes, diffContent)
+	return e.adapt(prompt.BuildPRSystemPrompt(), prompt.AddStyleGuide(userPrompt, e.styleGuide))
+}
+
+// buildFieldsPrompt asks for the message parts the templates are filled
+// with. Like buildPRPrompt it ignores WithSystemPrompt.
+func (e *Engine) buildFieldsPrompt(changes *Changes, diffContent string) (string, string) {
+	userPrompt := prompt.BuildFieldsUserPrompt(changes, diffContent, e.branch)
+	return e.adapt(prompt.BuildFieldsSystemPrompt(), prompt.AddStyleGuide(userPrompt, e.styleGuide))
+}
+
+// adapt applies strict mode and the banned words to built prompts
+func (e *Engine) adapt(systemPrompt, userPrompt string) (string, string) {
+	userPrompt = prompt.AddBannedWords(userPrompt, e.bannedWords)
+	if !e.strict {
+		return systemPrompt, userPrompt
+	}
+	return prompt.Strict(systemPrompt, userPrompt)
+}
+
+// Generate asks the provider for a commit message and validates it
+func (e *Engine) Generate(changes *Changes, diffContent string) (string, error) {
+	var message string
+	err := e.askFitting(changes, diffContent, e.buildPrompt, func(reply string) (err error) {
+		if e.templates != nil {
+			message, err = e.render(reply)
+			return err
+		}
+		message, err = e.check(prompt.ExtractMessageLine(reply))
+		return err
+	})
+	return message, err
+}
+
+// render fills the template for the type in a reply to the fields prompt.
+// A reply without JSON, which small models sometimes send despite the
+// prompt, is taken as the message itself.
+func (e *Engine) render(reply string) (string, error) {
+	if !strings.Contains(reply, "{") {
+		return e.check(prompt.ExtractMessageLine(reply))
+	}
+	fields, err := prompt.ParseFields(reply)
+	if err != nil {
+		return "", err
+	}
+	message, err := e.templates.Render(fields)
+	if err != nil {
+		return "", err
+	}
+	return e.check(message)
+}
+
+// GeneratePR asks the provider for a commit message together with a pull
+// request title and description, in a single request. If the reply only
+// holds a commit message, it is returned with ErrNoPullRequest.
+func (e *Engine) GeneratePR(changes *Changes, diffContent string) (*PullRequest, error) {
+	var pr *PullRequest
+	err := e.askFitting(changes, diffContent, e.buildPRPrompt, func(reply string) error {
+		parsed, err := prompt.ParsePullRequest(reply)
+		if err != nil {
+			// Small models sometimes ignore the format and reply with a subject
+			message, checkErr := e.check(prompt.ExtractMessageLine(reply))
+			if checkErr != nil {
+				if rejected(checkErr) != nil {
+					return checkErr
+				}
+				return err
+			}
+			pr = &PullRequest{Subject: message}
+			return fmt.Errorf("%w: %v", ErrNoPullRequest, err)
+		}
+		message, err := e.check(parsed.CommitMessage())
+		if err != nil {
+			return err
+		}
+		// Checking only touches the type, in the subject
+		parsed.Subject, _, _ = strings.Cut(message, "\n")
+		pr = parsed
+		return nil
+	})
+	return pr, err
+}
+
+// askFitting builds the prompts with build, fitted to the context window, and
+// asks for a reply as ask does. When the provider rejects them as too long for
+// the model, it switches to the large context model if there is one, and
+// otherwise halves the prompt by shortening the diff, up to MaxAttempts times.
+func (e *Engine) askFitting(changes *Changes, diffContent string, build func(*Changes, string) (string, string), read func(reply string) error) error {
+	systemPrompt, userPrompt := e.fitPrompt(changes, diffContent, build)
+	for attempt := 1; ; attempt++ {
+		err := e.ask(changes, systemPrompt, userPrompt, read)
+		if !errors.Is(err, ErrContextExceeded) || attempt == MaxAttempts {
+			return err
+		}
+		if e.largeContextModel != "" && e.model != e.largeContextModel {
+			e.model = e.largeContextModel
+			e.contextWindow = tokenizer.ContextWindow(e.model)
+			systemPrompt, userPrompt = e.fitPrompt(changes, diffContent, build)
+			continue
+		}
+		limit := (e.CountTokens(systemPrompt) + e.CountTokens(userPrompt)) / 2
+		systemPrompt, userPrompt = e.fitPromptWithin(changes, diffContent, build, limit)
+	}
+}
+
+// ask sends the prompts to the provider and hands the reply to read. A
+// reply that read rejects, for not being Conventional Commits in strict mode
+// or for a banned word, is quoted back to the model along with the reason,
+// and it is asked again. Providers that read changes themselves, such as the
+// mock provider, are given changes along with the prompts.
+func (e *Engine) ask(changes *Changes, systemPrompt, userPrompt string, read func(reply string) error) error {
+	for attempt := 1; ; attempt++ {
+		var completion *Completion
+		var err error
+		if changes != nil {
+			completion, err = provider.GenerateChanges(e.provider, changes, e.model, systemPrompt, userPrompt)
+		} else {
+			completion, err = e.provider.Generate(e.model, systemPrompt, userPrompt)
+		}
+		if err != nil {
+			return err
+		}
+		e.usage.PromptTokens += completion.Usage.PromptTokens
+		e.usage.CompletionTokens += completion.Usage.CompletionTokens
+		e.usage.CachedTokens += completion.Usage.CachedTokens
+		// Plugins may pass on the thinking of reasoning models
+		err = read(provider.StripThinking(completion.Content))
+		sentinel := rejected(err)
+		if sentinel == nil {
+			return err
+		}
+		if attempt == MaxAttempts || (sentinel == ErrBannedWord && e.bannedAction == BannedBlock) {
+			return fmt.Errorf("%w after %d attempt(s): %v", sentinel, attempt, err)
+		}
+		userPrompt = prompt.AddRejection(userPrompt, completion.Content, err)
+	}
+}
+
+// check validates a commit message taken from a reply and finishes it.
+// Outside strict mode the type of the subject is normalized; in strict
+// mode the message must parse as Conventional Commits with an allowed type,
+// which is lowercased.
+func (e *Engine) check(message string) (string, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return "", ErrEmptyMessage
+	}
+	subject, body, hasBody := strings.Cut(message, "\n")
+	if !e.strict {
+		subject = prompt.NormalizeCommitType(subject)
+		if hasBody {
+			subject += "\n" + body
+		}
+		return e.finish(subject)
+	}
+
+	parsed, err := conventional.Validate(message, conventional.Rules{Types: prompt.CommitTypes()})
+	if err != nil {
+		return "", err
+	}
+	return e.finish(strings.ToLower(parsed.Type) + message[len(parsed.Type):])
+}
+
+// finish applies the emoji style to a checked message and rejects it if its
+// subject contains a banned word
+func (e *Engine) finish(message string) (string, error) {
+	message = e.emoji.Apply(message)
+	subject, _, _ := strings.Cut(message, "\n")
+	if word := prompt.FindBanned(subject, e.bannedWords); word != "" {
+		return "", &bannedWordError{word: word}
+	}
+	return message, nil
+}
+
+// bannedWordError is a subject containing a banned word
+type bannedWordError struct {
+	word string
+}
+
+func (e *bannedWordError) Error() string {
+	return fmt.Sprintf("the subject contains the banned word %q", e.word)
+}
+
+// rejected returns the error a reply that read rejected ends with, or nil
+// if err doesn't reject the reply
+func rejected(err error) error {
+	var violation *conventional.Error
+	var banned *bannedWordError
+	switch {
+	case errors.As(err, &violation):
+		return ErrNotConventional
+	case errors.As(err, &banned):
+		return ErrBannedWord
+	}
+	return nil
+}
+
+// Run scans the repository and generates a commit message for its changes
+func (e *Engine) Run() (string, error) {
+	changes, diffContent, err := e.Scan()
+	if err != nil {
+		return "", err
+	}
+	return e.Generate(changes, diffContent)
+}
+
+// Validate extracts a single conventional commit line from a raw model reply
+// and normalizes its type
+func Validate(response string) (string, error) {
+	message := prompt.ExtractCommitMessage(response)
+	if strings.TrimSpace(message) == "" {
+		return "", ErrEmptyMessage
+	}
+	return message, nil
+}
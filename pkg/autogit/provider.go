@@ -0,0 +1,299 @@
+package autogit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"auto-git/internal/config"
+	"auto-git/internal/mock"
+	"auto-git/internal/ollama"
+	"auto-git/internal/openai"
+	"auto-git/internal/plugin"
+	"auto-git/internal/provider"
+	"auto-git/internal/transport"
+)
+
+const (
+	ProviderOllama      = "ollama"
+	ProviderSiliconFlow = "siliconflow"
+	ProviderOpenAI      = "openai"
+	// ProviderMock generates deterministic messages offline, without a model
+	ProviderMock = "mock"
+)
+
+// SupportedProviders lists the built-in provider names
+var SupportedProviders = []string{ProviderOllama, ProviderSiliconFlow, ProviderOpenAI, ProviderMock}
+
+// AvailableProviders lists the built-in providers followed by any installed plugins
+func AvailableProviders() []string {
+	names := append([]string{}, SupportedProviders...)
+	if dir, err := config.GetPluginDir(); err == nil {
+		for _, name := range plugin.Names(dir) {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Provider is implemented by every LLM backend
+type Provider = provider.Provider
+
+// Model describes a model offered by a provider
+type Model = provider.Model
+
+// Completion is a provider's raw reply together with its token usage
+type Completion = provider.Completion
+
+// Usage holds the token counts of a generation
+type Usage = provider.Usage
+
+// NewProvider creates a provider by name. An empty endpoint selects the
+// provider's default URL and an empty apiKey falls back to the environment.
+// Names that are not built in are looked up among the installed plugins.
+func NewProvider(name, endpoint, apiKey string) (Provider, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	switch name {
+	case ProviderOllama:
+		return ollama.NewClient(endpoint, apiKey), nil
+	case ProviderSiliconFlow:
+		return openai.NewClient(endpoint, apiKey, true), nil
+	case ProviderOpenAI:
+		return openai.NewClient(endpoint, apiKey, false), nil
+	case ProviderMock:
+		client, err := mock.NewClient()
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+
+	if dir, err := config.GetPluginDir(); err == nil {
+		if path, ok := plugin.Discover(dir)[name]; ok {
+			if apiKey == "" {
+				apiKey = APIKeyFromEnv(name)
+			}
+			return plugin.NewClient(name, path, endpoint, apiKey), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown provider type: %s (supported: %s)", name, strings.Join(AvailableProviders(), ", "))
+}
+
+// Endpoint returns the URL a provider sends requests to, or "" when it is not
+// known (plugins without a configured endpoint, the mock provider)
+func Endpoint(p Provider) string {
+	switch c := provider.Unwrap(p).(type) {
+	case *ollama.Client:
+		return unixEndpoint(c.Socket, c.BaseURL)
+	case *openai.Client:
+		return unixEndpoint(c.Socket, c.BaseURL)
+	case *plugin.Client:
+		return c.Endpoint
+	default:
+		return ""
+	}
+}
+
+// Ways to rotate several API keys given as a comma-separated list, see
+// SetKeyRotation
+const (
+	KeyRotationOnError    = transport.RotateOnError
+	KeyRotationRoundRobin = transport.RotateRoundRobin
+)
+
+// SetKeyRotation selects how a built-in HTTP provider with several API keys
+// picks one: KeyRotationOnError (the default) or KeyRotationRoundRobin.
+// Plugins and the mock provider ignore it.
+func SetKeyRotation(p Provider, mode string) error {
+	switch c := provider.Unwrap(p).(type) {
+	case *ollama.Client:
+		return c.Keys.SetRotation(mode)
+	case *openai.Client:
+		return c.Keys.SetRotation(mode)
+	}
+	return nil
+}
+
+// SetOpenAIAccount selects the organization and project the OpenAI provider
+// bills requests to, overriding OPENAI_ORG_ID and OPENAI_PROJECT_ID. Empty
+// values keep the environment's. Other providers ignore it.
+func SetOpenAIAccount(p Provider, organization, project string) {
+	c, ok := provider.Unwrap(p).(*openai.Client)
+	if !ok {
+		return
+	}
+	if organization = strings.TrimSpace(organization); organization != "" {
+		c.Organization = organization
+	}
+	if project = strings.TrimSpace(project); project != "" {
+		c.Project = project
+	}
+}
+
+// ErrReasoningOnly is returned when a reasoning model gave no answer besides
+// its thinking
+var ErrReasoningOnly = provider.ErrReasoningOnly
+
+// SetReasoning sets the reasoning effort ("minimal", "low", "medium" or
+// "high") and thinking budget of a built-in HTTP provider. OpenAI receives
+// reasoning_effort; a budget is sent as SiliconFlow's thinking_budget or
+// otherwise as the thinking parameter of Anthropic's OpenAI-compatible API.
+// Ollama turns on thinking when an effort is set. Plugins and the mock
+// provider ignore it.
+func SetReasoning(p Provider, effort string, budgetTokens int) error {
+	reasoning, err := provider.ParseReasoning(effort, budgetTokens)
+	if err != nil {
+		return err
+	}
+	switch c := provider.Unwrap(p).(type) {
+	case *ollama.Client:
+		c.Reasoning = reasoning
+	case *openai.Client:
+		c.Reasoning = reasoning
+	}
+	return nil
+}
+
+// Prompt caching modes accepted by SetPromptCache
+const (
+	PromptCacheOpenAI    = openai.CacheOpenAI
+	PromptCacheAnthropic = openai.CacheAnthropic
+)
+
+// SetPromptCache asks an OpenAI-compatible provider to cache the system
+// prompt: PromptCacheOpenAI sends a prompt_cache_key and PromptCacheAnthropic
+// marks the system prompt with cache_control. Empty leaves caching to the
+// endpoint. Ollama reuses a loaded model's cached prompt prefix on its own;
+// it, plugins and the mock provider ignore the setting.
+func SetPromptCache(p Provider, mode string) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "", PromptCacheOpenAI, PromptCacheAnthropic:
+	default:
+		return fmt.Errorf("unknown prompt_cache %q (supported: %s, %s)", mode, PromptCacheOpenAI, PromptCacheAnthropic)
+	}
+	if c, ok := provider.Unwrap(p).(*openai.Client); ok {
+		c.PromptCache = mode
+	}
+	return nil
+}
+
+// SetHeaders adds headers to every request of a built-in HTTP provider,
+// replacing the Authorization header when it is one of them. "${VAR}" and
+// "$VAR" in values are read from the environment. Plugins and the mock
+// provider ignore it.
+func SetHeaders(p Provider, headers map[string]string) {
+	var header http.Header
+	switch c := provider.Unwrap(p).(type) {
+	case *ollama.Client:
+		header = c.Header
+	case *openai.Client:
+		header = c.Header
+	default:
+		return
+	}
+	for name, value := range headers {
+		header.Set(name, os.ExpandEnv(value))
+	}
+}
+
+// FilterModels wraps p so that ListModels only returns the models whose name
+// matches the regular expression pattern. An empty pattern returns p as is.
+func FilterModels(p Provider, pattern string) (Provider, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return p, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid model_filter: %w", err)
+	}
+	return provider.FilterModels(p, re), nil
+}
+
+// SplitAPIKeys splits a comma-separated list of API keys
+func SplitAPIKeys(apiKey string) []string {
+	return transport.SplitKeys(apiKey)
+}
+
+// unixEndpoint returns the "unix://" endpoint of a client talking to socket,
+// or baseURL when it uses TCP
+func unixEndpoint(socket, baseURL string) string {
+	if socket == "" {
+		return baseURL
+	}
+	endpoint := "unix://" + socket
+	if u, err := url.Parse(baseURL); err == nil && u.Path != "" {
+		endpoint += "?path=" + u.Path
+	}
+	return endpoint
+}
+
+// CheckLocal returns an error unless every request made by p stays on this
+// machine: built-in HTTP providers must use a loopback endpoint or a Unix
+// domain socket, plugins cannot be verified, and the mock provider makes no
+// requests at all
+func CheckLocal(p Provider) error {
+	switch c := provider.Unwrap(p).(type) {
+	case *mock.Client:
+		return nil
+	case *plugin.Client:
+		return fmt.Errorf("plugin provider %q cannot be verified to stay local", c.Name)
+	}
+
+	endpoint := Endpoint(p)
+	if strings.HasPrefix(endpoint, "unix://") {
+		// Unix domain sockets only reach processes on this machine
+		return nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("cannot determine the host of endpoint %q", endpoint)
+	}
+	if !isLoopbackHost(u.Hostname()) {
+		return fmt.Errorf("endpoint %s is not a loopback address", endpoint)
+	}
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// APIKeyEnvVar returns the environment variable holding the API key for a provider
+func APIKeyEnvVar(name string) string {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case ProviderOllama:
+		return ollama.EnvAPIKey
+	case ProviderSiliconFlow:
+		return openai.EnvSiliconFlowAPIKey
+	case ProviderOpenAI:
+		return openai.EnvOpenAIAPIKey
+	case ProviderMock, "":
+		return ""
+	default:
+		// Plugins read <NAME>_API_KEY, e.g. MY_GATEWAY_API_KEY for my-gateway
+		return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(name), "-", "_")) + "_API_KEY"
+	}
+}
+
+// APIKeyFromEnv reads the API key for a provider from its environment variable
+func APIKeyFromEnv(name string) string {
+	envVar := APIKeyEnvVar(name)
+	if envVar == "" {
+		return ""
+	}
+	return strings.TrimSpace(os.Getenv(envVar))
+}
@@ -0,0 +1,152 @@
+// Package conventional parses commit messages according to the Conventional
+// Commits 1.0.0 specification (https://www.conventionalcommits.org/en/v1.0.0/),
+// reporting where a message departs from it. It also renders parsed messages,
+// validates them against a list of allowed types, and normalizes the types of
+// subjects written by models.
+//
+//	m, err := conventional.Validate("feat(parser): accept CRLF", conventional.Rules{Types: []string{"feat", "fix"}})
+//	subject := conventional.Normalize("Fix: handle EOF", []string{"feat", "fix"}, "chore") // "fix: handle EOF"
+package conventional
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Message is a parsed commit message
+type Message struct {
+	Type  string
+	Scope string
+	// Breaking is set by a "!" after the type or scope, or by a
+	// BREAKING CHANGE footer
+	Breaking    bool
+	Description string
+	Body        string
+	Footers     []Footer
+}
+
+// Footer is a git trailer-like footer, e.g. "Refs: #123" or "Closes #42"
+type Footer struct {
+	Token string
+	// Separator is ": " or " #"
+	Separator string
+	Value     string
+}
+
+// Error describes where a message departs from the specification
+type Error struct {
+	// Line is the 1-based line of the message the problem is on
+	Line   int
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+var (
+	// header is "type(scope)!: description"; the parts are checked one by
+	// one so that errors can say what is wrong
+	headerType  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*`)
+	headerScope = regexp.MustCompile(`^\(([^()\s][^()]*)\)`)
+	// footerLine starts a footer: "Token: value", "Token #value" or the
+	// special "BREAKING CHANGE: value"
+	footerLine = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z][A-Za-z0-9-]*)(: | #)(.*)$`)
+)
+
+// Parse parses message, which may end with a newline. It returns an *Error
+// for messages that don't follow the specification.
+func Parse(message string) (*Message, error) {
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(message, "\r\n", "\n"), "\n"), "\n")
+	m, err := parseHeader(lines[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 1 {
+		return m, nil
+	}
+	if strings.TrimSpace(lines[1]) != "" {
+		return nil, &Error{Line: 2, Reason: "the body must be separated from the header by a blank line"}
+	}
+
+	// Footers start with the first paragraph that opens with a footer; a
+	// footer's value runs until the next footer token
+	var body []string
+	footersAt := -1
+	for i := 2; i < len(lines); i++ {
+		if footersAt < 0 && strings.TrimSpace(lines[i-1]) == "" && footerLine.MatchString(lines[i]) {
+			footersAt = i
+		}
+		if footersAt < 0 {
+			body = append(body, lines[i])
+			continue
+		}
+		if f := footerLine.FindStringSubmatch(lines[i]); f != nil {
+			m.Footers = append(m.Footers, Footer{Token: f[1], Separator: f[2], Value: f[3]})
+			continue
+		}
+		last := &m.Footers[len(m.Footers)-1]
+		last.Value += "\n" + lines[i]
+	}
+	m.Body = strings.TrimSpace(strings.Join(body, "\n"))
+
+	for i := range m.Footers {
+		f := &m.Footers[i]
+		f.Value = strings.TrimSpace(f.Value)
+		if f.Token == "BREAKING-CHANGE" || f.Token == "BREAKING CHANGE" {
+			m.Breaking = true
+		}
+		if f.Value == "" {
+			return nil, &Error{Line: footerLineNumber(lines, footersAt, i), Reason: fmt.Sprintf("footer %q has no value", f.Token)}
+		}
+	}
+	return m, nil
+}
+
+// parseHeader parses "type(scope)!: description"
+func parseHeader(header string) (*Message, error) {
+	m := &Message{}
+	rest := header
+
+	m.Type = headerType.FindString(rest)
+	if m.Type == "" {
+		return nil, &Error{Line: 1, Reason: "the header must start with a type, such as \"feat\" or \"fix\""}
+	}
+	rest = rest[len(m.Type):]
+
+	if strings.HasPrefix(rest, "(") {
+		s := headerScope.FindStringSubmatch(rest)
+		if s == nil {
+			return nil, &Error{Line: 1, Reason: "the scope must be a noun in parentheses, such as \"(parser)\""}
+		}
+		m.Scope = strings.TrimSpace(s[1])
+		rest = rest[len(s[0]):]
+	}
+	if strings.HasPrefix(rest, "!") {
+		m.Breaking = true
+		rest = rest[1:]
+	}
+
+	if !strings.HasPrefix(rest, ": ") {
+		return nil, &Error{Line: 1, Reason: "the type must be followed by an optional scope, an optional \"!\" and \": \""}
+	}
+	m.Description = strings.TrimSpace(rest[2:])
+	if m.Description == "" {
+		return nil, &Error{Line: 1, Reason: "the description after \": \" is empty"}
+	}
+	return m, nil
+}
+
+// footerLineNumber returns the line number of the footer with index n
+func footerLineNumber(lines []string, from, n int) int {
+	for i := from; i < len(lines); i++ {
+		if footerLine.MatchString(lines[i]) {
+			if n == 0 {
+				return i + 1
+			}
+			n--
+		}
+	}
+	return len(lines)
+}
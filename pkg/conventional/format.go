@@ -0,0 +1,48 @@
+package conventional
+
+import "strings"
+
+// Header renders the first line, "type(scope)!: description"
+func (m *Message) Header() string {
+	var b strings.Builder
+	b.WriteString(m.Type)
+	if m.Scope != "" {
+		b.WriteString("(" + m.Scope + ")")
+	}
+	// A BREAKING CHANGE footer already marks the message as breaking
+	if m.Breaking && !m.hasBreakingFooter() {
+		b.WriteString("!")
+	}
+	b.WriteString(": " + m.Description)
+	return b.String()
+}
+
+// String renders the message: the header, the body and the footers, each
+// separated by a blank line. Parse(m.String()) returns an equal message.
+func (m *Message) String() string {
+	parts := []string{m.Header()}
+	if m.Body != "" {
+		parts = append(parts, m.Body)
+	}
+	if len(m.Footers) > 0 {
+		footers := make([]string, len(m.Footers))
+		for i, f := range m.Footers {
+			separator := f.Separator
+			if separator == "" {
+				separator = ": "
+			}
+			footers[i] = f.Token + separator + f.Value
+		}
+		parts = append(parts, strings.Join(footers, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func (m *Message) hasBreakingFooter() bool {
+	for _, f := range m.Footers {
+		if f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package conventional
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// shortcode matches emoji written as ":sparkles:"
+var shortcode = regexp.MustCompile(`^:[a-z0-9_+-]+:$`)
+
+// SplitEmoji splits a leading emoji, such as "✨" or ":sparkles:", and the
+// space after it off subject. Subjects without one are returned as the rest.
+func SplitEmoji(subject string) (emoji, rest string) {
+	token, after, ok := strings.Cut(subject, " ")
+	if !ok || !IsEmoji(token) {
+		return "", subject
+	}
+	return token, strings.TrimSpace(after)
+}
+
+// IsEmoji reports whether token is an emoji or a shortcode. Symbols outside
+// ASCII count as emoji, letters of any script don't.
+func IsEmoji(token string) bool {
+	if token == "" {
+		return false
+	}
+	if shortcode.MatchString(token) {
+		return true
+	}
+	for _, r := range token {
+		if r < 128 || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize fixes the type of a subject that models and people often get
+// slightly wrong. A type from types written in another case, e.g. "Feat:",
+// is lowercased as written in types; a leading emoji is kept. A subject
+// that doesn't start with any of the types gets fallback, e.g.
+// "chore: update files". Subjects are not otherwise checked; use Validate
+// for that.
+func Normalize(subject string, types []string, fallback string) string {
+	emoji, rest := SplitEmoji(strings.TrimSpace(subject))
+	if rest == "" {
+		return subject
+	}
+
+	word := headerType.FindString(rest)
+	if t := allowedType(word, types); t != "" && (len(rest) == len(word) || strings.ContainsRune("(!: ", rune(rest[len(word)]))) {
+		if t == word {
+			return subject
+		}
+		rest = t + rest[len(word):]
+		if emoji != "" {
+			return emoji + " " + rest
+		}
+		return rest
+	}
+
+	// Types that the header pattern doesn't match whole, e.g. "ci/cd", count
+	// only when followed by a scope, "!" or ":", not as the start of a word
+	// such as "featuring"
+	lower := strings.ToLower(rest)
+	for _, t := range types {
+		after, ok := strings.CutPrefix(lower, strings.ToLower(t))
+		if ok && after != "" && strings.ContainsRune("(!:", rune(after[0])) {
+			return subject
+		}
+	}
+	return fallback + ": " + subject
+}
@@ -0,0 +1,42 @@
+package conventional
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules are checks Validate makes on top of the specification
+type Rules struct {
+	// Types are the allowed types, matched case-insensitively; empty allows any
+	Types []string
+	// MaxHeaderLength limits the header in characters; 0 means no limit
+	MaxHeaderLength int
+}
+
+// Validate parses message and checks it against rules. Like Parse, it
+// returns an *Error describing the first problem found.
+func Validate(message string, rules Rules) (*Message, error) {
+	m, err := Parse(message)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules.Types) > 0 && allowedType(m.Type, rules.Types) == "" {
+		return nil, &Error{Line: 1, Reason: fmt.Sprintf("%q is not an allowed type (allowed: %s)", m.Type, strings.Join(rules.Types, ", "))}
+	}
+	header, _, _ := strings.Cut(message, "\n")
+	if n := len([]rune(strings.TrimSpace(header))); rules.MaxHeaderLength > 0 && n > rules.MaxHeaderLength {
+		return nil, &Error{Line: 1, Reason: fmt.Sprintf("the header is %d characters long, more than %d", n, rules.MaxHeaderLength)}
+	}
+	return m, nil
+}
+
+// allowedType returns the entry of types that typ matches case-insensitively,
+// or "" if there is none
+func allowedType(typ string, types []string) string {
+	for _, t := range types {
+		if strings.EqualFold(typ, t) {
+			return t
+		}
+	}
+	return ""
+}